@@ -0,0 +1,84 @@
+package squads
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+)
+
+// AddressLookupTableAccounts fetches and decodes the given address lookup tables
+func (s *Multisig) AddressLookupTableAccounts(ctx context.Context, addresses []solana.PublicKey) ([]addresslookuptable.KeyedAddressLookupTable, error) {
+	tables := []addresslookuptable.KeyedAddressLookupTable{}
+	if len(addresses) == 0 {
+		return tables, nil
+	}
+
+	out, err := s.client.GetMultipleAccounts(ctx, addresses...)
+	if err != nil {
+		return nil, err
+	}
+	for i, account := range out.Value {
+		if account == nil {
+			return nil, fmt.Errorf("address lookup table %s not found", addresses[i])
+		}
+		state, err := addresslookuptable.DecodeAddressLookupTableState(account.Data.GetBinary())
+		if err != nil {
+			return nil, fmt.Errorf("decode address lookup table %s: %w", addresses[i], err)
+		}
+		tables = append(tables, addresslookuptable.KeyedAddressLookupTable{
+			Key:   addresses[i],
+			State: *state,
+		})
+	}
+	return tables, nil
+}
+
+// vaultTransactionMessageRemainingAccounts returns the remaining accounts the program expects when executing a stored
+// vault transaction message: the address lookup table accounts, the static account keys, then for each lookup
+// the writable and readonly addresses it loads. The decoded lookup tables are returned so the outer
+// transaction can be compiled against them.
+func (s *Multisig) vaultTransactionMessageRemainingAccounts(ctx context.Context, message squads_multisig_program.VaultTransactionMessage) ([]*solana.AccountMeta, []addresslookuptable.KeyedAddressLookupTable, error) {
+	addressLookupTableAddresses := make([]solana.PublicKey, 0, len(message.AddressTableLookups))
+	for _, lookup := range message.AddressTableLookups {
+		addressLookupTableAddresses = append(addressLookupTableAddresses, lookup.AccountKey)
+	}
+	addressLookupTableAccounts, err := s.AddressLookupTableAccounts(ctx, addressLookupTableAddresses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var metas []*solana.AccountMeta
+	for _, address := range addressLookupTableAddresses {
+		metas = append(metas, solana.NewAccountMeta(address, false, false))
+	}
+	metas = append(metas, VaultTransactionMessageAccountMetas(message)...)
+
+	for i, lookup := range message.AddressTableLookups {
+		addresses := addressLookupTableAccounts[i].State.Addresses
+		for _, index := range lookup.WritableIndexes {
+			if int(index) >= len(addresses) {
+				return nil, nil, fmt.Errorf("address lookup table %s has no index %d", lookup.AccountKey, index)
+			}
+			metas = append(metas, solana.NewAccountMeta(addresses[index], true, false))
+		}
+		for _, index := range lookup.ReadonlyIndexes {
+			if int(index) >= len(addresses) {
+				return nil, nil, fmt.Errorf("address lookup table %s has no index %d", lookup.AccountKey, index)
+			}
+			metas = append(metas, solana.NewAccountMeta(addresses[index], false, false))
+		}
+	}
+	return metas, addressLookupTableAccounts, nil
+}
+
+// transactionAddressTables converts decoded lookup tables into the option used to compile a v0 transaction
+func transactionAddressTables(addressLookupTableAccounts []addresslookuptable.KeyedAddressLookupTable) map[solana.PublicKey]solana.PublicKeySlice {
+	tables := make(map[solana.PublicKey]solana.PublicKeySlice, len(addressLookupTableAccounts))
+	for _, table := range addressLookupTableAccounts {
+		tables[table.Key] = table.State.Addresses
+	}
+	return tables
+}
@@ -0,0 +1,163 @@
+package squads
+
+import (
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+func Test_VaultTransactionExecuteIxWithLookupTable(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+
+	transactionPda, err := GetTransactionPda(multisigPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vaultPda, err := GetVaultPda(multisigPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ephemeralSignerPda, err := GetEphemeralSignerPda(transactionPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := solana.NewWallet().PublicKey()
+	writable := solana.NewWallet().PublicKey()
+	readonly := solana.NewWallet().PublicKey()
+	destination := solana.NewWallet().PublicKey()
+	if err := client.SetAddressLookupTable(table, []solana.PublicKey{readonly, writable}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.SetBorshAccount(transactionPda, squads_multisig_program.VaultTransaction{
+		Multisig:             multisigPda,
+		Index:                1,
+		EphemeralSignerBumps: []byte{255},
+		Message: squads_multisig_program.VaultTransactionMessage{
+			NumSigners:            2,
+			NumWritableSigners:    2,
+			NumWritableNonSigners: 1,
+			AccountKeys:           []solana.PublicKey{vaultPda, ephemeralSignerPda, destination, solana.SystemProgramID},
+			Instructions:          []squads_multisig_program.MultisigCompiledInstruction{},
+			AddressTableLookups: []squads_multisig_program.MultisigMessageAddressTableLookup{{
+				AccountKey:      table,
+				WritableIndexes: []byte{1},
+				ReadonlyIndexes: []byte{0},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor := solana.NewWallet().PublicKey()
+	ix, err := s.VaultTransactionExecuteIx(t.Context(), executor, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*solana.AccountMeta{
+		solana.NewAccountMeta(table, false, false),
+		solana.NewAccountMeta(vaultPda, true, false),
+		solana.NewAccountMeta(ephemeralSignerPda, true, false),
+		solana.NewAccountMeta(destination, true, false),
+		solana.NewAccountMeta(solana.SystemProgramID, false, false),
+		solana.NewAccountMeta(writable, true, false),
+		solana.NewAccountMeta(readonly, false, false),
+	}
+	// the first four accounts are multisig, proposal, transaction and executor
+	got := ix.Accounts()[4:]
+	if len(got) != len(want) {
+		t.Fatalf("got %d remaining accounts, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if *got[i] != *want[i] {
+			t.Errorf("remaining account %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	tx, err := s.VaultTransactionExecuteTx(t.Context(), executor, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tx.Message.IsVersioned() {
+		t.Error("expected a v0 transaction")
+	}
+
+	// a declared ephemeral signer the message does not use is fine for the program
+	err = client.SetBorshAccount(transactionPda, squads_multisig_program.VaultTransaction{
+		Multisig:             multisigPda,
+		Index:                1,
+		EphemeralSignerBumps: []byte{255, 254},
+		Message: squads_multisig_program.VaultTransactionMessage{
+			NumSigners:         1,
+			NumWritableSigners: 1,
+			AccountKeys:        []solana.PublicKey{vaultPda, solana.SystemProgramID},
+			Instructions:       []squads_multisig_program.MultisigCompiledInstruction{},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VaultTransactionExecuteIx(t.Context(), executor, 1); err != nil {
+		t.Fatalf("unused ephemeral signers must not prevent execution: %v", err)
+	}
+}
+
+func Test_AddressLookupTableAccountsNotFound(t *testing.T) {
+	s := New(squadstest.NewClient(), solana.NewWallet().PublicKey())
+	if _, err := s.AddressLookupTableAccounts(t.Context(), []solana.PublicKey{solana.NewWallet().PublicKey()}); err == nil {
+		t.Fatal("expected an error for a missing lookup table")
+	}
+}
+
+func Test_VaultTransactionCreateIxWithOpts(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+	creator := solana.NewWallet().PublicKey()
+	vaultPda, err := GetVaultPda(multisigPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, destination := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	if err := client.SetAddressLookupTable(table, []solana.PublicKey{destination}); err != nil {
+		t.Fatal(err)
+	}
+	instructions := []solana.Instruction{system.NewTransferInstruction(1, vaultPda, destination).Build()}
+
+	message := func(ix solana.Instruction) squads_multisig_program.VaultTransactionMessage {
+		t.Helper()
+		create, err := squads_multisig_program.DecodeInstruction(ix.Accounts(), mustData(t, ix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeTransactionMessage(create.Impl.(*squads_multisig_program.VaultTransactionCreate).Args.TransactionMessage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decoded
+	}
+
+	ix, err := s.VaultTransactionCreateIx(t.Context(), creator, 0, 1, instructions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := message(ix); len(got.AddressTableLookups) != 0 || len(got.AccountKeys) != 3 {
+		t.Fatalf("expected static keys only, got %d keys and %d lookups", len(got.AccountKeys), len(got.AddressTableLookups))
+	}
+
+	ix, err = s.VaultTransactionCreateIxWithOpts(t.Context(), creator, 0, 1, instructions, &VaultTransactionMessageOpts{AddressLookupTables: []solana.PublicKey{table}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := message(ix)
+	if len(got.AddressTableLookups) != 1 || !got.AddressTableLookups[0].AccountKey.Equals(table) || len(got.AccountKeys) != 2 {
+		t.Fatalf("expected the destination to be loaded from the table, got %d keys and %d lookups", len(got.AccountKeys), len(got.AddressTableLookups))
+	}
+}
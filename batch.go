@@ -0,0 +1,368 @@
+package squads
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+)
+
+// BatchAccount retrieves the batch account information
+func (s *Multisig) BatchAccount(ctx context.Context, batchPda solana.PublicKey) (*squads_multisig_program.Batch, error) {
+	out, err := s.client.GetAccountInfo(ctx, batchPda)
+	if err != nil {
+		return nil, err
+	}
+	data := out.Value.Data.GetBinary()
+
+	account := &squads_multisig_program.Batch{}
+	decoder := ag_binary.NewBorshDecoder(data)
+	if err := account.UnmarshalWithDecoder(decoder); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// VaultBatchTransactionAccount retrieves the vault batch transaction account information
+func (s *Multisig) VaultBatchTransactionAccount(ctx context.Context, batchTransactionPda solana.PublicKey) (*squads_multisig_program.VaultBatchTransaction, error) {
+	out, err := s.client.GetAccountInfo(ctx, batchTransactionPda)
+	if err != nil {
+		return nil, err
+	}
+	data := out.Value.Data.GetBinary()
+
+	account := &squads_multisig_program.VaultBatchTransaction{}
+	decoder := ag_binary.NewBorshDecoder(data)
+	if err := account.UnmarshalWithDecoder(decoder); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// BatchCreateIx creates an instruction to create a batch.
+// A batch shares the transaction index space with vault and config transactions.
+func (s *Multisig) BatchCreateIx(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, batchIndex uint64, memo *string) (solana.Instruction, error) {
+	batchPda, err := GetTransactionPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	args := squads_multisig_program.BatchCreateArgs{
+		VaultIndex: vaultIndex,
+		Memo:       memo,
+	}
+
+	ix := squads_multisig_program.NewBatchCreateInstruction(
+		args,
+		s.multisigPda,
+		batchPda,
+		creatorAndPayer, // creator
+		creatorAndPayer,
+		solana.SystemProgramID,
+	).Build()
+
+	return ix, nil
+}
+
+// BatchCreateTx creates a transaction to create a batch
+func (s *Multisig) BatchCreateTx(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, batchIndex uint64, memo *string, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.BatchCreateIx(ctx, creatorAndPayer, vaultIndex, batchIndex, memo)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, creatorAndPayer, opts)
+}
+
+// BatchAddTransactionIx creates an instruction to add a transaction to a batch.
+// transactionIndex is the 1-based index of the transaction within the batch,
+// it must be the current batch size plus one.
+func (s *Multisig) BatchAddTransactionIx(ctx context.Context, memberAndPayer solana.PublicKey, vaultIndex uint8, batchIndex uint64, transactionIndex uint32, instructions []solana.Instruction) (solana.Instruction, error) {
+	return s.BatchAddTransactionIxWithOpts(ctx, memberAndPayer, vaultIndex, batchIndex, transactionIndex, instructions, nil)
+}
+
+// BatchAddTransactionIxWithOpts creates an instruction to add a transaction whose message is compiled with messageOpts to a batch
+func (s *Multisig) BatchAddTransactionIxWithOpts(ctx context.Context, memberAndPayer solana.PublicKey, vaultIndex uint8, batchIndex uint64, transactionIndex uint32, instructions []solana.Instruction, messageOpts *VaultTransactionMessageOpts) (solana.Instruction, error) {
+	batchPda, err := GetTransactionPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	proposalPda, err := GetProposalPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	batchTransactionPda, err := GetBatchTransactionPda(s.multisigPda, batchIndex, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	txMessageBytes, ephemeralSigners, err := s.vaultTransactionMessageBytes(ctx, batchTransactionPda, vaultIndex, instructions, messageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	args := squads_multisig_program.BatchAddTransactionArgs{
+		EphemeralSigners:   ephemeralSigners,
+		TransactionMessage: txMessageBytes,
+	}
+
+	ix := squads_multisig_program.NewBatchAddTransactionInstruction(
+		args,
+		s.multisigPda,
+		proposalPda,
+		batchPda,
+		batchTransactionPda,
+		memberAndPayer, // member
+		memberAndPayer,
+		solana.SystemProgramID,
+	).Build()
+
+	return ix, nil
+}
+
+// BatchAddTransactionTx creates a transaction to add a transaction to a batch
+func (s *Multisig) BatchAddTransactionTx(ctx context.Context, memberAndPayer solana.PublicKey, vaultIndex uint8, batchIndex uint64, transactionIndex uint32, instructions []solana.Instruction, opts ...TxOption) (*solana.Transaction, error) {
+	return s.BatchAddTransactionTxWithOpts(ctx, memberAndPayer, vaultIndex, batchIndex, transactionIndex, instructions, nil, opts...)
+}
+
+// BatchAddTransactionTxWithOpts creates a transaction to add a transaction whose message is compiled with messageOpts to a batch
+func (s *Multisig) BatchAddTransactionTxWithOpts(ctx context.Context, memberAndPayer solana.PublicKey, vaultIndex uint8, batchIndex uint64, transactionIndex uint32, instructions []solana.Instruction, messageOpts *VaultTransactionMessageOpts, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.BatchAddTransactionIxWithOpts(ctx, memberAndPayer, vaultIndex, batchIndex, transactionIndex, instructions, messageOpts)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, memberAndPayer, opts)
+}
+
+// BatchExecuteTransactionIx creates an instruction to execute the transaction at transactionIndex within a batch.
+// Batch transactions must be executed in order, starting from 1.
+func (s *Multisig) BatchExecuteTransactionIx(ctx context.Context, member solana.PublicKey, batchIndex uint64, transactionIndex uint32) (solana.Instruction, error) {
+	ix, _, err := s.batchExecuteTransactionIx(ctx, member, batchIndex, transactionIndex)
+	return ix, err
+}
+
+// batchExecuteTransactionIx creates the execute instruction along with the address lookup tables used by the batch transaction
+func (s *Multisig) batchExecuteTransactionIx(ctx context.Context, member solana.PublicKey, batchIndex uint64, transactionIndex uint32) (solana.Instruction, []addresslookuptable.KeyedAddressLookupTable, error) {
+	batchPda, err := GetTransactionPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	proposalPda, err := GetProposalPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	batchTransactionPda, err := GetBatchTransactionPda(s.multisigPda, batchIndex, transactionIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batchTransaction, err := s.VaultBatchTransactionAccount(ctx, batchTransactionPda)
+	if err != nil {
+		return nil, nil, err
+	}
+	remainingAccounts, addressLookupTableAccounts, err := s.vaultTransactionMessageRemainingAccounts(ctx, batchTransaction.Message)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ixb := squads_multisig_program.NewBatchExecuteTransactionInstruction(
+		s.multisigPda,
+		member,
+		proposalPda,
+		batchPda,
+		batchTransactionPda,
+	)
+
+	// Append the accounts referenced by the stored message
+	ixb.AccountMetaSlice = append(ixb.AccountMetaSlice, remainingAccounts...)
+
+	return ixb.Build(), addressLookupTableAccounts, nil
+}
+
+// BatchExecuteTransactionTx creates a transaction to execute a transaction within a batch
+func (s *Multisig) BatchExecuteTransactionTx(ctx context.Context, member solana.PublicKey, batchIndex uint64, transactionIndex uint32, opts ...TxOption) (*solana.Transaction, error) {
+	ix, addressLookupTableAccounts, err := s.batchExecuteTransactionIx(ctx, member, batchIndex, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, member, opts, solana.TransactionAddressTables(transactionAddressTables(addressLookupTableAccounts)))
+}
+
+// VaultBatchTransactionAccountCloseIx creates an instruction to close a batch transaction account.
+// Only the current last transaction of the batch can be closed.
+func (s *Multisig) VaultBatchTransactionAccountCloseIx(ctx context.Context, rentCollector solana.PublicKey, batchIndex uint64, transactionIndex uint32) (solana.Instruction, error) {
+	batchPda, err := GetTransactionPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	proposalPda, err := GetProposalPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	batchTransactionPda, err := GetBatchTransactionPda(s.multisigPda, batchIndex, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := squads_multisig_program.NewVaultBatchTransactionAccountCloseInstruction(
+		s.multisigPda,
+		proposalPda,
+		batchPda,
+		batchTransactionPda,
+		rentCollector,
+		solana.SystemProgramID,
+	).Build()
+
+	return ix, nil
+}
+
+// VaultBatchTransactionAccountCloseTx creates a transaction to close a batch transaction account
+func (s *Multisig) VaultBatchTransactionAccountCloseTx(ctx context.Context, rentCollector solana.PublicKey, batchIndex uint64, transactionIndex uint32, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.VaultBatchTransactionAccountCloseIx(ctx, rentCollector, batchIndex, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentCollector, opts)
+}
+
+// BatchAccountsCloseIx creates an instruction to close a batch and its proposal.
+// All batch transaction accounts must be closed first.
+func (s *Multisig) BatchAccountsCloseIx(ctx context.Context, rentCollector solana.PublicKey, batchIndex uint64) (solana.Instruction, error) {
+	batchPda, err := GetTransactionPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	proposalPda, err := GetProposalPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := squads_multisig_program.NewBatchAccountsCloseInstruction(
+		s.multisigPda,
+		proposalPda,
+		batchPda,
+		rentCollector,
+		solana.SystemProgramID,
+	).Build()
+
+	return ix, nil
+}
+
+// BatchAccountsCloseTx creates a transaction to close a batch and its proposal
+func (s *Multisig) BatchAccountsCloseTx(ctx context.Context, rentCollector solana.PublicKey, batchIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.BatchAccountsCloseIx(ctx, rentCollector, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentCollector, opts)
+}
+
+// BatchCreateWithTransactionsTxs creates the transactions needed to set up a batch for voting:
+// the batch with its draft proposal, one transaction per instruction group added as a batch transaction,
+// and the proposal activation. The transactions must be sent in the returned order.
+// If batchIndex is 0, the next transaction index of the multisig is used.
+func (s *Multisig) BatchCreateWithTransactionsTxs(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, batchIndex uint64, memo *string, instructionGroups [][]solana.Instruction, opts ...TxOption) ([]*solana.Transaction, error) {
+	return s.BatchCreateWithTransactionsTxsWithOpts(ctx, creatorAndPayer, vaultIndex, batchIndex, memo, instructionGroups, nil, opts...)
+}
+
+// BatchCreateWithTransactionsTxsWithOpts is BatchCreateWithTransactionsTxs with the message of every
+// instruction group compiled with messageOpts
+func (s *Multisig) BatchCreateWithTransactionsTxsWithOpts(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, batchIndex uint64, memo *string, instructionGroups [][]solana.Instruction, messageOpts *VaultTransactionMessageOpts, opts ...TxOption) ([]*solana.Transaction, error) {
+	if len(instructionGroups) == 0 {
+		return nil, errors.New("batch requires at least one instruction group")
+	}
+	if batchIndex == 0 {
+		multisigInfo, err := s.MultisigAccount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		batchIndex = multisigInfo.TransactionIndex + 1
+	}
+	proposalPda, err := GetProposalPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := newTxBuilder(ctx, s.client, opts)
+	if err != nil {
+		return nil, err
+	}
+	newTx := func(ixs ...solana.Instruction) (*solana.Transaction, error) {
+		return builder.build(ctx, ixs, creatorAndPayer)
+	}
+
+	bcIx, err := s.BatchCreateIx(ctx, creatorAndPayer, vaultIndex, batchIndex, memo)
+	if err != nil {
+		return nil, err
+	}
+	// batch transactions can only be added while the proposal is a draft
+	pcIx := squads_multisig_program.NewProposalCreateInstruction(
+		squads_multisig_program.ProposalCreateArgs{
+			TransactionIndex: batchIndex,
+			Draft:            true,
+		},
+		s.multisigPda,
+		proposalPda,
+		creatorAndPayer,
+		creatorAndPayer,
+		solana.SystemProgramID,
+	).Build()
+	createTx, err := newTx(bcIx, pcIx)
+	if err != nil {
+		return nil, err
+	}
+	txs := []*solana.Transaction{createTx}
+
+	for i, instructions := range instructionGroups {
+		batIx, err := s.BatchAddTransactionIxWithOpts(ctx, creatorAndPayer, vaultIndex, batchIndex, uint32(i+1), instructions, messageOpts)
+		if err != nil {
+			return nil, err
+		}
+		batTx, err := newTx(batIx)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, batTx)
+	}
+
+	paIx, err := s.ProposalActivateIx(ctx, creatorAndPayer, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	activateTx, err := newTx(paIx)
+	if err != nil {
+		return nil, err
+	}
+	return append(txs, activateTx), nil
+}
+
+// BatchExecuteTxs creates one transaction per batch transaction that has not been executed yet.
+// The proposal must be approved, the transactions must be sent in the returned order.
+func (s *Multisig) BatchExecuteTxs(ctx context.Context, member solana.PublicKey, batchIndex uint64, opts ...TxOption) ([]*solana.Transaction, error) {
+	batchPda, err := GetTransactionPda(s.multisigPda, batchIndex)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := s.BatchAccount(ctx, batchPda)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := newTxBuilder(ctx, s.client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []*solana.Transaction
+	for transactionIndex := batch.ExecutedTransactionIndex + 1; transactionIndex <= batch.Size; transactionIndex++ {
+		ix, addressLookupTableAccounts, err := s.batchExecuteTransactionIx(ctx, member, batchIndex, transactionIndex)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := builder.build(ctx, []solana.Instruction{ix}, member, solana.TransactionAddressTables(transactionAddressTables(addressLookupTableAccounts)))
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
@@ -0,0 +1,205 @@
+package squads
+
+import (
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+// squadsInstructionsOf decodes the multisig program instructions of a transaction
+func squadsInstructionsOf(t *testing.T, tx *solana.Transaction) []*squads_multisig_program.Instruction {
+	t.Helper()
+	var instructions []*squads_multisig_program.Instruction
+	for _, compiled := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(compiled.ProgramIDIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !programID.Equals(squads_multisig_program.ProgramID) {
+			continue
+		}
+		accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := squads_multisig_program.DecodeInstruction(accounts, compiled.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		instructions = append(instructions, decoded)
+	}
+	return instructions
+}
+
+func assertAccounts(t *testing.T, name string, got []*solana.AccountMeta, want ...solana.PublicKey) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d accounts, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].PublicKey.Equals(want[i]) {
+			t.Errorf("%s: account %d is %s, want %s", name, i, got[i].PublicKey, want[i])
+		}
+	}
+}
+
+func Test_BatchCreateWithTransactions(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+	creator := solana.NewWallet().PublicKey()
+	if err := client.SetBorshAccount(multisigPda, squads_multisig_program.Multisig{TransactionIndex: 4}); err != nil {
+		t.Fatal(err)
+	}
+	vaultPda, err := GetVaultPda(multisigPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := [][]solana.Instruction{
+		{system.NewTransferInstruction(1, vaultPda, solana.NewWallet().PublicKey()).Build()},
+		{system.NewTransferInstruction(2, vaultPda, solana.NewWallet().PublicKey()).Build()},
+	}
+
+	txs, err := s.BatchCreateWithTransactionsTxs(t.Context(), creator, 0, 0, nil, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 4 {
+		t.Fatalf("expected create, 2 add and activate transactions, got %d", len(txs))
+	}
+	batchPda, _ := GetTransactionPda(multisigPda, 5)
+	proposalPda, _ := GetProposalPda(multisigPda, 5)
+
+	create := squadsInstructionsOf(t, txs[0])
+	if len(create) != 2 {
+		t.Fatalf("expected batch and proposal creation, got %d instructions", len(create))
+	}
+	batchCreate, ok := create[0].Impl.(*squads_multisig_program.BatchCreate)
+	if !ok {
+		t.Fatalf("expected BatchCreate, got %T", create[0].Impl)
+	}
+	assertAccounts(t, "BatchCreate", batchCreate.AccountMetaSlice, multisigPda, batchPda, creator, creator, solana.SystemProgramID)
+	proposalCreate, ok := create[1].Impl.(*squads_multisig_program.ProposalCreate)
+	if !ok || proposalCreate.Args.TransactionIndex != 5 || !proposalCreate.Args.Draft {
+		t.Fatalf("expected a draft proposal for index 5, got %+v", create[1].Impl)
+	}
+
+	for i, tx := range txs[1:3] {
+		add, ok := squadsInstructionsOf(t, tx)[0].Impl.(*squads_multisig_program.BatchAddTransaction)
+		if !ok {
+			t.Fatalf("transaction %d: expected BatchAddTransaction", i+1)
+		}
+		batchTransactionPda, _ := GetBatchTransactionPda(multisigPda, 5, uint32(i+1))
+		assertAccounts(t, "BatchAddTransaction", add.AccountMetaSlice, multisigPda, proposalPda, batchPda, batchTransactionPda, creator, creator, solana.SystemProgramID)
+		message, err := DecodeTransactionMessage(add.Args.TransactionMessage)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !message.AccountKeys[0].Equals(vaultPda) {
+			t.Errorf("transaction %d: the vault must pay the batch transaction", i+1)
+		}
+	}
+
+	activate, ok := squadsInstructionsOf(t, txs[3])[0].Impl.(*squads_multisig_program.ProposalActivate)
+	if !ok {
+		t.Fatal("expected ProposalActivate last")
+	}
+	assertAccounts(t, "ProposalActivate", activate.AccountMetaSlice, multisigPda, creator, proposalPda)
+}
+
+func Test_BatchExecuteTxs(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+	member := solana.NewWallet().PublicKey()
+	batchPda, _ := GetTransactionPda(multisigPda, 2)
+	proposalPda, _ := GetProposalPda(multisigPda, 2)
+	vaultPda, _ := GetVaultPda(multisigPda, 0)
+
+	// the first of the three batch transactions already ran
+	err := client.SetBorshAccount(batchPda, squads_multisig_program.Batch{Multisig: multisigPda, Index: 2, Size: 3, ExecutedTransactionIndex: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	destinations := map[uint32]solana.PublicKey{}
+	for index := uint32(2); index <= 3; index++ {
+		batchTransactionPda, _ := GetBatchTransactionPda(multisigPda, 2, index)
+		destinations[index] = solana.NewWallet().PublicKey()
+		err := client.SetBorshAccount(batchTransactionPda, squads_multisig_program.VaultBatchTransaction{
+			Message: squads_multisig_program.VaultTransactionMessage{
+				NumSigners:            1,
+				NumWritableSigners:    1,
+				NumWritableNonSigners: 1,
+				AccountKeys:           []solana.PublicKey{vaultPda, destinations[index], solana.SystemProgramID},
+				Instructions:          []squads_multisig_program.MultisigCompiledInstruction{{ProgramIdIndex: 2, AccountIndexes: []byte{0, 1}, Data: []byte{2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	txs, err := s.BatchExecuteTxs(t.Context(), member, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected the 2 pending batch transactions, got %d", len(txs))
+	}
+	for i, tx := range txs {
+		index := uint32(i + 2)
+		execute, ok := squadsInstructionsOf(t, tx)[0].Impl.(*squads_multisig_program.BatchExecuteTransaction)
+		if !ok {
+			t.Fatalf("transaction %d: expected BatchExecuteTransaction", i)
+		}
+		batchTransactionPda, _ := GetBatchTransactionPda(multisigPda, 2, index)
+		assertAccounts(t, "BatchExecuteTransaction", execute.AccountMetaSlice,
+			multisigPda, member, proposalPda, batchPda, batchTransactionPda,
+			// remaining accounts of the stored message
+			vaultPda, destinations[index], solana.SystemProgramID,
+		)
+		if remaining := execute.AccountMetaSlice[5:]; !remaining[0].IsWritable || remaining[0].IsSigner || !remaining[1].IsWritable || remaining[2].IsWritable {
+			t.Errorf("transaction %d: unexpected remaining account flags %+v", i, remaining)
+		}
+	}
+
+	// nothing left once every batch transaction ran
+	err = client.SetBorshAccount(batchPda, squads_multisig_program.Batch{Multisig: multisigPda, Index: 2, Size: 3, ExecutedTransactionIndex: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txs, err := s.BatchExecuteTxs(t.Context(), member, 2); err != nil || len(txs) != 0 {
+		t.Fatalf("expected no transactions, got %d: %v", len(txs), err)
+	}
+}
+
+func Test_BatchCloseBuilders(t *testing.T) {
+	multisigPda, rentCollector := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	s := New(squadstest.NewClient(), multisigPda)
+	batchPda, _ := GetTransactionPda(multisigPda, 7)
+	proposalPda, _ := GetProposalPda(multisigPda, 7)
+	batchTransactionPda, _ := GetBatchTransactionPda(multisigPda, 7, 3)
+
+	ix, err := s.VaultBatchTransactionAccountCloseIx(t.Context(), rentCollector, 7, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccounts(t, "VaultBatchTransactionAccountClose", ix.Accounts(), multisigPda, proposalPda, batchPda, batchTransactionPda, rentCollector, solana.SystemProgramID)
+
+	ix, err = s.BatchAccountsCloseIx(t.Context(), rentCollector, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertAccounts(t, "BatchAccountsClose", ix.Accounts(), multisigPda, proposalPda, batchPda, rentCollector, solana.SystemProgramID)
+
+	tx, err := s.BatchAccountsCloseTx(t.Context(), rentCollector, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := squadsInstructionsOf(t, tx)[0].Impl.(*squads_multisig_program.BatchAccountsClose); !ok || !tx.Message.AccountKeys[0].Equals(rentCollector) {
+		t.Fatal("expected a BatchAccountsClose transaction paid by the rent collector")
+	}
+}
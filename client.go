@@ -0,0 +1,20 @@
+package squads
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// RPCClient is the subset of the Solana RPC API used by the SDK.
+// *rpc.Client implements it, squadstest.Client provides an in-memory implementation for tests.
+type RPCClient interface {
+	GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error)
+	GetAccountDataInto(ctx context.Context, account solana.PublicKey, inVar interface{}) error
+	GetMultipleAccounts(ctx context.Context, accounts ...solana.PublicKey) (*rpc.GetMultipleAccountsResult, error)
+	GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error)
+	GetProgramAccountsWithOpts(ctx context.Context, publicKey solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error)
+}
+
+var _ RPCClient = (*rpc.Client)(nil)
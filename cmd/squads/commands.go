@@ -0,0 +1,687 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	squads "github.com/Lee0x273/go-squads"
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+func base64Encode(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// stringsFlag is a repeatable string flag
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// parsePermissions parses a comma separated list of initiate, vote and execute, or "all"
+func parsePermissions(value string) (squads.Permission, error) {
+	var permissions squads.Permission
+	for _, name := range strings.Split(value, ",") {
+		switch strings.TrimSpace(strings.ToLower(name)) {
+		case "initiate":
+			permissions |= squads.Initiate
+		case "vote":
+			permissions |= squads.Vote
+		case "execute":
+			permissions |= squads.Execute
+		case "all":
+			permissions |= squads.Initiate | squads.Vote | squads.Execute
+		case "", "none":
+		default:
+			return 0, fmt.Errorf("unknown permission %q", name)
+		}
+	}
+	return permissions, nil
+}
+
+// parseMember parses "pubkey" or "pubkey:permissions", members get every permission by default
+func parseMember(value string) (squads_multisig_program.Member, error) {
+	key, perms, found := strings.Cut(value, ":")
+	pubkey, err := solana.PublicKeyFromBase58(key)
+	if err != nil {
+		return squads_multisig_program.Member{}, fmt.Errorf("member %q: %w", key, err)
+	}
+	permissions := squads.Initiate | squads.Vote | squads.Execute
+	if found {
+		if permissions, err = parsePermissions(perms); err != nil {
+			return squads_multisig_program.Member{}, err
+		}
+	}
+	return squads_multisig_program.Member{
+		Key:         pubkey,
+		Permissions: squads_multisig_program.Permissions{Mask: uint8(permissions)},
+	}, nil
+}
+
+// parseAmount converts a decimal amount such as "1.5" into base units
+func parseAmount(value string, decimals uint8) (uint64, error) {
+	whole, fraction, _ := strings.Cut(value, ".")
+	if len(fraction) > int(decimals) {
+		return 0, fmt.Errorf("amount %s has more than %d decimals", value, decimals)
+	}
+	digits := whole + fraction + strings.Repeat("0", int(decimals)-len(fraction))
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return 0, fmt.Errorf("amount %s must be positive", value)
+	}
+	var amount uint64
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid amount %s", value)
+		}
+		digit := uint64(c - '0')
+		if amount > (math.MaxUint64-digit)/10 {
+			return 0, fmt.Errorf("amount %s overflows", value)
+		}
+		amount = amount*10 + digit
+	}
+	return amount, nil
+}
+
+func optionalPublicKey(value string) (*solana.PublicKey, error) {
+	if value == "" {
+		return nil, nil
+	}
+	key, err := solana.PublicKeyFromBase58(value)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+type signaturesResult struct {
+	Signatures []solana.Signature `json:"signatures"`
+}
+
+func (r signaturesResult) text(w io.Writer) {
+	for _, signature := range r.Signatures {
+		fmt.Fprintln(w, "Signature:", signature)
+	}
+}
+
+func runCreate(ctx context.Context, e *env, args []string) error {
+	fs := e.flagSet("create", "--member <pubkey[:permissions]>... --threshold <n> [flags]")
+	var members stringsFlag
+	fs.Var(&members, "member", "member as pubkey or pubkey:initiate,vote,execute, repeatable; the keypair is not added implicitly")
+	threshold := fs.Uint("threshold", 1, "number of approvals required")
+	timeLock := fs.Uint("timelock", 0, "seconds between approval and execution")
+	configAuthority := fs.String("config-authority", "", "config authority, the multisig is autonomous when empty")
+	rentCollector := fs.String("rent-collector", "", "account receiving the rent of closed accounts, closing is disabled when empty")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return errors.New("at least one --member is required")
+	}
+
+	parsed := make([]squads_multisig_program.Member, 0, len(members))
+	for _, member := range members {
+		m, err := parseMember(member)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, m)
+	}
+	authority, err := optionalPublicKey(*configAuthority)
+	if err != nil {
+		return err
+	}
+	collector, err := optionalPublicKey(*rentCollector)
+	if err != nil {
+		return err
+	}
+	keypair, err := e.signer()
+	if err != nil {
+		return err
+	}
+
+	createKey := solana.NewWallet().PrivateKey
+	tx, multisigPda, err := squads.CreateMultisigTx(ctx, e.client, createKey.PublicKey(), keypair.PublicKey(), authority, parsed, uint16(*threshold), uint32(*timeLock), collector)
+	if err != nil {
+		return err
+	}
+	signatures, err := e.submit(ctx, []*solana.Transaction{tx}, createKey)
+	if err != nil || e.dryRun {
+		return err
+	}
+	vault, err := squads.GetVaultPda(multisigPda, 0)
+	if err != nil {
+		return err
+	}
+	result := struct {
+		Multisig solana.PublicKey `json:"multisig"`
+		Vault    solana.PublicKey `json:"vault"`
+		signaturesResult
+	}{multisigPda, vault, signaturesResult{signatures}}
+	return e.print(result, func(w io.Writer) {
+		fmt.Fprintln(w, "Multisig:", result.Multisig)
+		fmt.Fprintln(w, "Vault 0: ", result.Vault)
+		result.text(w)
+	})
+}
+
+type memberInfo struct {
+	Key         solana.PublicKey  `json:"key"`
+	Permissions squads.Permission `json:"mask"`
+	Names       string            `json:"permissions"`
+}
+
+func runInfo(ctx context.Context, e *env, args []string) error {
+	fs := e.flagSet("info", "--multisig <address> [flags]")
+	address := multisigFlag(fs)
+	vaultIndex := fs.Uint("vault-index", 0, "index of the vault to show")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	multisigPda, err := parseMultisig(*address)
+	if err != nil {
+		return err
+	}
+	multisig, err := squads.New(e.client, multisigPda).MultisigAccount(ctx)
+	if err != nil {
+		return err
+	}
+	vault, err := squads.GetVaultPda(multisigPda, uint8(*vaultIndex))
+	if err != nil {
+		return err
+	}
+
+	info := struct {
+		Address               solana.PublicKey  `json:"address"`
+		Vault                 solana.PublicKey  `json:"vault"`
+		VaultIndex            uint              `json:"vaultIndex"`
+		Threshold             uint16            `json:"threshold"`
+		TimeLock              uint32            `json:"timeLock"`
+		TransactionIndex      uint64            `json:"transactionIndex"`
+		StaleTransactionIndex uint64            `json:"staleTransactionIndex"`
+		ConfigAuthority       *solana.PublicKey `json:"configAuthority"`
+		RentCollector         *solana.PublicKey `json:"rentCollector"`
+		Members               []memberInfo      `json:"members"`
+	}{
+		Address:               multisigPda,
+		Vault:                 vault,
+		VaultIndex:            *vaultIndex,
+		Threshold:             multisig.Threshold,
+		TimeLock:              multisig.TimeLock,
+		TransactionIndex:      multisig.TransactionIndex,
+		StaleTransactionIndex: multisig.StaleTransactionIndex,
+		RentCollector:         multisig.RentCollector,
+		Members:               []memberInfo{},
+	}
+	if !multisig.ConfigAuthority.IsZero() {
+		info.ConfigAuthority = &multisig.ConfigAuthority
+	}
+	for _, member := range multisig.Members {
+		permissions := squads.Permission(member.Permissions.Mask)
+		info.Members = append(info.Members, memberInfo{Key: member.Key, Permissions: permissions, Names: permissions.String()})
+	}
+
+	return e.print(info, func(w io.Writer) {
+		fmt.Fprintln(w, "Multisig:         ", info.Address)
+		fmt.Fprintf(w, "Vault %d:           %s\n", info.VaultIndex, info.Vault)
+		fmt.Fprintf(w, "Threshold:          %d of %d\n", info.Threshold, len(info.Members))
+		fmt.Fprintln(w, "Time lock:         ", time.Duration(info.TimeLock)*time.Second)
+		fmt.Fprintln(w, "Transaction index: ", info.TransactionIndex)
+		fmt.Fprintln(w, "Stale index:       ", info.StaleTransactionIndex)
+		if info.ConfigAuthority != nil {
+			fmt.Fprintln(w, "Config authority:  ", *info.ConfigAuthority)
+		} else {
+			fmt.Fprintln(w, "Config authority:   none, changes go through config transactions")
+		}
+		if info.RentCollector != nil {
+			fmt.Fprintln(w, "Rent collector:    ", *info.RentCollector)
+		}
+		fmt.Fprintln(w, "Members:")
+		for _, member := range info.Members {
+			fmt.Fprintf(w, "  %s  %s\n", member.Key, member.Names)
+		}
+	})
+}
+
+func runProposals(ctx context.Context, e *env, args []string) error {
+	fs := e.flagSet("proposals", "--multisig <address> [--status Active,Approved] [flags]")
+	address := multisigFlag(fs)
+	statusFilter := fs.String("status", "", "comma separated statuses to keep: Draft, Active, Rejected, Approved, Executing, Executed, Cancelled")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	multisigPda, err := parseMultisig(*address)
+	if err != nil {
+		return err
+	}
+	var statuses []squads.ProposalStatus
+	if *statusFilter != "" {
+		for _, name := range strings.Split(*statusFilter, ",") {
+			var status squads.ProposalStatus
+			if err := status.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+				return err
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	s := squads.New(e.client, multisigPda)
+	multisig, err := s.MultisigAccount(ctx)
+	if err != nil {
+		return err
+	}
+	proposals, err := s.Proposals(ctx, statuses...)
+	if err != nil {
+		return err
+	}
+	states := make([]*squads.ProposalState, 0, len(proposals))
+	for _, proposal := range proposals {
+		states = append(states, squads.NewProposalState(multisig, proposal.Account))
+	}
+	return e.print(states, func(w io.Writer) {
+		if len(states) == 0 {
+			fmt.Fprintln(w, "No proposals")
+			return
+		}
+		fmt.Fprintf(w, "%-8s %-10s %-9s %-9s %s\n", "INDEX", "STATUS", "APPROVED", "REJECTED", "SINCE")
+		for _, state := range states {
+			since := ""
+			if ts, ok := state.Timestamp(); ok {
+				since = ts.Format(time.RFC3339)
+			}
+			if state.Stale {
+				since += " (stale)"
+			}
+			fmt.Fprintf(w, "%-8d %-10s %-9s %-9d %s\n", state.TransactionIndex, state.Status,
+				fmt.Sprintf("%d/%d", len(state.Approved), state.Threshold), len(state.Rejected), since)
+		}
+	})
+}
+
+func runTransfer(ctx context.Context, e *env, args []string) error {
+	fs := e.flagSet("transfer", "--multisig <address> --to <address> --amount <amount> [--mint <mint>] [flags]")
+	address := multisigFlag(fs)
+	vaultIndex := fs.Uint("vault-index", 0, "index of the vault to transfer from")
+	to := fs.String("to", "", "recipient wallet (required)")
+	amountFlag := fs.String("amount", "", "amount in SOL, or in tokens with --mint (required)")
+	mintFlag := fs.String("mint", "", "SPL token mint, transfers SOL when empty; the recipient token account must exist")
+	approve := fs.Bool("approve", false, "approve the proposal in the same transaction, the keypair must be a voter")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	multisigPda, err := parseMultisig(*address)
+	if err != nil {
+		return err
+	}
+	if *to == "" || *amountFlag == "" {
+		return errors.New("--to and --amount are required")
+	}
+	recipient, err := solana.PublicKeyFromBase58(*to)
+	if err != nil {
+		return err
+	}
+	keypair, err := e.signer()
+	if err != nil {
+		return err
+	}
+	vault, err := squads.GetVaultPda(multisigPda, uint8(*vaultIndex))
+	if err != nil {
+		return err
+	}
+
+	var ix solana.Instruction
+	if *mintFlag == "" {
+		lamports, err := parseAmount(*amountFlag, 9)
+		if err != nil {
+			return err
+		}
+		ix = system.NewTransferInstruction(lamports, vault, recipient).Build()
+	} else {
+		mint, err := solana.PublicKeyFromBase58(*mintFlag)
+		if err != nil {
+			return err
+		}
+		var mintAccount token.Mint
+		if err := e.client.GetAccountDataInto(ctx, mint, &mintAccount); err != nil {
+			return fmt.Errorf("load mint %s: %w", mint, err)
+		}
+		amount, err := parseAmount(*amountFlag, mintAccount.Decimals)
+		if err != nil {
+			return err
+		}
+		source, _, err := solana.FindAssociatedTokenAddress(vault, mint)
+		if err != nil {
+			return err
+		}
+		destination, _, err := solana.FindAssociatedTokenAddress(recipient, mint)
+		if err != nil {
+			return err
+		}
+		ix = token.NewTransferCheckedInstruction(amount, mintAccount.Decimals, source, mint, destination, vault, nil).Build()
+	}
+
+	s := squads.New(e.client, multisigPda)
+	multisig, err := s.MultisigAccount(ctx)
+	if err != nil {
+		return err
+	}
+	transactionIndex := multisig.TransactionIndex + 1
+	tx, err := s.VaultTransactionAndProposalTx(ctx, keypair.PublicKey(), uint8(*vaultIndex), transactionIndex, []solana.Instruction{ix}, *approve)
+	if err != nil {
+		return err
+	}
+	signatures, err := e.submit(ctx, []*solana.Transaction{tx})
+	if err != nil || e.dryRun {
+		return err
+	}
+	result := struct {
+		TransactionIndex uint64 `json:"transactionIndex"`
+		signaturesResult
+	}{transactionIndex, signaturesResult{signatures}}
+	return e.print(result, func(w io.Writer) {
+		fmt.Fprintln(w, "Transaction index:", result.TransactionIndex)
+		result.text(w)
+	})
+}
+
+type voteKind int
+
+const (
+	voteApprove voteKind = iota
+	voteReject
+	voteCancel
+)
+
+func runVote(kind voteKind) func(ctx context.Context, e *env, args []string) error {
+	name := [...]string{"approve", "reject", "cancel"}[kind]
+	return func(ctx context.Context, e *env, args []string) error {
+		fs := e.flagSet(name, "--multisig <address> --index <transaction index> [flags]")
+		address := multisigFlag(fs)
+		index := fs.Uint64("index", 0, "transaction index of the proposal (required)")
+		if err := e.parse(fs, args); err != nil {
+			return err
+		}
+		multisigPda, err := parseMultisig(*address)
+		if err != nil {
+			return err
+		}
+		if *index == 0 {
+			return errors.New("--index is required")
+		}
+		keypair, err := e.signer()
+		if err != nil {
+			return err
+		}
+
+		s := squads.New(e.client, multisigPda)
+		var ix solana.Instruction
+		switch kind {
+		case voteApprove:
+			ix, err = s.ProposalApproveIx(ctx, keypair.PublicKey(), *index)
+		case voteReject:
+			ix, err = s.ProposalRejectIx(ctx, keypair.PublicKey(), *index)
+		case voteCancel:
+			ix, err = s.ProposalCancelV2Ix(ctx, keypair.PublicKey(), *index)
+		}
+		if err != nil {
+			return err
+		}
+		tx, err := e.newTransaction(ctx, ix)
+		if err != nil {
+			return err
+		}
+		signatures, err := e.submit(ctx, []*solana.Transaction{tx})
+		if err != nil || e.dryRun {
+			return err
+		}
+		result := signaturesResult{signatures}
+		return e.print(result, result.text)
+	}
+}
+
+// transactionKind tells whether the transaction at index is a "vault", "config" or "batch" transaction
+func transactionKind(ctx context.Context, e *env, multisigPda solana.PublicKey, index uint64) (string, error) {
+	transactionPda, err := squads.GetTransactionPda(multisigPda, index)
+	if err != nil {
+		return "", err
+	}
+	out, err := e.client.GetAccountInfo(ctx, transactionPda)
+	if err != nil {
+		return "", fmt.Errorf("load transaction %d: %w", index, err)
+	}
+	data := out.Value.Data.GetBinary()
+	switch {
+	case bytes.HasPrefix(data, squads_multisig_program.VaultTransactionDiscriminator[:]):
+		return "vault", nil
+	case bytes.HasPrefix(data, squads_multisig_program.ConfigTransactionDiscriminator[:]):
+		return "config", nil
+	case bytes.HasPrefix(data, squads_multisig_program.BatchDiscriminator[:]):
+		return "batch", nil
+	}
+	return "", fmt.Errorf("account %s is not a transaction", transactionPda)
+}
+
+func runExecute(ctx context.Context, e *env, args []string) error {
+	fs := e.flagSet("execute", "--multisig <address> --index <transaction index> [flags]")
+	address := multisigFlag(fs)
+	index := fs.Uint64("index", 0, "transaction index to execute (required)")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	multisigPda, err := parseMultisig(*address)
+	if err != nil {
+		return err
+	}
+	if *index == 0 {
+		return errors.New("--index is required")
+	}
+	keypair, err := e.signer()
+	if err != nil {
+		return err
+	}
+
+	s := squads.New(e.client, multisigPda)
+	if !e.dryRun {
+		readiness, err := s.ExecutionReadiness(ctx, *index)
+		if err != nil {
+			return err
+		}
+		if !readiness.Executable {
+			if !readiness.ExecutableAt.IsZero() {
+				return fmt.Errorf("transaction %d can not be executed before %s: %w", *index, readiness.ExecutableAt.Format(time.RFC3339), readiness.Reason)
+			}
+			return fmt.Errorf("transaction %d can not be executed: %w", *index, readiness.Reason)
+		}
+	}
+
+	kind, err := transactionKind(ctx, e, multisigPda, *index)
+	if err != nil {
+		return err
+	}
+	var txs []*solana.Transaction
+	switch kind {
+	case "vault":
+		tx, err := s.VaultTransactionExecuteTx(ctx, keypair.PublicKey(), *index)
+		if err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+	case "config":
+		tx, err := s.ConfigTransactionExecuteTx(ctx, keypair.PublicKey(), keypair.PublicKey(), *index, nil)
+		if err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+	case "batch":
+		if txs, err = s.BatchExecuteTxs(ctx, keypair.PublicKey(), *index); err != nil {
+			return err
+		}
+	}
+	signatures, err := e.submit(ctx, txs)
+	if err != nil || e.dryRun {
+		return err
+	}
+	result := signaturesResult{signatures}
+	return e.print(result, result.text)
+}
+
+func runClose(ctx context.Context, e *env, args []string) error {
+	fs := e.flagSet("close", "--multisig <address> --index <transaction index> [flags]")
+	address := multisigFlag(fs)
+	index := fs.Uint64("index", 0, "transaction index to close (required)")
+	if err := e.parse(fs, args); err != nil {
+		return err
+	}
+	multisigPda, err := parseMultisig(*address)
+	if err != nil {
+		return err
+	}
+	if *index == 0 {
+		return errors.New("--index is required")
+	}
+
+	s := squads.New(e.client, multisigPda)
+	multisig, err := s.MultisigAccount(ctx)
+	if err != nil {
+		return err
+	}
+	if multisig.RentCollector == nil {
+		return errors.New("the multisig has no rent collector, its accounts can not be closed")
+	}
+	kind, err := transactionKind(ctx, e, multisigPda, *index)
+	if err != nil {
+		return err
+	}
+	var ix solana.Instruction
+	switch kind {
+	case "vault":
+		ix, err = s.VaultTransactionAccountsCloseIx(ctx, *multisig.RentCollector, *index)
+	case "config":
+		ix, err = s.ConfigTransactionAccountsCloseIx(ctx, *multisig.RentCollector, *index)
+	default:
+		return fmt.Errorf("closing %s transactions is not supported, close the batch transactions first with the SDK", kind)
+	}
+	if err != nil {
+		return err
+	}
+	tx, err := e.newTransaction(ctx, ix)
+	if err != nil {
+		return err
+	}
+	signatures, err := e.submit(ctx, []*solana.Transaction{tx})
+	if err != nil || e.dryRun {
+		return err
+	}
+	result := signaturesResult{signatures}
+	return e.print(result, result.text)
+}
+
+func runMembers(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 || (args[0] != "add" && args[0] != "remove") {
+		return errors.New(`usage: squads members add|remove --multisig <address> --member <pubkey> [flags]`)
+	}
+	action := args[0]
+	fs := e.flagSet("members "+action, "--multisig <address> --member <pubkey> [flags]")
+	address := multisigFlag(fs)
+	memberFlag := fs.String("member", "", "member to add or remove (required)")
+	permissionsFlag := fs.String("permissions", "all", "permissions of an added member: initiate, vote, execute or all")
+	approve := fs.Bool("approve", false, "approve the config proposal in the same transaction, the keypair must be a voter")
+	if err := e.parse(fs, args[1:]); err != nil {
+		return err
+	}
+	multisigPda, err := parseMultisig(*address)
+	if err != nil {
+		return err
+	}
+	if *memberFlag == "" {
+		return errors.New("--member is required")
+	}
+	member, err := parseMember(*memberFlag + ":" + *permissionsFlag)
+	if err != nil {
+		return err
+	}
+	keypair, err := e.signer()
+	if err != nil {
+		return err
+	}
+
+	s := squads.New(e.client, multisigPda)
+	multisig, err := s.MultisigAccount(ctx)
+	if err != nil {
+		return err
+	}
+
+	var ixs []solana.Instruction
+	var transactionIndex uint64
+	if !multisig.ConfigAuthority.IsZero() {
+		// controlled multisig: the config authority changes the members directly
+		var ix solana.Instruction
+		if action == "add" {
+			ix, err = s.MultisigAddMemeberIx(ctx, keypair.PublicKey(), keypair.PublicKey(), member)
+		} else {
+			ix, err = s.MultisigRemoveMemberIx(ctx, keypair.PublicKey(), keypair.PublicKey(), member.Key)
+		}
+		if err != nil {
+			return err
+		}
+		ixs = append(ixs, ix)
+	} else {
+		// autonomous multisig: the change is proposed as a config transaction
+		var configAction squads_multisig_program.ConfigAction = &squads_multisig_program.ConfigActionAddMember{NewMember: member}
+		if action == "remove" {
+			configAction = &squads_multisig_program.ConfigActionRemoveMember{OldMember: member.Key}
+		}
+		transactionIndex = multisig.TransactionIndex + 1
+		createIx, err := s.ConfigTransactionCreateIx(ctx, keypair.PublicKey(), transactionIndex, &squads_multisig_program.ConfigTransactionCreateArgs{
+			Actions: []squads_multisig_program.ConfigAction{configAction},
+		})
+		if err != nil {
+			return err
+		}
+		proposalIx, err := s.ProposalCreateIx(ctx, keypair.PublicKey(), transactionIndex)
+		if err != nil {
+			return err
+		}
+		ixs = append(ixs, createIx, proposalIx)
+		if *approve {
+			approveIx, err := s.ProposalApproveIx(ctx, keypair.PublicKey(), transactionIndex)
+			if err != nil {
+				return err
+			}
+			ixs = append(ixs, approveIx)
+		}
+	}
+
+	tx, err := e.newTransaction(ctx, ixs...)
+	if err != nil {
+		return err
+	}
+	signatures, err := e.submit(ctx, []*solana.Transaction{tx})
+	if err != nil || e.dryRun {
+		return err
+	}
+	result := struct {
+		TransactionIndex uint64 `json:"transactionIndex,omitempty"`
+		signaturesResult
+	}{transactionIndex, signaturesResult{signatures}}
+	return e.print(result, func(w io.Writer) {
+		if result.TransactionIndex != 0 {
+			fmt.Fprintln(w, "Config transaction index:", result.TransactionIndex)
+		}
+		result.text(w)
+	})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	squads "github.com/Lee0x273/go-squads"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// env holds the flags shared by every command and the clients built from them
+type env struct {
+	stdout io.Writer
+	dial   func(url string) rpcClient
+
+	url         string
+	commitment  string
+	keypairPath string
+	dryRun      bool
+	output      string
+
+	client  rpcClient
+	keypair solana.PrivateKey
+}
+
+var clusterMonikers = map[string]string{
+	"m": rpc.MainNetBeta_RPC, "mainnet-beta": rpc.MainNetBeta_RPC,
+	"d": rpc.DevNet_RPC, "devnet": rpc.DevNet_RPC,
+	"t": rpc.TestNet_RPC, "testnet": rpc.TestNet_RPC,
+	"l": rpc.LocalNet_RPC, "localhost": rpc.LocalNet_RPC,
+}
+
+// flagSet creates the flag set of a command with the shared flags registered
+func (e *env) flagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: squads %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	defaultKeypair := "~/.config/solana/id.json"
+	fs.StringVar(&e.url, "url", "mainnet-beta", "RPC URL or moniker: mainnet-beta, devnet, testnet or localhost")
+	fs.StringVar(&e.commitment, "commitment", string(rpc.CommitmentConfirmed), "commitment: processed, confirmed or finalized")
+	fs.StringVar(&e.keypairPath, "keypair", defaultKeypair, "solana-keygen JSON keypair of the signer and fee payer")
+	fs.BoolVar(&e.dryRun, "dry-run", false, "print the unsigned transactions instead of sending them")
+	fs.StringVar(&e.output, "output", "text", "output format: text or json")
+	return fs
+}
+
+// parse parses the command flags, validates the shared flags and connects to the RPC node
+func (e *env) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+	switch e.output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q", e.output)
+	}
+	switch rpc.CommitmentType(e.commitment) {
+	case rpc.CommitmentProcessed, rpc.CommitmentConfirmed, rpc.CommitmentFinalized:
+	default:
+		return fmt.Errorf("unknown commitment %q", e.commitment)
+	}
+	url := e.url
+	if endpoint, ok := clusterMonikers[url]; ok {
+		url = endpoint
+	}
+	e.client = e.dial(url)
+	return nil
+}
+
+// signer loads the keypair of the signer and fee payer
+func (e *env) signer() (solana.PrivateKey, error) {
+	if e.keypair != nil {
+		return e.keypair, nil
+	}
+	path := e.keypairPath
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, rest)
+	}
+	keypair, err := solana.PrivateKeyFromSolanaKeygenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load keypair %s: %w", e.keypairPath, err)
+	}
+	e.keypair = keypair
+	return keypair, nil
+}
+
+// print writes v as indented JSON, or calls text for the text output
+func (e *env) print(v interface{}, text func(w io.Writer)) error {
+	if e.output == "json" {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	text(e.stdout)
+	return nil
+}
+
+type dryRunTransaction struct {
+	Transaction  string                       `json:"transaction"`
+	Signers      []solana.PublicKey           `json:"signers"`
+	Instructions []*squads.InstructionSummary `json:"instructions,omitempty"`
+}
+
+// submit signs, sends and confirms the transactions in order and returns their signatures.
+// Program errors are returned as *squads.TransactionError.
+// With --dry-run it prints the unsigned transactions instead and returns no signatures.
+func (e *env) submit(ctx context.Context, txs []*solana.Transaction, extraSigners ...solana.PrivateKey) ([]solana.Signature, error) {
+	if e.dryRun {
+		return nil, e.printDryRun(txs)
+	}
+	keypair, err := e.signer()
+	if err != nil {
+		return nil, err
+	}
+	signers := []squads.Signer{squads.NewKeypairSigner(keypair)}
+	for _, key := range extraSigners {
+		signers = append(signers, squads.NewKeypairSigner(key))
+	}
+
+	var signatures []solana.Signature
+	for _, tx := range txs {
+		if err := squads.SignTransaction(ctx, tx, signers...); err != nil {
+			return signatures, err
+		}
+		result, err := squads.SendAndConfirm(ctx, e.client, tx, &squads.SendOpts{
+			Commitment: rpc.CommitmentType(e.commitment),
+		})
+		if err != nil {
+			return signatures, err
+		}
+		signatures = append(signatures, result.Signature)
+	}
+	return signatures, nil
+}
+
+func (e *env) printDryRun(txs []*solana.Transaction) error {
+	out := make([]dryRunTransaction, 0, len(txs))
+	for _, tx := range txs {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		out = append(out, dryRunTransaction{
+			Transaction:  base64Encode(raw),
+			Signers:      tx.Message.AccountKeys[:tx.Message.Header.NumRequiredSignatures],
+			Instructions: describeInstructions(tx),
+		})
+	}
+	return e.print(out, func(w io.Writer) {
+		for i, tx := range out {
+			fmt.Fprintf(w, "Transaction %d, signers %v\n", i+1, tx.Signers)
+			for _, ix := range tx.Instructions {
+				fmt.Fprintf(w, "  %s\n", ix)
+			}
+			fmt.Fprintln(w, tx.Transaction)
+		}
+	})
+}
+
+// describeInstructions summarizes the instructions of a transaction, nil when they can not be resolved
+func describeInstructions(tx *solana.Transaction) []*squads.InstructionSummary {
+	var summaries []*squads.InstructionSummary
+	for _, compiled := range tx.Message.Instructions {
+		programID, err := tx.Message.ResolveProgramIDIndex(compiled.ProgramIDIndex)
+		if err != nil {
+			return nil
+		}
+		accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			return nil
+		}
+		ix := solana.NewInstruction(programID, accounts, compiled.Data)
+		summaries = append(summaries, squads.DefaultDecoderRegistry.Decode(ix, nil))
+	}
+	return summaries
+}
+
+// newTransaction builds a transaction paid by the signer
+func (e *env) newTransaction(ctx context.Context, ixs ...solana.Instruction) (*solana.Transaction, error) {
+	keypair, err := e.signer()
+	if err != nil {
+		return nil, err
+	}
+	recent, err := e.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, err
+	}
+	return solana.NewTransaction(ixs, recent.Value.Blockhash, solana.TransactionPayer(keypair.PublicKey()))
+}
+
+// multisigFlag registers the --multisig flag
+func multisigFlag(fs *flag.FlagSet) *string {
+	return fs.String("multisig", "", "address of the multisig (required)")
+}
+
+func parseMultisig(value string) (solana.PublicKey, error) {
+	if value == "" {
+		return solana.PublicKey{}, errors.New("--multisig is required")
+	}
+	return solana.PublicKeyFromBase58(value)
+}
@@ -0,0 +1,86 @@
+// Command squads manages Squads v4 multisigs from the command line.
+//
+// Usage:
+//
+//	squads <command> [flags]
+//
+// Every command accepts --url, --commitment, --keypair, --dry-run and --output.
+// Run "squads <command> -h" for the flags of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	squads "github.com/Lee0x273/go-squads"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// rpcClient is the RPC API the command needs, *rpc.Client implements it
+type rpcClient = squads.SendClient
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+var commands = []command{
+	{"create", "create a multisig", runCreate},
+	{"info", "show a multisig, its members and vault", runInfo},
+	{"proposals", "list the proposals of a multisig", runProposals},
+	{"transfer", "propose a SOL or token transfer out of a vault", runTransfer},
+	{"approve", "approve a proposal", runVote(voteApprove)},
+	{"reject", "reject a proposal", runVote(voteReject)},
+	{"cancel", "cancel an approved proposal", runVote(voteCancel)},
+	{"execute", "execute an approved transaction", runExecute},
+	{"close", "close an executed, rejected or cancelled transaction and its proposal", runClose},
+	{"members", "add or remove members", runMembers},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	dial := func(url string) rpcClient { return rpc.New(url) }
+	if err := run(ctx, os.Args[1:], os.Stdout, dial); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, dial func(url string) rpcClient) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(stdout)
+		if len(args) == 0 {
+			return flag.ErrHelp
+		}
+		return nil
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			e := &env{stdout: stdout, dial: dial}
+			return cmd.run(ctx, e, args[1:])
+		}
+	}
+	usage(os.Stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: squads <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "squads <command> -h" for the flags of a command.`)
+}
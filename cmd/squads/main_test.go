@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	squads "github.com/Lee0x273/go-squads"
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func testCLI(t *testing.T) (*squadstest.Client, solana.PrivateKey, solana.PublicKey, func(args ...string) (string, error)) {
+	t.Helper()
+	keypair := solana.NewWallet().PrivateKey
+	// solana-keygen writes the key as a list of numbers
+	numbers := make([]int, len(keypair))
+	for i, b := range keypair {
+		numbers[i] = int(b)
+	}
+	raw, err := json.Marshal(numbers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keypairPath := filepath.Join(t.TempDir(), "id.json")
+	if err := os.WriteFile(keypairPath, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// every transaction sent is confirmed
+	client := squadstest.NewClient()
+	client.OnSend(func(tx *solana.Transaction) error {
+		client.SetSignatureStatus(tx.Signatures[0], &rpc.SignatureStatusesResult{ConfirmationStatus: rpc.ConfirmationStatusFinalized})
+		return nil
+	})
+	multisigPda := solana.NewWallet().PublicKey()
+	all := squads.Initiate | squads.Vote | squads.Execute
+	err = client.SetBorshAccount(multisigPda, squads_multisig_program.Multisig{
+		Threshold:        1,
+		TransactionIndex: 3,
+		Members: []squads_multisig_program.Member{
+			{Key: keypair.PublicKey(), Permissions: squads_multisig_program.Permissions{Mask: uint8(all)}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dial := func(url string) rpcClient { return client }
+	cli := func(args ...string) (string, error) {
+		var stdout bytes.Buffer
+		args = append(args, "--keypair", keypairPath, "--multisig", multisigPda.String())
+		err := run(t.Context(), args, &stdout, dial)
+		return stdout.String(), err
+	}
+	return client, keypair, multisigPda, cli
+}
+
+func Test_TransferDryRun(t *testing.T) {
+	client, keypair, _, cli := testCLI(t)
+	recipient := solana.NewWallet().PublicKey()
+
+	out, err := cli("transfer", "--to", recipient.String(), "--amount", "1.5", "--approve", "--dry-run", "--output", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var txs []dryRunTransaction
+	if err := json.Unmarshal([]byte(out), &txs); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if len(txs) != 1 || len(txs[0].Signers) != 1 || txs[0].Signers[0] != keypair.PublicKey() {
+		t.Fatalf("unexpected transactions %s", out)
+	}
+	var names []string
+	for _, ix := range txs[0].Instructions {
+		names = append(names, ix.Name)
+	}
+	if len(names) != 3 {
+		t.Fatalf("expected create, proposal and approve instructions, got %v", names)
+	}
+	if _, err := solana.TransactionFromBase64(txs[0].Transaction); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.SentTransactions()) != 0 {
+		t.Fatal("dry run sent a transaction")
+	}
+}
+
+func Test_ApproveSends(t *testing.T) {
+	client, keypair, _, cli := testCLI(t)
+
+	out, err := cli("approve", "--index", "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.SentTransactions()) != 1 {
+		t.Fatalf("expected one transaction, got %d", len(client.SentTransactions()))
+	}
+	tx := client.SentTransactions()[0]
+	if err := tx.VerifySignatures(); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Message.AccountKeys[0] != keypair.PublicKey() {
+		t.Fatalf("unexpected fee payer %s", tx.Message.AccountKeys[0])
+	}
+	if !strings.Contains(out, tx.Signatures[0].String()) {
+		t.Fatalf("signature missing from output %q", out)
+	}
+}
+
+func Test_Info(t *testing.T) {
+	_, keypair, multisigPda, cli := testCLI(t)
+
+	out, err := cli("info", "--output", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info struct {
+		Address          solana.PublicKey `json:"address"`
+		TransactionIndex uint64           `json:"transactionIndex"`
+		Members          []memberInfo     `json:"members"`
+	}
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if info.Address != multisigPda || info.TransactionIndex != 3 || len(info.Members) != 1 || info.Members[0].Key != keypair.PublicKey() {
+		t.Fatalf("unexpected info %s", out)
+	}
+}
+
+func Test_ParseAmount(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals uint8
+		want     uint64
+		wantErr  bool
+	}{
+		{"1", 9, 1_000_000_000, false},
+		{"1.5", 9, 1_500_000_000, false},
+		{"0.000001", 6, 1, false},
+		{".25", 2, 25, false},
+		{"0.0000001", 6, 0, true},
+		{"0", 6, 0, true},
+		{"1e3", 0, 0, true},
+		{"-1", 6, 0, true},
+		{"18446744073709551616", 0, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.value, tt.decimals)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAmount(%q, %d) = %d, %v", tt.value, tt.decimals, got, err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package squads
 
 import (
+	"sort"
+
 	"github.com/gagliardetto/solana-go"
 	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
 )
@@ -8,12 +10,20 @@ import (
 type CompiledKeyMeta struct {
 	IsSigner   bool `json:"isSigner"`
 	IsWritable bool `json:"isWritable"`
-	IsInvoked  bool `json:"isInvoked"`
+	// IsInvoked keeps the key out of lookup tables. CompileKeys never sets it, like the TypeScript SDK
+	// which loads programs from lookup tables, it is only honored for key metas passed to NewCompiledKeys.
+	IsInvoked bool `json:"isInvoked"`
 }
 
+// CompiledKeys collects the account keys of a set of instructions.
+// Keys are ordered by first appearance, payer first, so compiling the same instructions always
+// yields the same message, like the Map based CompiledKeys of the TypeScript SDK.
 type CompiledKeys struct {
 	Payer      solana.PublicKey           `json:"payer"`
 	KeyMetaMap map[string]CompiledKeyMeta `json:"keyMetaMap"`
+
+	// keys are the addresses of KeyMetaMap in insertion order
+	keys []string
 }
 
 type AccountKeysFromLookups struct {
@@ -34,56 +44,86 @@ type MessageAccountKeys struct {
 	AccountKeysFromLookups AccountKeysFromLookups `json:"accountKeysFromLookups"`
 }
 
+// NewCompiledKeys creates CompiledKeys from a key meta map.
+// A map carries no insertion order, so the payer comes first and the other keys are sorted by address.
 func NewCompiledKeys(payer solana.PublicKey, keyMetaMap map[string]CompiledKeyMeta) *CompiledKeys {
-	return &CompiledKeys{
+	ck := &CompiledKeys{
 		Payer:      payer,
 		KeyMetaMap: keyMetaMap,
 	}
+	ck.keys = ck.orderedKeys()
+	return ck
 }
 
+// CompileKeys merges the account metas of the instructions: the payer is a writable signer, and a key is
+// a signer or writable if any instruction uses it as such.
 func CompileKeys(instructions []solana.Instruction, payer solana.PublicKey) *CompiledKeys {
-	keyMetaMap := make(map[string]CompiledKeyMeta)
+	ck := &CompiledKeys{
+		Payer:      payer,
+		KeyMetaMap: make(map[string]CompiledKeyMeta),
+	}
 
-	getOrInsertDefault := func(pubkey solana.PublicKey) *CompiledKeyMeta {
+	update := func(pubkey solana.PublicKey, fn func(*CompiledKeyMeta)) {
 		address := pubkey.String()
-		if keyMeta, exists := keyMetaMap[address]; exists {
-			return &keyMeta
+		keyMeta, exists := ck.KeyMetaMap[address]
+		if !exists {
+			ck.keys = append(ck.keys, address)
 		}
-
-		keyMeta := CompiledKeyMeta{
-			IsSigner:   false,
-			IsWritable: false,
-			IsInvoked:  false,
-		}
-		keyMetaMap[address] = keyMeta
-		return &keyMeta
+		fn(&keyMeta)
+		ck.KeyMetaMap[address] = keyMeta
 	}
 
-	payerKeyMeta := getOrInsertDefault(payer)
-	payerKeyMeta.IsSigner = true
-	payerKeyMeta.IsWritable = true
-	keyMetaMap[payer.String()] = *payerKeyMeta
+	update(payer, func(keyMeta *CompiledKeyMeta) {
+		keyMeta.IsSigner = true
+		keyMeta.IsWritable = true
+	})
 
 	for _, ix := range instructions {
-		programKeyMeta := getOrInsertDefault(ix.ProgramID())
-		programKeyMeta.IsInvoked = false
-		keyMetaMap[ix.ProgramID().String()] = *programKeyMeta
-
+		// programs are not flagged as invoked, see CompiledKeyMeta.IsInvoked
+		update(ix.ProgramID(), func(*CompiledKeyMeta) {})
 		for _, accountMeta := range ix.Accounts() {
-			keyMeta := getOrInsertDefault(accountMeta.PublicKey)
-			keyMeta.IsSigner = keyMeta.IsSigner || accountMeta.IsSigner
-			keyMeta.IsWritable = keyMeta.IsWritable || accountMeta.IsWritable
-			keyMetaMap[accountMeta.PublicKey.String()] = *keyMeta
+			update(accountMeta.PublicKey, func(keyMeta *CompiledKeyMeta) {
+				keyMeta.IsSigner = keyMeta.IsSigner || accountMeta.IsSigner
+				keyMeta.IsWritable = keyMeta.IsWritable || accountMeta.IsWritable
+			})
 		}
 	}
 
-	return NewCompiledKeys(payer, keyMetaMap)
+	return ck
+}
+
+// orderedKeys returns the addresses of KeyMetaMap in insertion order.
+// Addresses added to KeyMetaMap directly follow, payer first and the rest sorted.
+func (ck *CompiledKeys) orderedKeys() []string {
+	keys := make([]string, 0, len(ck.KeyMetaMap))
+	seen := make(map[string]bool, len(ck.KeyMetaMap))
+	for _, address := range ck.keys {
+		if _, ok := ck.KeyMetaMap[address]; ok && !seen[address] {
+			seen[address] = true
+			keys = append(keys, address)
+		}
+	}
+	var extra []string
+	for address := range ck.KeyMetaMap {
+		if !seen[address] {
+			extra = append(extra, address)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool {
+		payer := ck.Payer.String()
+		if extra[i] == payer || extra[j] == payer {
+			return extra[i] == payer
+		}
+		return extra[i] < extra[j]
+	})
+	return append(keys, extra...)
 }
 
 func (ck *CompiledKeys) GetMessageComponents() (solana.MessageHeader, []solana.PublicKey) {
 	var writableSigners, readonlySigners, writableNonSigners, readonlyNonSigners []string
 
-	for address, meta := range ck.KeyMetaMap {
+	for _, address := range ck.orderedKeys() {
+		meta := ck.KeyMetaMap[address]
 		if meta.IsSigner && meta.IsWritable {
 			writableSigners = append(writableSigners, address)
 		} else if meta.IsSigner && !meta.IsWritable {
@@ -126,6 +166,8 @@ func (ck *CompiledKeys) GetMessageComponents() (solana.MessageHeader, []solana.P
 	return header, staticAccountKeys
 }
 
+// ExtractTableLookup moves the keys found in the lookup table out of the static keys.
+// Signers, and keys flagged as invoked, must stay static.
 func (ck *CompiledKeys) ExtractTableLookup(lookupTable addresslookuptable.KeyedAddressLookupTable) (*solana.MessageAddressTableLookup, *AccountKeysFromLookups, bool) {
 	writableIndexes, drainedWritableKeys := ck.drainKeysFoundInLookupTable(
 		lookupTable.State.Addresses,
@@ -158,20 +200,21 @@ func (ck *CompiledKeys) ExtractTableLookup(lookupTable addresslookuptable.KeyedA
 }
 
 func (ck *CompiledKeys) drainKeysFoundInLookupTable(lookupTableEntries []solana.PublicKey, keyMetaFilter func(CompiledKeyMeta) bool) ([]uint8, []solana.PublicKey) {
-	var lookupTableIndexes []uint8
-	var drainedKeys []solana.PublicKey
-
-	for address, keyMeta := range ck.KeyMetaMap {
-		if keyMetaFilter(keyMeta) {
-			key, _ := solana.PublicKeyFromBase58(address)
-
-			for i, entry := range lookupTableEntries {
-				if entry.Equals(key) {
-					lookupTableIndexes = append(lookupTableIndexes, uint8(i))
-					drainedKeys = append(drainedKeys, key)
-					delete(ck.KeyMetaMap, address)
-					break
-				}
+	lookupTableIndexes := []uint8{}
+	drainedKeys := []solana.PublicKey{}
+
+	for _, address := range ck.orderedKeys() {
+		keyMeta := ck.KeyMetaMap[address]
+		if !keyMetaFilter(keyMeta) {
+			continue
+		}
+		key, _ := solana.PublicKeyFromBase58(address)
+		for i, entry := range lookupTableEntries {
+			if entry.Equals(key) {
+				lookupTableIndexes = append(lookupTableIndexes, uint8(i))
+				drainedKeys = append(drainedKeys, key)
+				delete(ck.KeyMetaMap, address)
+				break
 			}
 		}
 	}
@@ -0,0 +1,55 @@
+package squads
+
+import (
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_ConfigTransactionExecute(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+	member, createKey, removed := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	transactionPda, err := GetTransactionPda(multisigPda, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.SetBorshAccount(transactionPda, squads_multisig_program.ConfigTransaction{
+		Multisig: multisigPda,
+		Index:    3,
+		Actions: squads_multisig_program.ConfigActionsWithVariants([]squads_multisig_program.ConfigAction{
+			&squads_multisig_program.ConfigActionChangeThreshold{NewThreshold: 2},
+			&squads_multisig_program.ConfigActionAddSpendingLimit{CreateKey: createKey, Amount: 10, Members: []solana.PublicKey{member}},
+			&squads_multisig_program.ConfigActionRemoveSpendingLimit{SpendingLimit: removed},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := s.ConfigTransactionExecuteTx(t.Context(), member, member, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiled := tx.Message.Instructions[0]
+	accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := GetSpendingLimitPda(multisigPda, createKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining := accounts[6:]
+	if len(remaining) != 2 || remaining[0].PublicKey != added || remaining[1].PublicKey != removed ||
+		!remaining[0].IsWritable || !remaining[1].IsWritable || remaining[0].IsSigner {
+		t.Fatalf("unexpected remaining accounts %+v", remaining)
+	}
+	if _, err := squads_multisig_program.DecodeInstruction(accounts, compiled.Data); err != nil {
+		t.Fatal(err)
+	}
+}
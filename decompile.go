@@ -0,0 +1,176 @@
+package squads
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+)
+
+// DecodeTransactionMessage decodes transaction message bytes, as written to a transaction buffer or passed to
+// VaultTransactionCreate, into the message format stored in vault and batch transactions
+func DecodeTransactionMessage(data []byte) (squads_multisig_program.VaultTransactionMessage, error) {
+	reader := bytes.NewReader(data)
+	var txMsg squads_multisig_program.TransactionMessage
+	if err := squads_multisig_program.NewDecoder(reader).Decode(&txMsg); err != nil {
+		return squads_multisig_program.VaultTransactionMessage{}, fmt.Errorf("decode transaction message: %w", err)
+	}
+	if reader.Len() != 0 {
+		return squads_multisig_program.VaultTransactionMessage{}, fmt.Errorf("decode transaction message: %d trailing bytes", reader.Len())
+	}
+
+	message := squads_multisig_program.VaultTransactionMessage{
+		NumSigners:            txMsg.NumSigners,
+		NumWritableSigners:    txMsg.NumWritableSigners,
+		NumWritableNonSigners: txMsg.NumWritableNonSigners,
+		AccountKeys:           txMsg.AccountKeys.Data,
+		Instructions:          make([]squads_multisig_program.MultisigCompiledInstruction, 0, len(txMsg.Instructions.Data)),
+		AddressTableLookups:   make([]squads_multisig_program.MultisigMessageAddressTableLookup, 0, len(txMsg.AddressTableLookups.Data)),
+	}
+	for _, ix := range txMsg.Instructions.Data {
+		message.Instructions = append(message.Instructions, squads_multisig_program.MultisigCompiledInstruction{
+			ProgramIdIndex: ix.ProgramIdIndex,
+			AccountIndexes: ix.AccountIndexes.Data,
+			Data:           ix.Data.Data,
+		})
+	}
+	for _, lookup := range txMsg.AddressTableLookups.Data {
+		message.AddressTableLookups = append(message.AddressTableLookups, squads_multisig_program.MultisigMessageAddressTableLookup{
+			AccountKey:      lookup.AccountKey,
+			WritableIndexes: lookup.WritableIndexes.Data,
+			ReadonlyIndexes: lookup.ReadonlyIndexes.Data,
+		})
+	}
+	return message, nil
+}
+
+// DecompileVaultTransactionMessage turns a stored vault transaction message back into the instructions it executes.
+// Signers of the message are the vault and the ephemeral signer PDAs. Every lookup of the message must be
+// resolvable from addressLookupTableAccounts.
+func DecompileVaultTransactionMessage(message squads_multisig_program.VaultTransactionMessage,
+	addressLookupTableAccounts []addresslookuptable.KeyedAddressLookupTable) ([]solana.Instruction, error) {
+	if int(message.NumSigners) > len(message.AccountKeys) {
+		return nil, fmt.Errorf("invalid transaction message: %d signers for %d account keys", message.NumSigners, len(message.AccountKeys))
+	}
+
+	// Accounts are indexed as the static keys, then the writable and the readonly keys loaded from the lookup tables
+	metas := make([]*solana.AccountMeta, 0, len(message.AccountKeys))
+	for i, meta := range VaultTransactionMessageAccountMetas(message) {
+		metas = append(metas, solana.NewAccountMeta(meta.PublicKey, meta.IsWritable, i < int(message.NumSigners)))
+	}
+	var writableMetas, readonlyMetas []*solana.AccountMeta
+	for _, lookup := range message.AddressTableLookups {
+		var addresses solana.PublicKeySlice
+		found := false
+		for _, table := range addressLookupTableAccounts {
+			if table.Key.Equals(lookup.AccountKey) {
+				addresses, found = table.State.Addresses, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("address lookup table %s not provided", lookup.AccountKey)
+		}
+		for _, index := range lookup.WritableIndexes {
+			if int(index) >= len(addresses) {
+				return nil, fmt.Errorf("address lookup table %s has no index %d", lookup.AccountKey, index)
+			}
+			writableMetas = append(writableMetas, solana.NewAccountMeta(addresses[index], true, false))
+		}
+		for _, index := range lookup.ReadonlyIndexes {
+			if int(index) >= len(addresses) {
+				return nil, fmt.Errorf("address lookup table %s has no index %d", lookup.AccountKey, index)
+			}
+			readonlyMetas = append(readonlyMetas, solana.NewAccountMeta(addresses[index], false, false))
+		}
+	}
+	metas = append(metas, writableMetas...)
+	metas = append(metas, readonlyMetas...)
+
+	instructions := make([]solana.Instruction, 0, len(message.Instructions))
+	for i, ix := range message.Instructions {
+		if int(ix.ProgramIdIndex) >= len(metas) {
+			return nil, fmt.Errorf("instruction %d: program id index %d out of range", i, ix.ProgramIdIndex)
+		}
+		accounts := make(solana.AccountMetaSlice, 0, len(ix.AccountIndexes))
+		for _, index := range ix.AccountIndexes {
+			if int(index) >= len(metas) {
+				return nil, fmt.Errorf("instruction %d: account index %d out of range", i, index)
+			}
+			meta := *metas[index]
+			accounts = append(accounts, &meta)
+		}
+		data := append([]byte(nil), ix.Data...)
+		instructions = append(instructions, solana.NewInstruction(metas[ix.ProgramIdIndex].PublicKey, accounts, data))
+	}
+	return instructions, nil
+}
+
+// VaultTransactionMessageInstructions decompiles a vault transaction message, fetching the lookup tables it uses
+func (s *Multisig) VaultTransactionMessageInstructions(ctx context.Context, message squads_multisig_program.VaultTransactionMessage) ([]solana.Instruction, error) {
+	if len(message.AddressTableLookups) == 0 {
+		return DecompileVaultTransactionMessage(message, nil)
+	}
+	if s.client == nil {
+		return nil, fmt.Errorf("an rpc client is required to resolve address lookup tables")
+	}
+	addresses := make([]solana.PublicKey, 0, len(message.AddressTableLookups))
+	for _, lookup := range message.AddressTableLookups {
+		addresses = append(addresses, lookup.AccountKey)
+	}
+	addressLookupTableAccounts, err := s.AddressLookupTableAccounts(ctx, addresses)
+	if err != nil {
+		return nil, err
+	}
+	return DecompileVaultTransactionMessage(message, addressLookupTableAccounts)
+}
+
+// VaultTransactionInstructions fetches a vault transaction and decompiles the instructions it executes
+func (s *Multisig) VaultTransactionInstructions(ctx context.Context, transactionIndex uint64) ([]solana.Instruction, error) {
+	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	vaultTransaction, err := s.VaultTransactionAccount(ctx, transactionPda)
+	if err != nil {
+		return nil, err
+	}
+	return s.VaultTransactionMessageInstructions(ctx, vaultTransaction.Message)
+}
+
+// VaultBatchTransactionInstructions fetches a transaction of a batch and decompiles the instructions it executes
+func (s *Multisig) VaultBatchTransactionInstructions(ctx context.Context, batchIndex uint64, transactionIndex uint32) ([]solana.Instruction, error) {
+	batchTransactionPda, err := GetBatchTransactionPda(s.multisigPda, batchIndex, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	batchTransaction, err := s.VaultBatchTransactionAccount(ctx, batchTransactionPda)
+	if err != nil {
+		return nil, err
+	}
+	return s.VaultTransactionMessageInstructions(ctx, batchTransaction.Message)
+}
+
+// TransactionBufferInstructions fetches a transaction buffer and decompiles the message it holds.
+// The buffer must hold the complete message.
+func (s *Multisig) TransactionBufferInstructions(ctx context.Context, creator solana.PublicKey, bufferIndex uint8) ([]solana.Instruction, error) {
+	bufferPda, err := GetTransactionBufferPda(s.multisigPda, creator, bufferIndex)
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := s.TransactionBufferAccount(ctx, bufferPda)
+	if err != nil {
+		return nil, err
+	}
+	if len(buffer.Buffer) != int(buffer.FinalBufferSize) {
+		return nil, fmt.Errorf("transaction buffer %s is incomplete: %d of %d bytes", bufferPda, len(buffer.Buffer), buffer.FinalBufferSize)
+	}
+	message, err := DecodeTransactionMessage(buffer.Buffer)
+	if err != nil {
+		return nil, err
+	}
+	return s.VaultTransactionMessageInstructions(ctx, message)
+}
@@ -0,0 +1,153 @@
+package squads
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+)
+
+func assertInstructionsEqual(t *testing.T, got, want []solana.Instruction) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d instructions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].ProgramID().Equals(want[i].ProgramID()) {
+			t.Errorf("instruction %d: program %s, want %s", i, got[i].ProgramID(), want[i].ProgramID())
+		}
+		gotAccounts, wantAccounts := got[i].Accounts(), want[i].Accounts()
+		if len(gotAccounts) != len(wantAccounts) {
+			t.Fatalf("instruction %d: got %d accounts, want %d", i, len(gotAccounts), len(wantAccounts))
+		}
+		for j := range wantAccounts {
+			if *gotAccounts[j] != *wantAccounts[j] {
+				t.Errorf("instruction %d account %d: got %+v, want %+v", i, j, gotAccounts[j], wantAccounts[j])
+			}
+		}
+		gotData, _ := got[i].Data()
+		wantData, _ := want[i].Data()
+		if string(gotData) != string(wantData) {
+			t.Errorf("instruction %d: data %x, want %x", i, gotData, wantData)
+		}
+	}
+}
+
+func Test_DecompileTransactionMessageBytes(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "compile", "lookup_tables.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f compileFixture
+	if err := json.Unmarshal(raw, &f); err != nil {
+		t.Fatal(err)
+	}
+	message, tables := f.message(t)
+	data, err := TransactionMessageToMultisigTransactionMessageBytes(message, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeTransactionMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instructions, err := DecompileVaultTransactionMessage(decoded, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInstructionsEqual(t, instructions, message.Instructions)
+
+	if _, err := DecompileVaultTransactionMessage(decoded, nil); err == nil {
+		t.Fatal("expected an error for a missing lookup table")
+	}
+	if _, err := DecodeTransactionMessage(append(data, 0)); err == nil {
+		t.Fatal("expected an error for trailing bytes")
+	}
+}
+
+func Test_DecompilePromotesFlags(t *testing.T) {
+	vault := solana.NewWallet().PublicKey()
+	alice := solana.NewWallet().PublicKey()
+	program := solana.NewWallet().PublicKey()
+	instructions := []solana.Instruction{
+		solana.NewInstruction(program, solana.AccountMetaSlice{solana.NewAccountMeta(alice, false, false)}, []byte{1}),
+		solana.NewInstruction(program, solana.AccountMetaSlice{solana.NewAccountMeta(alice, true, false), solana.NewAccountMeta(vault, true, true)}, []byte{2}),
+	}
+	data, err := TransactionMessageToMultisigTransactionMessageBytes(TransactionMessage{PayerKey: vault, Instructions: instructions}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := DecodeTransactionMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecompileVaultTransactionMessage(message, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// alice is writable in the compiled message, so both instructions see her as writable
+	assertInstructionsEqual(t, got, []solana.Instruction{
+		solana.NewInstruction(program, solana.AccountMetaSlice{solana.NewAccountMeta(alice, true, false)}, []byte{1}),
+		solana.NewInstruction(program, solana.AccountMetaSlice{solana.NewAccountMeta(alice, true, false), solana.NewAccountMeta(vault, true, true)}, []byte{2}),
+	})
+}
+
+func Test_VaultTransactionInstructions(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+
+	vaultPda, err := GetVaultPda(multisigPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionPda, err := GetTransactionPda(multisigPda, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	destination := solana.NewWallet().PublicKey()
+	table := addresslookuptable.KeyedAddressLookupTable{
+		Key:   solana.NewWallet().PublicKey(),
+		State: addresslookuptable.AddressLookupTableState{Addresses: solana.PublicKeySlice{destination}},
+	}
+	if err := client.SetAddressLookupTable(table.Key, table.State.Addresses); err != nil {
+		t.Fatal(err)
+	}
+	want := []solana.Instruction{
+		solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{
+			solana.NewAccountMeta(vaultPda, true, true),
+			solana.NewAccountMeta(destination, true, false),
+		}, []byte{2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}),
+	}
+	data, err := TransactionMessageToMultisigTransactionMessageBytes(TransactionMessage{PayerKey: vaultPda, Instructions: want},
+		[]addresslookuptable.KeyedAddressLookupTable{table})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := DecodeTransactionMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(message.AddressTableLookups) != 1 {
+		t.Fatalf("expected the destination to be loaded from the lookup table")
+	}
+	err = client.SetBorshAccount(transactionPda, squads_multisig_program.VaultTransaction{
+		Multisig: multisigPda,
+		Index:    3,
+		Message:  message,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.VaultTransactionInstructions(t.Context(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInstructionsEqual(t, got, want)
+}
@@ -0,0 +1,140 @@
+package squads
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// DefaultMembershipPageSize is the number of memberships returned per page when FindMultisigsOpts.PageSize is zero
+const DefaultMembershipPageSize = 50
+
+// MultisigMembership is a multisig a key is a member of
+type MultisigMembership struct {
+	Address     solana.PublicKey                  `json:"address"`
+	Multisig    *squads_multisig_program.Multisig `json:"multisig"`
+	Permissions Permission                        `json:"permissions"`
+}
+
+// MultisigMembershipPage is a page of the memberships found by FindMultisigs
+type MultisigMembershipPage struct {
+	Memberships []MultisigMembership `json:"memberships"`
+	// Next is the cursor to pass to MultisigMemberships.Page to get the next page, nil on the last page
+	Next *solana.PublicKey `json:"next,omitempty"`
+}
+
+// FindMultisigsOpts configures FindMultisigs
+type FindMultisigsOpts struct {
+	// PageSize is the maximum number of memberships per page, DefaultMembershipPageSize when zero
+	PageSize int
+	// Permissions only keeps the memberships holding all of these permissions, any membership when zero
+	Permissions Permission
+}
+
+// MultisigMemberships holds the memberships found by FindMultisigs, sorted by multisig address.
+// Its pages are cut from the memberships fetched once, without further requests.
+type MultisigMemberships struct {
+	pageSize    int
+	memberships []MultisigMembership
+}
+
+// FindMultisigs returns the multisigs member belongs to, to be read a page at a time.
+// The members of a multisig are variable length and can not be filtered by the RPC node, so every multisig
+// account is fetched with a single getProgramAccounts request and decoded locally. Call it again to refresh.
+func FindMultisigs(ctx context.Context, client RPCClient, member solana.PublicKey, opts *FindMultisigsOpts) (*MultisigMemberships, error) {
+	if opts == nil {
+		opts = &FindMultisigsOpts{}
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultMembershipPageSize
+	}
+	memberships, err := findMemberships(ctx, client, member, opts.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	return &MultisigMemberships{pageSize: pageSize, memberships: memberships}, nil
+}
+
+// Page returns the page following the multisig at address after, as returned in MultisigMembershipPage.Next,
+// the first page when after is nil
+func (m *MultisigMemberships) Page(after *solana.PublicKey) *MultisigMembershipPage {
+	start := 0
+	if after != nil {
+		start, _ = slices.BinarySearchFunc(m.memberships, *after, func(membership MultisigMembership, address solana.PublicKey) int {
+			return comparePublicKeys(membership.Address, address)
+		})
+		if start < len(m.memberships) && m.memberships[start].Address.Equals(*after) {
+			start++
+		}
+	}
+
+	end := min(start+m.pageSize, len(m.memberships))
+	page := &MultisigMembershipPage{Memberships: m.memberships[start:end]}
+	if end < len(m.memberships) {
+		next := m.memberships[end-1].Address
+		page.Next = &next
+	}
+	return page
+}
+
+// All returns every membership
+func (m *MultisigMemberships) All() []MultisigMembership {
+	return m.memberships
+}
+
+// FindAllMultisigs returns every multisig member belongs to, like FindMultisigs in a single page
+func FindAllMultisigs(ctx context.Context, client RPCClient, member solana.PublicKey, permissions Permission) ([]MultisigMembership, error) {
+	return findMemberships(ctx, client, member, permissions)
+}
+
+// findMemberships fetches every multisig account and keeps those member belongs to with all the
+// given permissions, sorted by address
+func findMemberships(ctx context.Context, client RPCClient, member solana.PublicKey, permissions Permission) ([]MultisigMembership, error) {
+	out, err := client.GetProgramAccountsWithOpts(ctx, squads_multisig_program.ProgramID, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: squads_multisig_program.MultisigDiscriminator[:]}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := []MultisigMembership{}
+	for _, keyed := range out {
+		data := keyed.Account.Data.GetBinary()
+		// filters are not guaranteed to be applied by every rpc node
+		if !bytes.HasPrefix(data, squads_multisig_program.MultisigDiscriminator[:]) {
+			continue
+		}
+		multisig := &squads_multisig_program.Multisig{}
+		if err := multisig.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(data)); err != nil {
+			return nil, fmt.Errorf("decode multisig %s: %w", keyed.Pubkey, err)
+		}
+		index := slices.IndexFunc(multisig.Members, func(m squads_multisig_program.Member) bool { return m.Key.Equals(member) })
+		if index < 0 {
+			continue
+		}
+		memberPermissions := Permission(multisig.Members[index].Permissions.Mask)
+		if memberPermissions&permissions != permissions {
+			continue
+		}
+		memberships = append(memberships, MultisigMembership{
+			Address:     keyed.Pubkey,
+			Multisig:    multisig,
+			Permissions: memberPermissions,
+		})
+	}
+	slices.SortFunc(memberships, func(a, b MultisigMembership) int { return comparePublicKeys(a.Address, b.Address) })
+	return memberships, nil
+}
+
+func comparePublicKeys(a, b solana.PublicKey) int {
+	return bytes.Compare(a[:], b[:])
+}
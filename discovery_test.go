@@ -0,0 +1,100 @@
+package squads
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// countingClient counts the account requests made through the fake client
+type countingClient struct {
+	*squadstest.Client
+	requests int
+}
+
+func (c *countingClient) GetMultipleAccounts(ctx context.Context, accounts ...solana.PublicKey) (*rpc.GetMultipleAccountsResult, error) {
+	c.requests++
+	return c.Client.GetMultipleAccounts(ctx, accounts...)
+}
+
+func (c *countingClient) GetProgramAccountsWithOpts(ctx context.Context, publicKey solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error) {
+	c.requests++
+	return c.Client.GetProgramAccountsWithOpts(ctx, publicKey, opts)
+}
+
+func Test_FindMultisigs(t *testing.T) {
+	client := squadstest.NewClient()
+	member := solana.NewWallet().PublicKey()
+	withMember := func(permissions Permission) squads_multisig_program.Member {
+		return squads_multisig_program.Member{Key: member, Permissions: squads_multisig_program.Permissions{Mask: uint8(permissions)}}
+	}
+
+	voter := map[solana.PublicKey]bool{}
+	for i := 0; i < 7; i++ {
+		address := solana.NewWallet().PublicKey()
+		multisig := testMultisig(1, testMember(Vote))
+		switch {
+		case i < 3:
+			multisig.Members = append(multisig.Members, withMember(Initiate|Vote))
+			voter[address] = true
+		case i < 5:
+			multisig.Members = append(multisig.Members, withMember(Execute))
+			voter[address] = false
+		}
+		if err := client.SetBorshAccount(address, *multisig); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// other program accounts are not multisigs
+	setAccounts(t, client, squads_multisig_program.Proposal{Status: &squads_multisig_program.ProposalStatusActive{}})
+
+	counting := &countingClient{Client: client}
+	memberships, err := FindMultisigs(t.Context(), counting, member, &FindMultisigsOpts{PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []MultisigMembership
+	var after *solana.PublicKey
+	for pages := 1; ; pages++ {
+		page := memberships.Page(after)
+		if len(page.Memberships) > 2 {
+			t.Fatalf("page %d holds %d memberships", pages, len(page.Memberships))
+		}
+		found = append(found, page.Memberships...)
+		if page.Next == nil {
+			if pages != 3 {
+				t.Fatalf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		after = page.Next
+	}
+	// the pages are cut from a single scan of the program
+	if counting.requests != 1 {
+		t.Fatalf("paging took %d requests, want 1", counting.requests)
+	}
+	if len(found) != 5 || len(memberships.All()) != 5 {
+		t.Fatalf("expected 5 memberships, got %d", len(found))
+	}
+	for i, membership := range found {
+		isVoter, ok := voter[membership.Address]
+		if !ok || isVoter != membership.Permissions.Has(Vote) {
+			t.Fatalf("unexpected membership %+v", membership)
+		}
+		if i > 0 && comparePublicKeys(found[i-1].Address, membership.Address) >= 0 {
+			t.Fatal("memberships are not sorted by address")
+		}
+	}
+
+	voting, err := FindAllMultisigs(t.Context(), client, member, Vote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voting) != 3 {
+		t.Fatalf("expected 3 voting memberships, got %d", len(voting))
+	}
+}
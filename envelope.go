@@ -0,0 +1,365 @@
+package squads
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// EnvelopeVersion is the version of the envelope format written by this package
+const EnvelopeVersion = 1
+
+// Signer roles reported by envelopes
+const (
+	RoleFeePayer        = "fee payer"
+	RoleCreator         = "creator"
+	RoleVoter           = "voter"
+	RoleExecutor        = "executor"
+	RoleRentPayer       = "rent payer"
+	RoleCreateKey       = "create key"
+	RoleConfigAuthority = "config authority"
+	RoleNonceAuthority  = "nonce authority"
+	RoleSigner          = "signer"
+)
+
+var (
+	// ErrEnvelopeVersion is returned when importing an envelope of an unsupported version
+	ErrEnvelopeVersion = errors.New("unsupported envelope version")
+	// ErrEnvelopeMismatch is returned when merging envelopes of different transactions
+	ErrEnvelopeMismatch = errors.New("envelopes carry different transactions")
+)
+
+// Envelope carries an unsigned or partially signed transaction between machines, so that it can be signed
+// offline and broadcast later by anyone. It is exchanged as versioned JSON, or in the compact base64 form.
+//
+// Only the message and the signatures are trusted on import: the blockhash, nonce, signer roles and summary
+// are derived again from the message, so a tampered envelope can not misrepresent what is being signed.
+type Envelope struct {
+	Version int `json:"version"`
+	// Message is the base64 encoded transaction message, the bytes being signed
+	Message string `json:"message"`
+	// Blockhash is the recent blockhash of the message, or the nonce value when Nonce is set
+	Blockhash solana.Hash `json:"blockhash"`
+	// Nonce is set when the transaction advances a durable nonce, it then stays valid until the nonce is used
+	Nonce *EnvelopeNonce `json:"nonce,omitempty"`
+	// Signers are the required signers in message order, with the signatures collected so far
+	Signers []EnvelopeSigner `json:"signers"`
+	// Summary describes the instructions of the transaction, it is empty when the message uses address lookup tables
+	Summary []*InstructionSummary `json:"summary,omitempty"`
+
+	message solana.Message
+	raw     []byte
+}
+
+// EnvelopeNonce is the durable nonce advanced by an envelope transaction
+type EnvelopeNonce struct {
+	Account   solana.PublicKey `json:"account"`
+	Authority solana.PublicKey `json:"authority"`
+}
+
+// EnvelopeSigner is a required signer of an envelope transaction
+type EnvelopeSigner struct {
+	PublicKey solana.PublicKey `json:"publicKey"`
+	// Roles are what the key signs as, e.g. "fee payer" and "voter"
+	Roles     []string          `json:"roles"`
+	Signature *solana.Signature `json:"signature,omitempty"`
+}
+
+// EnvelopeOpts configures the summary of an exported envelope
+type EnvelopeOpts struct {
+	// Registry decodes the instructions, DefaultDecoderRegistry if nil
+	Registry *DecoderRegistry
+	// Labels names well known addresses in the summary
+	Labels AddressLabels
+}
+
+// ExportEnvelope wraps a transaction built by any *Tx function, with the signatures it already carries.
+// opts may be nil.
+func ExportEnvelope(tx *solana.Transaction, opts *EnvelopeOpts) (*Envelope, error) {
+	raw, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	env, err := newEnvelope(raw, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i, signature := range tx.Signatures {
+		if i >= len(env.Signers) || signature.IsZero() {
+			continue
+		}
+		if err := env.addSignature(i, signature); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// ImportEnvelope reads an envelope in JSON or compact form and verifies its signatures.
+// The summary is decoded with opts, which may be nil.
+func ImportEnvelope(data []byte, opts *EnvelopeOpts) (*Envelope, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var in Envelope
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, fmt.Errorf("decode envelope: %w", err)
+		}
+		if in.Version != EnvelopeVersion {
+			return nil, fmt.Errorf("%w: %d", ErrEnvelopeVersion, in.Version)
+		}
+		raw, err := base64.StdEncoding.DecodeString(in.Message)
+		if err != nil {
+			return nil, fmt.Errorf("decode envelope message: %w", err)
+		}
+		env, err := newEnvelope(raw, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, signer := range in.Signers {
+			if signer.Signature == nil {
+				continue
+			}
+			i := slices.IndexFunc(env.Signers, func(s EnvelopeSigner) bool { return s.PublicKey == signer.PublicKey })
+			if i < 0 {
+				return nil, fmt.Errorf("envelope signature of %s which is not a signer", signer.PublicKey)
+			}
+			if err := env.addSignature(i, *signer.Signature); err != nil {
+				return nil, err
+			}
+		}
+		return env, nil
+	}
+
+	compact, err := base64.RawURLEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode compact envelope: %w", err)
+	}
+	if len(compact) == 0 || compact[0] != EnvelopeVersion {
+		return nil, ErrEnvelopeVersion
+	}
+	tx, err := solana.TransactionFromBytes(compact[1:])
+	if err != nil {
+		return nil, fmt.Errorf("decode compact envelope: %w", err)
+	}
+	return ExportEnvelope(tx, opts)
+}
+
+func newEnvelope(raw []byte, opts *EnvelopeOpts) (*Envelope, error) {
+	if opts == nil {
+		opts = &EnvelopeOpts{}
+	}
+	registry := opts.Registry
+	if registry == nil {
+		registry = DefaultDecoderRegistry
+	}
+
+	env := &Envelope{
+		Version: EnvelopeVersion,
+		Message: base64.StdEncoding.EncodeToString(raw),
+		raw:     raw,
+	}
+	if err := env.message.UnmarshalWithDecoder(ag_binary.NewBinDecoder(raw)); err != nil {
+		return nil, fmt.Errorf("decode envelope message: %w", err)
+	}
+	msg := &env.message
+	env.Blockhash = msg.RecentBlockhash
+
+	numSigners := int(msg.Header.NumRequiredSignatures)
+	if numSigners == 0 || numSigners > len(msg.AccountKeys) {
+		return nil, errors.New("envelope message has no valid signers")
+	}
+	roles := make(map[solana.PublicKey][]string, numSigners)
+	addRole := func(key solana.PublicKey, role string) {
+		if !slices.Contains(roles[key], role) {
+			roles[key] = append(roles[key], role)
+		}
+	}
+	addRole(msg.AccountKeys[0], RoleFeePayer)
+
+	resolvable := msg.NumLookups() == 0
+	for i, compiled := range msg.Instructions {
+		if int(compiled.ProgramIDIndex) >= len(msg.AccountKeys) {
+			return nil, fmt.Errorf("envelope instruction %d: invalid program index", i)
+		}
+		programID := msg.AccountKeys[compiled.ProgramIDIndex]
+		static := func(pos int) (solana.PublicKey, bool) {
+			if pos >= len(compiled.Accounts) || int(compiled.Accounts[pos]) >= len(msg.AccountKeys) {
+				return solana.PublicKey{}, false
+			}
+			return msg.AccountKeys[compiled.Accounts[pos]], true
+		}
+
+		if i == 0 && programID == solana.SystemProgramID && isAdvanceNonce(compiled.Data) {
+			account, ok1 := static(0)
+			authority, ok2 := static(2)
+			if ok1 && ok2 {
+				env.Nonce = &EnvelopeNonce{Account: account, Authority: authority}
+				addRole(authority, RoleNonceAuthority)
+			}
+		}
+		if programID == squads_multisig_program.ProgramID && len(compiled.Data) >= 8 {
+			for pos, role := range squadsSignerRoles[ag_binary.TypeIDFromBytes(compiled.Data[:8])] {
+				if key, ok := static(pos); ok {
+					addRole(key, role)
+				}
+			}
+		}
+
+		if resolvable {
+			accounts, err := compiled.ResolveInstructionAccounts(msg)
+			if err != nil {
+				return nil, fmt.Errorf("envelope instruction %d: %w", i, err)
+			}
+			env.Summary = append(env.Summary, registry.Decode(solana.NewInstruction(programID, accounts, compiled.Data), opts.Labels))
+		}
+	}
+
+	for _, key := range msg.AccountKeys[:numSigners] {
+		keyRoles := roles[key]
+		if len(keyRoles) == 0 {
+			keyRoles = []string{RoleSigner}
+		}
+		env.Signers = append(env.Signers, EnvelopeSigner{PublicKey: key, Roles: keyRoles})
+	}
+	return env, nil
+}
+
+// isAdvanceNonce tells whether the system instruction data is AdvanceNonceAccount
+func isAdvanceNonce(data []byte) bool {
+	return len(data) == 4 && binary.LittleEndian.Uint32(data) == 4
+}
+
+// squadsSignerRoles maps the multisig instructions to the roles of their signer accounts, by account position
+var squadsSignerRoles = map[ag_binary.TypeID]map[int]string{
+	squads_multisig_program.Instruction_MultisigCreateV2:                 {3: RoleCreateKey, 4: RoleCreator},
+	squads_multisig_program.Instruction_MultisigAddMember:                {1: RoleConfigAuthority, 2: RoleRentPayer},
+	squads_multisig_program.Instruction_MultisigRemoveMember:             {1: RoleConfigAuthority, 2: RoleRentPayer},
+	squads_multisig_program.Instruction_MultisigChangeThreshold:          {1: RoleConfigAuthority, 2: RoleRentPayer},
+	squads_multisig_program.Instruction_MultisigSetTimeLock:              {1: RoleConfigAuthority, 2: RoleRentPayer},
+	squads_multisig_program.Instruction_MultisigSetConfigAuthority:       {1: RoleConfigAuthority, 2: RoleRentPayer},
+	squads_multisig_program.Instruction_MultisigSetRentCollector:         {1: RoleConfigAuthority, 2: RoleRentPayer},
+	squads_multisig_program.Instruction_MultisigAddSpendingLimit:         {1: RoleConfigAuthority, 3: RoleRentPayer},
+	squads_multisig_program.Instruction_MultisigRemoveSpendingLimit:      {1: RoleConfigAuthority},
+	squads_multisig_program.Instruction_ConfigTransactionCreate:          {2: RoleCreator, 3: RoleRentPayer},
+	squads_multisig_program.Instruction_ConfigTransactionExecute:         {1: RoleExecutor, 4: RoleRentPayer},
+	squads_multisig_program.Instruction_VaultTransactionCreate:           {2: RoleCreator, 3: RoleRentPayer},
+	squads_multisig_program.Instruction_VaultTransactionCreateFromBuffer: {2: RoleCreator, 3: RoleRentPayer},
+	squads_multisig_program.Instruction_VaultTransactionExecute:          {3: RoleExecutor},
+	squads_multisig_program.Instruction_TransactionBufferCreate:          {2: RoleCreator, 3: RoleRentPayer},
+	squads_multisig_program.Instruction_TransactionBufferExtend:          {2: RoleCreator},
+	squads_multisig_program.Instruction_BatchCreate:                      {2: RoleCreator, 3: RoleRentPayer},
+	squads_multisig_program.Instruction_BatchAddTransaction:              {4: RoleCreator, 5: RoleRentPayer},
+	squads_multisig_program.Instruction_BatchExecuteTransaction:          {1: RoleExecutor},
+	squads_multisig_program.Instruction_ProposalCreate:                   {2: RoleCreator, 3: RoleRentPayer},
+	squads_multisig_program.Instruction_ProposalActivate:                 {1: RoleCreator},
+	squads_multisig_program.Instruction_ProposalApprove:                  {1: RoleVoter},
+	squads_multisig_program.Instruction_ProposalReject:                   {1: RoleVoter},
+	squads_multisig_program.Instruction_ProposalCancel:                   {1: RoleVoter},
+	squads_multisig_program.Instruction_ProposalCancelV2:                 {1: RoleVoter},
+}
+
+func (env *Envelope) addSignature(i int, signature solana.Signature) error {
+	signer := &env.Signers[i]
+	if !signature.Verify(signer.PublicKey, env.raw) {
+		return fmt.Errorf("invalid envelope signature of %s", signer.PublicKey)
+	}
+	if signer.Signature != nil && *signer.Signature != signature {
+		return fmt.Errorf("conflicting envelope signatures of %s", signer.PublicKey)
+	}
+	signer.Signature = &signature
+	return nil
+}
+
+// Sign adds the signatures of the signers whose key the transaction requires, other signers are ignored.
+// Existing signatures are kept.
+func (env *Envelope) Sign(ctx context.Context, signers ...Signer) error {
+	for i, required := range env.Signers {
+		if required.Signature != nil {
+			continue
+		}
+		idx := slices.IndexFunc(signers, func(s Signer) bool { return s != nil && s.PublicKey() == required.PublicKey })
+		if idx < 0 {
+			continue
+		}
+		signature, err := signers[idx].SignMessage(ctx, env.raw)
+		if err != nil {
+			return err
+		}
+		if err := env.addSignature(i, signature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge adds the signatures of other, which must carry the same message
+func (env *Envelope) Merge(other *Envelope) error {
+	if !bytes.Equal(env.raw, other.raw) {
+		return ErrEnvelopeMismatch
+	}
+	for i, signer := range other.Signers {
+		if signer.Signature == nil {
+			continue
+		}
+		if err := env.addSignature(i, *signer.Signature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks every signature of the envelope, and that none is missing
+func (env *Envelope) Verify() error {
+	if missing := env.Missing(); len(missing) > 0 {
+		return fmt.Errorf("%w: missing signatures of %v", ErrSignerNotFound, missing)
+	}
+	for _, signer := range env.Signers {
+		if !signer.Signature.Verify(signer.PublicKey, env.raw) {
+			return fmt.Errorf("invalid envelope signature of %s", signer.PublicKey)
+		}
+	}
+	return nil
+}
+
+// Missing returns the signers that have not signed yet
+func (env *Envelope) Missing() []solana.PublicKey {
+	var missing []solana.PublicKey
+	for _, signer := range env.Signers {
+		if signer.Signature == nil {
+			missing = append(missing, signer.PublicKey)
+		}
+	}
+	return missing
+}
+
+// Transaction returns the transaction with the signatures collected so far, missing ones are left zero
+func (env *Envelope) Transaction() *solana.Transaction {
+	tx := &solana.Transaction{
+		Signatures: make([]solana.Signature, len(env.Signers)),
+		Message:    env.message,
+	}
+	for i, signer := range env.Signers {
+		if signer.Signature != nil {
+			tx.Signatures[i] = *signer.Signature
+		}
+	}
+	return tx
+}
+
+// Compact encodes the envelope as unpadded URL-safe base64: the version byte followed by the wire transaction
+func (env *Envelope) Compact() (string, error) {
+	raw, err := env.Transaction().MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append([]byte{EnvelopeVersion}, raw...)), nil
+}
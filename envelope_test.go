@@ -0,0 +1,144 @@
+package squads
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func Test_EnvelopeOfflineSigning(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+	voterKey, payerKey := solana.NewWallet().PrivateKey, solana.NewWallet().PrivateKey
+
+	ix, err := s.ProposalApproveIx(t.Context(), voterKey.PublicKey(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := client.GetLatestBlockhash(t.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := solana.NewTransaction([]solana.Instruction{ix}, recent.Value.Blockhash, solana.TransactionPayer(payerKey.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	online, err := ExportEnvelope(tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if online.Nonce != nil || online.Blockhash != recent.Value.Blockhash {
+		t.Fatalf("unexpected blockhash %s nonce %v", online.Blockhash, online.Nonce)
+	}
+	if len(online.Signers) != 2 ||
+		!slices.Equal(online.Signers[0].Roles, []string{RoleFeePayer}) ||
+		!slices.Equal(online.Signers[1].Roles, []string{RoleVoter}) {
+		t.Fatalf("unexpected signers %+v", online.Signers)
+	}
+	if len(online.Summary) != 1 || online.Summary[0].Name != "ProposalApprove" {
+		t.Fatalf("unexpected summary %+v", online.Summary)
+	}
+	compact, err := online.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cold voter imports the compact form, signs and exports JSON
+	offline, err := ImportEnvelope([]byte(compact), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.Sign(t.Context(), NewKeypairSigner(voterKey)); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := json.Marshal(offline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the summary of an imported envelope is derived from the message, not trusted
+	tampered := strings.Replace(string(signed), "ProposalApprove", "ProposalReject", 1)
+	back, err := ImportEnvelope([]byte(tampered), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Summary[0].Name != "ProposalApprove" {
+		t.Fatalf("summary taken from the envelope: %+v", back.Summary[0])
+	}
+
+	if err := online.Sign(t.Context(), NewKeypairSigner(payerKey)); err != nil {
+		t.Fatal(err)
+	}
+	if err := online.Verify(); !errors.Is(err, ErrSignerNotFound) {
+		t.Fatalf("expected a missing signature, got %v", err)
+	}
+	if err := online.Merge(back); err != nil {
+		t.Fatal(err)
+	}
+	if err := online.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	final := online.Transaction()
+	if err := final.VerifySignatures(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SendTransactionWithOpts(t.Context(), final, rpc.TransactionOpts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// merging another transaction or a forged signature fails
+	other, err := ExportEnvelope(tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := solana.NewWallet().PrivateKey
+	signature, err := forged.Sign([]byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Signers[1].Signature = &signature
+	if err := online.Merge(other); err == nil {
+		t.Fatal("expected a forged signature error")
+	}
+	tx.Message.RecentBlockhash = solana.Hash{9}
+	different, err := ExportEnvelope(tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := online.Merge(different); !errors.Is(err, ErrEnvelopeMismatch) {
+		t.Fatalf("expected ErrEnvelopeMismatch, got %v", err)
+	}
+	if _, err := ImportEnvelope([]byte(`{"version":2}`), nil); !errors.Is(err, ErrEnvelopeVersion) {
+		t.Fatalf("expected ErrEnvelopeVersion, got %v", err)
+	}
+}
+
+func Test_EnvelopeDurableNonce(t *testing.T) {
+	nonceAccount, authority, payer := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	advance := system.NewAdvanceNonceAccountInstruction(nonceAccount, solana.SysVarRecentBlockHashesPubkey, authority).Build()
+	transfer := system.NewTransferInstruction(1, payer, authority).Build()
+	nonce := solana.Hash{1, 2, 3}
+	tx, err := solana.NewTransaction([]solana.Instruction{advance, transfer}, nonce, solana.TransactionPayer(payer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := ExportEnvelope(tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Nonce == nil || env.Nonce.Account != nonceAccount || env.Nonce.Authority != authority || env.Blockhash != nonce {
+		t.Fatalf("unexpected nonce %+v blockhash %s", env.Nonce, env.Blockhash)
+	}
+	if len(env.Signers) != 2 || !slices.Equal(env.Signers[1].Roles, []string{RoleNonceAuthority}) {
+		t.Fatalf("unexpected signers %+v", env.Signers)
+	}
+}
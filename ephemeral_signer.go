@@ -0,0 +1,89 @@
+package squads
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// GetEphemeralSignerPdas returns the ephemeral signer PDAs of a transaction, in index order
+func GetEphemeralSignerPdas(transactionPda solana.PublicKey, ephemeralSigners uint8) ([]solana.PublicKey, error) {
+	pdas := make([]solana.PublicKey, 0, ephemeralSigners)
+	for i := uint8(0); i < ephemeralSigners; i++ {
+		pda, err := GetEphemeralSignerPda(transactionPda, i)
+		if err != nil {
+			return nil, err
+		}
+		pdas = append(pdas, pda)
+	}
+	return pdas, nil
+}
+
+// ReplaceEphemeralSigners rewrites the placeholder signers of vault instructions into ephemeral signer PDAs of the
+// transaction: placeholders[i] (e.g. a freshly generated mint keypair) becomes the ephemeral signer at index i.
+// Other signers are left untouched; the ones that already are ephemeral signer PDAs of transactionPda are counted.
+// Only account metas are rewritten, a placeholder key inside instruction data must be replaced by the caller.
+// It returns the rewritten instructions, the number of ephemeral signers to declare when creating the transaction
+// and the placeholder to PDA mapping.
+func ReplaceEphemeralSigners(transactionPda, vaultPda solana.PublicKey, placeholders []solana.PublicKey, instructions []solana.Instruction) ([]solana.Instruction, uint8, map[solana.PublicKey]solana.PublicKey, error) {
+	if len(placeholders) > math.MaxUint8 {
+		return nil, 0, nil, fmt.Errorf("too many ephemeral signers: %d", len(placeholders))
+	}
+	replacements := make(map[solana.PublicKey]solana.PublicKey, len(placeholders))
+	for i, placeholder := range placeholders {
+		if _, ok := replacements[placeholder]; ok {
+			return nil, 0, nil, fmt.Errorf("duplicate ephemeral signer placeholder %s", placeholder)
+		}
+		if placeholder.Equals(vaultPda) {
+			return nil, 0, nil, fmt.Errorf("the vault %s cannot be an ephemeral signer placeholder", placeholder)
+		}
+		pda, err := GetEphemeralSignerPda(transactionPda, uint8(i))
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		replacements[placeholder] = pda
+	}
+
+	// signers that are neither the vault nor a placeholder may be ephemeral signers the caller derived itself
+	others := make(map[solana.PublicKey]bool)
+	for _, ix := range instructions {
+		for _, meta := range ix.Accounts() {
+			if _, ok := replacements[meta.PublicKey]; meta.IsSigner && !ok && !meta.PublicKey.Equals(vaultPda) {
+				others[meta.PublicKey] = true
+			}
+		}
+	}
+	ephemeralSigners := len(placeholders)
+	for i := 0; i <= math.MaxUint8 && len(others) > 0; i++ {
+		pda, err := GetEphemeralSignerPda(transactionPda, uint8(i))
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if others[pda] {
+			delete(others, pda)
+			ephemeralSigners = max(ephemeralSigners, i+1)
+		}
+	}
+	if len(replacements) == 0 {
+		return instructions, uint8(ephemeralSigners), replacements, nil
+	}
+
+	out := make([]solana.Instruction, 0, len(instructions))
+	for _, ix := range instructions {
+		data, err := ix.Data()
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		accounts := make(solana.AccountMetaSlice, 0, len(ix.Accounts()))
+		for _, meta := range ix.Accounts() {
+			key := meta.PublicKey
+			if pda, ok := replacements[key]; ok {
+				key = pda
+			}
+			accounts = append(accounts, solana.NewAccountMeta(key, meta.IsWritable, meta.IsSigner))
+		}
+		out = append(out, solana.NewInstruction(ix.ProgramID(), accounts, data))
+	}
+	return out, uint8(ephemeralSigners), replacements, nil
+}
@@ -0,0 +1,89 @@
+package squads
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_ReplaceEphemeralSigners(t *testing.T) {
+	transactionPda := solana.NewWallet().PublicKey()
+	vaultPda := solana.NewWallet().PublicKey()
+	programID := solana.NewWallet().PublicKey()
+	placeholder := solana.NewWallet().PublicKey()
+	other := solana.NewWallet().PublicKey()
+	declared, err := GetEphemeralSignerPda(transactionPda, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instructions := []solana.Instruction{
+		solana.NewInstruction(programID, solana.AccountMetaSlice{
+			solana.NewAccountMeta(vaultPda, true, true),
+			solana.NewAccountMeta(placeholder, true, true),
+			solana.NewAccountMeta(other, false, true),
+		}, []byte{1}),
+		solana.NewInstruction(programID, solana.AccountMetaSlice{
+			solana.NewAccountMeta(placeholder, true, false),
+			solana.NewAccountMeta(declared, false, true),
+		}, []byte{2}),
+	}
+
+	out, ephemeralSigners, mapping, err := ReplaceEphemeralSigners(transactionPda, vaultPda, []solana.PublicKey{placeholder}, instructions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ephemeralSigners != 3 {
+		t.Fatalf("expected 3 ephemeral signers to cover the declared index 2, got %d", ephemeralSigners)
+	}
+	replacement, err := GetEphemeralSignerPda(transactionPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mapping) != 1 || mapping[placeholder] != replacement {
+		t.Fatalf("unexpected mapping %v", mapping)
+	}
+	if !out[0].Accounts()[0].PublicKey.Equals(vaultPda) {
+		t.Fatal("vault must not be replaced")
+	}
+	if !out[0].Accounts()[1].PublicKey.Equals(replacement) || !out[1].Accounts()[0].PublicKey.Equals(replacement) {
+		t.Fatal("placeholder must be replaced by the ephemeral signer at its index")
+	}
+	if !out[0].Accounts()[2].PublicKey.Equals(other) {
+		t.Fatal("signers that are not placeholders must not be replaced")
+	}
+	if !out[1].Accounts()[1].PublicKey.Equals(declared) {
+		t.Fatal("declared ephemeral signer must keep its index")
+	}
+	if !instructions[0].Accounts()[1].PublicKey.Equals(placeholder) {
+		t.Fatal("input instructions must not be modified")
+	}
+
+	if _, _, _, err := ReplaceEphemeralSigners(transactionPda, vaultPda, []solana.PublicKey{placeholder, placeholder}, instructions); err == nil {
+		t.Fatal("expected an error for a duplicate placeholder")
+	}
+}
+
+func Test_ReplaceEphemeralSignersWithoutPlaceholders(t *testing.T) {
+	transactionPda := solana.NewWallet().PublicKey()
+	vaultPda := solana.NewWallet().PublicKey()
+	declared, err := GetEphemeralSignerPda(transactionPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instructions := []solana.Instruction{
+		solana.NewInstruction(solana.SystemProgramID, solana.AccountMetaSlice{
+			solana.NewAccountMeta(vaultPda, true, true),
+			solana.NewAccountMeta(declared, true, true),
+		}, []byte{1}),
+	}
+
+	out, ephemeralSigners, _, err := ReplaceEphemeralSigners(transactionPda, vaultPda, nil, instructions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the only signer is PDA #1, so PDA #0 is declared but unused
+	if ephemeralSigners != 2 || !out[0].Accounts()[1].PublicKey.Equals(declared) {
+		t.Fatalf("expected PDA #1 to be kept with 2 ephemeral signers, got %d", ephemeralSigners)
+	}
+}
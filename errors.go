@@ -0,0 +1,192 @@
+package squads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+//go:generate go run ./internal/generrors -idl generated/squads_multisig_program.json -out program_errors.go
+
+// ProgramError is a custom error of the multisig program
+type ProgramError struct {
+	Code uint32
+	Name string
+	Msg  string
+}
+
+func (e *ProgramError) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Name, e.Code, e.Msg)
+}
+
+// ProgramErrorFromCode returns the multisig program error with the given custom error code
+func ProgramErrorFromCode(code uint32) (*ProgramError, bool) {
+	e, ok := programErrors[code]
+	return e, ok
+}
+
+// TransactionError is a custom program error raised by a transaction or a simulation.
+// It unwraps to the matching ProgramError, so errors.Is(err, ErrNotAMember) works on it.
+type TransactionError struct {
+	// InstructionIndex is the index of the failing instruction in the transaction
+	InstructionIndex int
+	// Code is the custom program error code
+	Code uint32
+	// Err is the matching multisig program error, nil if the error was raised by another program
+	// or if no logs tell which program raised it
+	Err *ProgramError
+	// Logs are the program logs of the transaction, when available
+	Logs []string
+
+	cause error
+}
+
+func (e *TransactionError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("instruction %d failed: %s", e.InstructionIndex, e.Err)
+	}
+	return fmt.Sprintf("instruction %d failed: custom program error: 0x%x", e.InstructionIndex, e.Code)
+}
+
+func (e *TransactionError) Unwrap() []error {
+	var errs []error
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	if e.cause != nil {
+		errs = append(errs, e.cause)
+	}
+	return errs
+}
+
+var customProgramErrorRegexp = regexp.MustCompile(`Error processing Instruction (\d+): custom program error: 0x([0-9a-fA-F]+)`)
+
+// DecodeTransactionError extracts the failing instruction and custom error code from an error returned by
+// rpc calls such as SendTransaction, whose preflight simulation failed.
+func DecodeTransactionError(err error) (*TransactionError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		if data, ok := rpcErr.Data.(map[string]interface{}); ok {
+			logs := toStrings(data["logs"])
+			if index, code, ok := decodeInstructionError(data["err"]); ok {
+				return newTransactionError(index, code, logs, err), true
+			}
+		}
+	}
+
+	// fall back to the error message, e.g. "Error processing Instruction 0: custom program error: 0x177a"
+	matches := customProgramErrorRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return nil, false
+	}
+	index, _ := strconv.Atoi(matches[1])
+	code, perr := strconv.ParseUint(matches[2], 16, 32)
+	if perr != nil {
+		return nil, false
+	}
+	return newTransactionError(index, uint32(code), nil, err), true
+}
+
+// DecodeSimulationError extracts the failing instruction and custom error code from a simulation result
+func DecodeSimulationError(result *rpc.SimulateTransactionResult) (*TransactionError, bool) {
+	if result == nil || result.Err == nil {
+		return nil, false
+	}
+	index, code, ok := decodeInstructionError(result.Err)
+	if !ok {
+		return nil, false
+	}
+	return newTransactionError(index, code, result.Logs, nil), true
+}
+
+func newTransactionError(index int, code uint32, logs []string, cause error) *TransactionError {
+	txErr := &TransactionError{
+		InstructionIndex: index,
+		Code:             code,
+		Logs:             logs,
+		cause:            cause,
+	}
+	if failedProgramIsMultisig(logs) {
+		txErr.Err, _ = ProgramErrorFromCode(code)
+	}
+	return txErr
+}
+
+// failedProgramIsMultisig reports whether the custom error was raised by the multisig program.
+// The first failure logged is the one raised: when a program invoked by a vault transaction fails,
+// the runtime logs the same error again for every caller up to the multisig program.
+// Without logs the program is unknown and false is returned.
+func failedProgramIsMultisig(logs []string) bool {
+	for _, line := range logs {
+		if strings.HasPrefix(line, "Program ") && strings.Contains(line, " failed: ") {
+			return strings.HasPrefix(line, "Program "+squads_multisig_program.ProgramID.String()+" ")
+		}
+	}
+	return false
+}
+
+// decodeInstructionError decodes a transaction error of the form {"InstructionError":[0,{"Custom":6005}]}
+func decodeInstructionError(v interface{}) (int, uint32, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, 0, false
+	}
+	pair, ok := m["InstructionError"].([]interface{})
+	if !ok || len(pair) != 2 {
+		return 0, 0, false
+	}
+	index, ok := toUint64(pair[0])
+	if !ok {
+		return 0, 0, false
+	}
+	detail, ok := pair[1].(map[string]interface{})
+	if !ok {
+		return 0, 0, false
+	}
+	code, ok := toUint64(detail["Custom"])
+	if !ok {
+		return 0, 0, false
+	}
+	return int(index), uint32(code), true
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		u, err := strconv.ParseUint(n.String(), 10, 64)
+		return u, err == nil
+	case float64:
+		return uint64(n), n >= 0
+	case int:
+		return uint64(n), n >= 0
+	case int64:
+		return uint64(n), n >= 0
+	case uint64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toStrings(v interface{}) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package squads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+func Test_DecodeTransactionError(t *testing.T) {
+	rpcErr := &jsonrpc.RPCError{
+		Code:    -32002,
+		Message: "Transaction simulation failed: Error processing Instruction 1: custom program error: 0x1775",
+		Data: map[string]interface{}{
+			"err": map[string]interface{}{
+				"InstructionError": []interface{}{json.Number("1"), map[string]interface{}{"Custom": json.Number("6005")}},
+			},
+			"logs": []interface{}{
+				"Program SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf invoke [1]",
+				"Program SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf failed: custom program error: 0x1775",
+			},
+		},
+	}
+	err := fmt.Errorf("send: %w", rpcErr)
+
+	txErr, ok := DecodeTransactionError(err)
+	if !ok {
+		t.Fatal("expected a transaction error")
+	}
+	if txErr.InstructionIndex != 1 || txErr.Code != 6005 || len(txErr.Logs) != 2 {
+		t.Fatalf("unexpected decoded error: %+v", txErr)
+	}
+	if !errors.Is(txErr, ErrNotAMember) {
+		t.Fatal("expected errors.Is to match ErrNotAMember")
+	}
+	if !errors.Is(txErr, rpcErr) {
+		t.Fatal("expected errors.Is to match the original rpc error")
+	}
+}
+
+func Test_DecodeTransactionErrorFromMessage(t *testing.T) {
+	err := errors.New("Transaction simulation failed: Error processing Instruction 0: custom program error: 0x177a")
+	txErr, ok := DecodeTransactionError(err)
+	if !ok {
+		t.Fatal("expected a transaction error")
+	}
+	// without logs the failing program is unknown
+	if txErr.InstructionIndex != 0 || txErr.Code != 6010 || txErr.Err != nil {
+		t.Fatalf("unexpected decoded error: %v", txErr)
+	}
+}
+
+func Test_DecodeSimulationError(t *testing.T) {
+	result := &rpc.SimulateTransactionResult{
+		Err: map[string]interface{}{
+			"InstructionError": []interface{}{float64(0), map[string]interface{}{"Custom": float64(6021)}},
+		},
+	}
+	txErr, ok := DecodeSimulationError(result)
+	if !ok || txErr.Code != 6021 || txErr.Err != nil {
+		t.Fatalf("expected an unmapped error without logs, got %v", txErr)
+	}
+
+	result.Logs = []string{"Program SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf failed: custom program error: 0x1785"}
+	txErr, ok = DecodeSimulationError(result)
+	if !ok || !errors.Is(txErr, ErrTimeLockNotReleased) {
+		t.Fatalf("unexpected decoded error: %v", txErr)
+	}
+
+	// custom errors raised by other programs are not mapped
+	result.Logs = []string{"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA failed: custom program error: 0x1775"}
+	txErr, ok = DecodeSimulationError(result)
+	if !ok || txErr.Err != nil {
+		t.Fatalf("unexpected decoded error: %v", txErr)
+	}
+}
+
+func Test_DecodeSimulationErrorFromInvokedProgram(t *testing.T) {
+	// a vault transaction execute whose inner program fails with an Anchor error in the range of the multisig program
+	result := &rpc.SimulateTransactionResult{
+		Err: map[string]interface{}{
+			"InstructionError": []interface{}{float64(0), map[string]interface{}{"Custom": float64(6005)}},
+		},
+		Logs: []string{
+			"Program SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf invoke [1]",
+			"Program log: Instruction: VaultTransactionExecute",
+			"Program 9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin invoke [2]",
+			"Program log: AnchorError occurred. Error Code: Unauthorized. Error Number: 6005. Error Message: Unauthorized.",
+			"Program 9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin consumed 4120 of 180000 compute units",
+			"Program 9xQeWvG816bUx9EPjHmaT23yvVM2ZWbrrpZb9PusVFin failed: custom program error: 0x1775",
+			"Program SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf consumed 24000 of 200000 compute units",
+			"Program SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf failed: custom program error: 0x1775",
+		},
+	}
+	txErr, ok := DecodeSimulationError(result)
+	if !ok || txErr.Code != 6005 || txErr.Err != nil || errors.Is(txErr, ErrNotAMember) {
+		t.Fatalf("error of the invoked program mapped to a multisig error: %v", txErr)
+	}
+}
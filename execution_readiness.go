@@ -0,0 +1,163 @@
+package squads
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultExecutionPollInterval is how often WaitUntilExecutable re-checks a proposal that is not approved yet
+const DefaultExecutionPollInterval = 5 * time.Second
+
+// ExecutionReadiness tells whether an approved transaction can be executed at a given cluster time
+type ExecutionReadiness struct {
+	TransactionIndex uint64 `json:"transactionIndex"`
+	// Executable is set when executing the transaction now passes the proposal checks of the program
+	Executable bool `json:"executable"`
+	// ExecutableAt is the cluster time from which the transaction can be executed.
+	// It is zero while the proposal is not approved and when the transaction can never be executed.
+	ExecutableAt time.Time `json:"executableAt"`
+	// ClusterTime is the unix timestamp of the clock sysvar the readiness was evaluated at
+	ClusterTime time.Time `json:"clusterTime"`
+	// Reason is the program error executing now would fail with, nil when the transaction is executable
+	Reason error `json:"-"`
+	// Final is set when the transaction can never be executed: it was already executed, the proposal
+	// was rejected or cancelled, or it became stale before it could be executed
+	Final bool `json:"final"`
+	// Proposal is the evaluated proposal the readiness is based on
+	Proposal *ProposalState `json:"proposal"`
+}
+
+// Wait returns how long to wait from the cluster time until the transaction becomes executable,
+// zero when it is executable now or when the point is unknown
+func (r *ExecutionReadiness) Wait() time.Duration {
+	if r.Executable || r.ExecutableAt.IsZero() {
+		return 0
+	}
+	return max(r.ExecutableAt.Sub(r.ClusterTime), 0)
+}
+
+// NewExecutionReadiness evaluates whether the transaction of a proposal can be executed at the given cluster time.
+// Config transactions cannot be executed once stale, while vault and batch transactions approved before
+// becoming stale still can.
+func NewExecutionReadiness(multisig *squads_multisig_program.Multisig, proposal *squads_multisig_program.Proposal, configTransaction bool, clusterTime time.Time) *ExecutionReadiness {
+	ps := NewProposalState(multisig, proposal)
+	r := &ExecutionReadiness{
+		TransactionIndex: proposal.TransactionIndex,
+		ClusterTime:      clusterTime,
+		Proposal:         ps,
+	}
+
+	switch ps.Status {
+	case ProposalStatusDraft, ProposalStatusActive:
+		r.Reason = ErrInvalidProposalStatus
+		r.Final = ps.CannotPass
+		return r
+	case ProposalStatusApproved:
+	default:
+		r.Reason = ErrInvalidProposalStatus
+		r.Final = true
+		return r
+	}
+
+	if configTransaction && ps.Stale {
+		r.Reason = ErrStaleProposal
+		r.Final = true
+		return r
+	}
+
+	r.ExecutableAt = ps.ApprovedAt.Add(time.Duration(multisig.TimeLock) * time.Second)
+	if clusterTime.Before(r.ExecutableAt) {
+		r.Reason = ErrTimeLockNotReleased
+		return r
+	}
+	r.Executable = true
+	return r
+}
+
+// ClusterTime returns the unix timestamp of the clock sysvar, the time the program checks time locks against
+func (s *Multisig) ClusterTime(ctx context.Context) (time.Time, error) {
+	out, err := s.client.GetAccountInfo(ctx, solana.SysVarClockPubkey)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// slot u64, epoch_start_timestamp i64, epoch u64, leader_schedule_epoch u64, unix_timestamp i64
+	data := out.Value.Data.GetBinary()
+	if len(data) < 40 {
+		return time.Time{}, fmt.Errorf("invalid clock sysvar data length %d", len(data))
+	}
+	return time.Unix(int64(binary.LittleEndian.Uint64(data[32:40])), 0).UTC(), nil
+}
+
+// ExecutionReadiness fetches a proposal, its transaction and the cluster time, and tells whether the
+// transaction can be executed now, or from when and why not
+func (s *Multisig) ExecutionReadiness(ctx context.Context, transactionIndex uint64) (*ExecutionReadiness, error) {
+	multisig, err := s.MultisigAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proposalPda, err := GetProposalPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := s.ProposalAccount(ctx, proposalPda)
+	if err != nil {
+		return nil, err
+	}
+	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.client.GetAccountInfo(ctx, transactionPda)
+	if err != nil {
+		return nil, err
+	}
+	configTransaction := bytes.HasPrefix(out.Value.Data.GetBinary(), squads_multisig_program.ConfigTransactionDiscriminator[:])
+
+	clusterTime, err := s.ClusterTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewExecutionReadiness(multisig, proposal, configTransaction, clusterTime), nil
+}
+
+// WaitUntilExecutable blocks until the transaction can be executed and returns the final readiness.
+// It re-checks the cluster time once the time lock should have been released, and polls every
+// pollInterval while the proposal is not approved yet; a zero pollInterval uses DefaultExecutionPollInterval.
+// It returns an error wrapping the blocking reason when the transaction can never be executed.
+func (s *Multisig) WaitUntilExecutable(ctx context.Context, transactionIndex uint64, pollInterval time.Duration) (*ExecutionReadiness, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultExecutionPollInterval
+	}
+	for {
+		readiness, err := s.ExecutionReadiness(ctx, transactionIndex)
+		if err != nil {
+			return nil, err
+		}
+		if readiness.Executable {
+			return readiness, nil
+		}
+		if readiness.Final {
+			return readiness, fmt.Errorf("transaction %d can not be executed: %w", transactionIndex, readiness.Reason)
+		}
+
+		// the cluster clock only advances with new slots, so never wait longer than the poll interval
+		wait := pollInterval
+		if errors.Is(readiness.Reason, ErrTimeLockNotReleased) {
+			wait = min(max(readiness.Wait(), time.Second), pollInterval)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return readiness, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
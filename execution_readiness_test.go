@@ -0,0 +1,141 @@
+package squads
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_ExecutionReadiness(t *testing.T) {
+	a := testMember(Vote)
+	multisig := testMultisig(1, a)
+	multisig.TimeLock = 3600
+	multisig.StaleTransactionIndex = 5
+
+	approved := &squads_multisig_program.Proposal{
+		TransactionIndex: 5,
+		Status:           &squads_multisig_program.ProposalStatusApproved{Timestamp: 1700000000},
+		Approved:         []solana.PublicKey{a.Key},
+	}
+
+	locked := NewExecutionReadiness(multisig, approved, false, time.Unix(1700000600, 0))
+	if locked.Executable || !errors.Is(locked.Reason, ErrTimeLockNotReleased) || locked.Final {
+		t.Fatalf("expected a time locked transaction: %+v", locked)
+	}
+	if locked.ExecutableAt.Unix() != 1700003600 || locked.Wait() != 50*time.Minute {
+		t.Fatalf("unexpected executable at %v, wait %v", locked.ExecutableAt, locked.Wait())
+	}
+
+	// stale vault transactions approved before becoming stale can still be executed
+	ready := NewExecutionReadiness(multisig, approved, false, time.Unix(1700003600, 0))
+	if !ready.Executable || ready.Reason != nil || ready.Wait() != 0 {
+		t.Fatalf("expected an executable transaction: %+v", ready)
+	}
+
+	stale := NewExecutionReadiness(multisig, approved, true, time.Unix(1700003600, 0))
+	if stale.Executable || !errors.Is(stale.Reason, ErrStaleProposal) || !stale.Final {
+		t.Fatalf("expected a stale config transaction: %+v", stale)
+	}
+
+	active := NewExecutionReadiness(multisig, &squads_multisig_program.Proposal{
+		TransactionIndex: 6,
+		Status:           &squads_multisig_program.ProposalStatusActive{Timestamp: 1700000000},
+	}, false, time.Unix(1700003600, 0))
+	if active.Executable || !errors.Is(active.Reason, ErrInvalidProposalStatus) || active.Final || !active.ExecutableAt.IsZero() {
+		t.Fatalf("expected a pending proposal: %+v", active)
+	}
+
+	executed := NewExecutionReadiness(multisig, &squads_multisig_program.Proposal{
+		TransactionIndex: 6,
+		Status:           &squads_multisig_program.ProposalStatusExecuted{Timestamp: 1700000000},
+	}, false, time.Unix(1700003600, 0))
+	if executed.Executable || !executed.Final {
+		t.Fatalf("expected an executed proposal: %+v", executed)
+	}
+}
+
+func testExecutionFixture(t *testing.T, timeLock uint32, status squads_multisig_program.ProposalStatus) (*squadstest.Client, *Multisig) {
+	t.Helper()
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+
+	a := testMember(Vote)
+	multisig := testMultisig(1, a)
+	multisig.TimeLock = timeLock
+	if err := client.SetBorshAccount(multisigPda, *multisig); err != nil {
+		t.Fatal(err)
+	}
+	proposalPda, err := GetProposalPda(multisigPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.SetBorshAccount(proposalPda, squads_multisig_program.Proposal{
+		Multisig:         multisigPda,
+		TransactionIndex: 1,
+		Status:           status,
+		Approved:         []solana.PublicKey{a.Key},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionPda, err := GetTransactionPda(multisigPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.SetBorshAccount(transactionPda, squads_multisig_program.VaultTransaction{
+		Multisig: multisigPda,
+		Index:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, New(client, multisigPda)
+}
+
+func Test_MultisigExecutionReadiness(t *testing.T) {
+	client, s := testExecutionFixture(t, 60, &squads_multisig_program.ProposalStatusApproved{Timestamp: 1700000000})
+	client.SetClock(100, 1700000030)
+
+	readiness, err := s.ExecutionReadiness(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readiness.Executable || readiness.ClusterTime.Unix() != 1700000030 || readiness.ExecutableAt.Unix() != 1700000060 {
+		t.Fatalf("unexpected readiness %+v", readiness)
+	}
+}
+
+func Test_WaitUntilExecutable(t *testing.T) {
+	client, s := testExecutionFixture(t, 60, &squads_multisig_program.ProposalStatusApproved{Timestamp: 1700000000})
+	client.SetClock(100, 1700000030)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		client.SetClock(200, 1700000060)
+	}()
+	readiness, err := s.WaitUntilExecutable(t.Context(), 1, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !readiness.Executable || readiness.ClusterTime.Unix() != 1700000060 {
+		t.Fatalf("unexpected readiness %+v", readiness)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	client.SetClock(300, 1700000030)
+	if _, err := s.WaitUntilExecutable(ctx, 1, 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context to expire, got %v", err)
+	}
+
+	client, s = testExecutionFixture(t, 0, &squads_multisig_program.ProposalStatusCancelled{Timestamp: 1700000000})
+	client.SetClock(100, 1700000030)
+	if _, err := s.WaitUntilExecutable(t.Context(), 1, 5*time.Millisecond); !errors.Is(err, ErrInvalidProposalStatus) {
+		t.Fatalf("expected an invalid proposal status, got %v", err)
+	}
+}
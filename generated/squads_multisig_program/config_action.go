@@ -0,0 +1,122 @@
+package squads_multisig_program
+
+import (
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+)
+
+// ConfigAction is a Borsh enum whose variants carry data, which the generated code cannot encode on its own:
+// it writes the variants without their index and skips them when decoding.
+// ConfigActionsWithVariants and DecodeConfigTransaction fill the gap without touching the generated files.
+
+// configActionVariant encodes the wrapped ConfigAction prefixed with its variant index
+type configActionVariant struct {
+	ConfigAction
+}
+
+func (v configActionVariant) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	index, err := configActionVariantIndex(v.ConfigAction)
+	if err != nil {
+		return err
+	}
+	if err := encoder.WriteUint8(index); err != nil {
+		return err
+	}
+	return encoder.Encode(v.ConfigAction)
+}
+
+// ConfigActionsWithVariants wraps actions so that ConfigTransactionCreateArgs and ConfigTransaction encode
+// each of them with its variant index
+func ConfigActionsWithVariants(actions []ConfigAction) []ConfigAction {
+	out := make([]ConfigAction, 0, len(actions))
+	for _, action := range actions {
+		if _, ok := action.(configActionVariant); !ok {
+			action = configActionVariant{action}
+		}
+		out = append(out, action)
+	}
+	return out
+}
+
+// DecodeConfigTransaction decodes a ConfigTransaction account, including the variant index of its actions
+func DecodeConfigTransaction(data []byte) (*ConfigTransaction, error) {
+	decoder := ag_binary.NewBorshDecoder(data)
+	discriminator, err := decoder.ReadTypeID()
+	if err != nil {
+		return nil, err
+	}
+	if !discriminator.Equal(ConfigTransactionDiscriminator[:]) {
+		return nil, fmt.Errorf("wrong discriminator: wanted %v, got %v", ConfigTransactionDiscriminator, discriminator)
+	}
+	obj := &ConfigTransaction{}
+	for _, field := range []any{&obj.Multisig, &obj.Creator, &obj.Index, &obj.Bump} {
+		if err := decoder.Decode(field); err != nil {
+			return nil, err
+		}
+	}
+	if obj.Actions, err = decodeConfigActions(decoder); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func configActionVariantIndex(action ConfigAction) (uint8, error) {
+	switch action.(type) {
+	case *ConfigActionAddMember:
+		return 0, nil
+	case *ConfigActionRemoveMember:
+		return 1, nil
+	case *ConfigActionChangeThreshold:
+		return 2, nil
+	case *ConfigActionSetTimeLock:
+		return 3, nil
+	case *ConfigActionAddSpendingLimit:
+		return 4, nil
+	case *ConfigActionRemoveSpendingLimit:
+		return 5, nil
+	case *ConfigActionSetRentCollector:
+		return 6, nil
+	default:
+		return 0, fmt.Errorf("unknown config action %T", action)
+	}
+}
+
+// decodeConfigActions decodes a vec of ConfigAction, each prefixed with its variant index
+func decodeConfigActions(decoder *ag_binary.Decoder) ([]ConfigAction, error) {
+	length, err := decoder.ReadUint32(ag_binary.LE)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]ConfigAction, 0, length)
+	for i := uint32(0); i < length; i++ {
+		index, err := decoder.ReadUint8()
+		if err != nil {
+			return nil, err
+		}
+		var action ConfigAction
+		switch index {
+		case 0:
+			action = new(ConfigActionAddMember)
+		case 1:
+			action = new(ConfigActionRemoveMember)
+		case 2:
+			action = new(ConfigActionChangeThreshold)
+		case 3:
+			action = new(ConfigActionSetTimeLock)
+		case 4:
+			action = new(ConfigActionAddSpendingLimit)
+		case 5:
+			action = new(ConfigActionRemoveSpendingLimit)
+		case 6:
+			action = new(ConfigActionSetRentCollector)
+		default:
+			return nil, fmt.Errorf("unknown config action variant %d", index)
+		}
+		if err := decoder.Decode(action); err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
@@ -0,0 +1,48 @@
+package squads_multisig_program
+
+import (
+	"bytes"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+)
+
+func TestConfigActionsWithVariants(t *testing.T) {
+	member := ag_solanago.MustPublicKeyFromBase58("G26QSXWEdY11iue8Dw2aushtw7hhVF5zHDhSXqSJGRLA")
+	transaction := ConfigTransaction{
+		Multisig: member,
+		Index:    7,
+		Actions: ConfigActionsWithVariants([]ConfigAction{
+			&ConfigActionChangeThreshold{NewThreshold: 2},
+			&ConfigActionRemoveMember{OldMember: member},
+		}),
+	}
+	buf := new(bytes.Buffer)
+	if err := ag_binary.NewBorshEncoder(buf).Encode(transaction); err != nil {
+		t.Fatal(err)
+	}
+	// discriminator, multisig, creator, index and bump precede the actions
+	actions := buf.Bytes()[8+32+32+8+1:]
+	if want := []byte{2, 0, 0, 0, 2, 2, 0, 1}; !bytes.HasPrefix(actions, want) {
+		t.Fatalf("actions encoded as %v, want prefix %v", actions, want)
+	}
+
+	decoded, err := DecodeConfigTransaction(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Index != 7 || len(decoded.Actions) != 2 {
+		t.Fatalf("unexpected config transaction %+v", decoded)
+	}
+	if action, ok := decoded.Actions[0].(*ConfigActionChangeThreshold); !ok || action.NewThreshold != 2 {
+		t.Fatalf("unexpected first action %#v", decoded.Actions[0])
+	}
+	if action, ok := decoded.Actions[1].(*ConfigActionRemoveMember); !ok || action.OldMember != member {
+		t.Fatalf("unexpected second action %#v", decoded.Actions[1])
+	}
+
+	if _, err := DecodeConfigTransaction(buf.Bytes()[1:]); err == nil {
+		t.Fatal("expected an error for a wrong discriminator")
+	}
+}
@@ -101,6 +101,11 @@ func (e *Encoder) Encode(v interface{}) error {
 	case ag_solanago.PublicKey:
 		_, err := e.w.Write(val[:])
 		return err
+	// MessageAddressTableLookup.EncodeWith encodes &m.AccountKey, without this case every message
+	// with an address table lookup fails to encode. The Decoder has the matching case.
+	case *ag_solanago.PublicKey:
+		_, err := e.w.Write(val[:])
+		return err
 	default:
 		return fmt.Errorf("unsupported type: %T", v)
 	}
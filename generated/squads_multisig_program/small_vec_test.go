@@ -85,3 +85,19 @@ func TestEncode(t *testing.T) {
 	}
 
 }
+
+func TestEncodeAddressTableLookup(t *testing.T) {
+	lookup := MessageAddressTableLookup{
+		AccountKey:      ag_solanago.MustPublicKeyFromBase58("G26QSXWEdY11iue8Dw2aushtw7hhVF5zHDhSXqSJGRLA"),
+		WritableIndexes: SmallVec[uint8, uint8]{Data: []uint8{2}},
+		ReadonlyIndexes: SmallVec[uint8, uint8]{Data: []uint8{0, 1}},
+	}
+	var buf bytes.Buffer
+	if err := lookup.EncodeWith(NewEncoder(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	want := append(lookup.AccountKey.Bytes(), 1, 2, 2, 0, 1)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("got %x, want %x", buf.Bytes(), want)
+	}
+}
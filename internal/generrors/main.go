@@ -0,0 +1,70 @@
+// Command generrors generates the program error table of the squads package from the program IDL.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"go/format"
+	"log"
+	"os"
+	"text/template"
+)
+
+type idlError struct {
+	Code uint32 `json:"code"`
+	Name string `json:"name"`
+	Msg  string `json:"msg"`
+}
+
+var tmpl = template.Must(template.New("errors").Parse(`// Code generated by go run ./internal/generrors. DO NOT EDIT.
+
+package {{ .Package }}
+
+// Custom errors of the multisig program, use errors.Is to match them.
+var (
+{{- range .Errors }}
+	// Err{{ .Name }}: {{ .Msg }}
+	Err{{ .Name }} = &ProgramError{Code: {{ .Code }}, Name: {{ printf "%q" .Name }}, Msg: {{ printf "%q" .Msg }}}
+{{- end }}
+)
+
+var programErrors = map[uint32]*ProgramError{
+{{- range .Errors }}
+	{{ .Code }}: Err{{ .Name }},
+{{- end }}
+}
+`))
+
+func main() {
+	idlPath := flag.String("idl", "generated/squads_multisig_program.json", "path to the program IDL")
+	outPath := flag.String("out", "program_errors.go", "output file")
+	pkg := flag.String("package", "squads", "package name of the output file")
+	flag.Parse()
+
+	raw, err := os.ReadFile(*idlPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var idl struct {
+		Errors []idlError `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &idl); err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{
+		"Package": *pkg,
+		"Errors":  idl.Errors,
+	}); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*outPath, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,266 @@
+package squads
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// KeyedAccount is a decoded program account and its address
+type KeyedAccount[T any] struct {
+	Address solana.PublicKey `json:"address"`
+	Account *T               `json:"account"`
+}
+
+// TransactionBufferStatus is the upload status of a transaction buffer
+type TransactionBufferStatus uint8
+
+const (
+	// TransactionBufferUploading is set while the buffer holds fewer bytes than its final size
+	TransactionBufferUploading TransactionBufferStatus = iota
+	// TransactionBufferComplete is set once the whole message was uploaded
+	TransactionBufferComplete
+)
+
+func (s TransactionBufferStatus) String() string {
+	switch s {
+	case TransactionBufferUploading:
+		return "Uploading"
+	case TransactionBufferComplete:
+		return "Complete"
+	}
+	return fmt.Sprintf("TransactionBufferStatus(%d)", uint8(s))
+}
+
+// GetTransactionBufferStatus returns the upload status of a transaction buffer
+func GetTransactionBufferStatus(buffer *squads_multisig_program.TransactionBuffer) TransactionBufferStatus {
+	if len(buffer.Buffer) >= int(buffer.FinalBufferSize) {
+		return TransactionBufferComplete
+	}
+	return TransactionBufferUploading
+}
+
+// SpendingLimitStatus tells whether a spending limit has an amount left in its current period
+type SpendingLimitStatus uint8
+
+const (
+	// SpendingLimitAvailable is set while the spending limit has a remaining amount
+	SpendingLimitAvailable SpendingLimitStatus = iota
+	// SpendingLimitExhausted is set once the remaining amount recorded on chain reached zero
+	SpendingLimitExhausted
+)
+
+func (s SpendingLimitStatus) String() string {
+	switch s {
+	case SpendingLimitAvailable:
+		return "Available"
+	case SpendingLimitExhausted:
+		return "Exhausted"
+	}
+	return fmt.Sprintf("SpendingLimitStatus(%d)", uint8(s))
+}
+
+// GetSpendingLimitStatus returns the status of a spending limit as recorded on chain
+func GetSpendingLimitStatus(spendingLimit *squads_multisig_program.SpendingLimit) SpendingLimitStatus {
+	if spendingLimit.RemainingAmount == 0 {
+		return SpendingLimitExhausted
+	}
+	return SpendingLimitAvailable
+}
+
+// multisigFieldOffset is the offset of the multisig field, right after the discriminator, in every account listed here
+const multisigFieldOffset = 8
+
+type borshAccount[T any] interface {
+	*T
+	UnmarshalWithDecoder(decoder *ag_binary.Decoder) error
+}
+
+// listProgramAccounts fetches and decodes the multisig program accounts with the given discriminator
+// that belong to multisigPda
+func listProgramAccounts[T any, PT borshAccount[T]](ctx context.Context, client RPCClient, multisigPda solana.PublicKey, discriminator [8]byte) ([]KeyedAccount[T], error) {
+	out, err := client.GetProgramAccountsWithOpts(ctx, squads_multisig_program.ProgramID, &rpc.GetProgramAccountsOpts{
+		Filters: []rpc.RPCFilter{
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: discriminator[:]}},
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: multisigFieldOffset, Bytes: multisigPda.Bytes()}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]KeyedAccount[T], 0, len(out))
+	for _, keyed := range out {
+		data := keyed.Account.Data.GetBinary()
+		// filters are not guaranteed to be applied by every rpc node
+		if !bytes.HasPrefix(data, discriminator[:]) {
+			continue
+		}
+		account := PT(new(T))
+		var err error
+		if transaction, ok := any(account).(*squads_multisig_program.ConfigTransaction); ok {
+			// config actions need their variant index, which the generated decoder skips
+			var decoded *squads_multisig_program.ConfigTransaction
+			if decoded, err = squads_multisig_program.DecodeConfigTransaction(data); err == nil {
+				*transaction = *decoded
+			}
+		} else {
+			err = account.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode account %s: %w", keyed.Pubkey, err)
+		}
+		accounts = append(accounts, KeyedAccount[T]{Address: keyed.Pubkey, Account: account})
+	}
+	return accounts, nil
+}
+
+// proposalStatuses maps the transaction indexes of the multisig proposals to their status
+func (s *Multisig) proposalStatuses(ctx context.Context) (map[uint64]ProposalStatus, error) {
+	proposals, err := s.Proposals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[uint64]ProposalStatus, len(proposals))
+	for _, proposal := range proposals {
+		statuses[proposal.Account.TransactionIndex] = GetProposalStatus(proposal.Account.Status)
+	}
+	return statuses, nil
+}
+
+// filterByProposalStatus keeps the transactions whose proposal has one of the given statuses.
+// Transactions without a proposal are dropped whenever statuses are given.
+func filterByProposalStatus[T any](ctx context.Context, s *Multisig, accounts []KeyedAccount[T], index func(*T) uint64, statuses []ProposalStatus) ([]KeyedAccount[T], error) {
+	if len(statuses) == 0 {
+		return accounts, nil
+	}
+	proposalStatuses, err := s.proposalStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(accounts, func(account KeyedAccount[T]) bool {
+		status, ok := proposalStatuses[index(account.Account)]
+		return !ok || !slices.Contains(statuses, status)
+	}), nil
+}
+
+// Proposals lists the proposals of the multisig sorted by transaction index.
+// When statuses are given, only the proposals with one of them are returned.
+func (s *Multisig) Proposals(ctx context.Context, statuses ...ProposalStatus) ([]KeyedAccount[squads_multisig_program.Proposal], error) {
+	proposals, err := listProgramAccounts[squads_multisig_program.Proposal](ctx, s.client, s.multisigPda, squads_multisig_program.ProposalDiscriminator)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) > 0 {
+		proposals = slices.DeleteFunc(proposals, func(proposal KeyedAccount[squads_multisig_program.Proposal]) bool {
+			return !slices.Contains(statuses, GetProposalStatus(proposal.Account.Status))
+		})
+	}
+	slices.SortFunc(proposals, func(a, b KeyedAccount[squads_multisig_program.Proposal]) int {
+		return cmp.Compare(a.Account.TransactionIndex, b.Account.TransactionIndex)
+	})
+	return proposals, nil
+}
+
+// VaultTransactions lists the vault transactions of the multisig sorted by index.
+// When statuses are given, only the transactions whose proposal has one of them are returned.
+func (s *Multisig) VaultTransactions(ctx context.Context, statuses ...ProposalStatus) ([]KeyedAccount[squads_multisig_program.VaultTransaction], error) {
+	transactions, err := listProgramAccounts[squads_multisig_program.VaultTransaction](ctx, s.client, s.multisigPda, squads_multisig_program.VaultTransactionDiscriminator)
+	if err != nil {
+		return nil, err
+	}
+	index := func(transaction *squads_multisig_program.VaultTransaction) uint64 { return transaction.Index }
+	transactions, err = filterByProposalStatus(ctx, s, transactions, index, statuses)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(transactions, func(a, b KeyedAccount[squads_multisig_program.VaultTransaction]) int {
+		return cmp.Compare(index(a.Account), index(b.Account))
+	})
+	return transactions, nil
+}
+
+// ConfigTransactions lists the config transactions of the multisig sorted by index.
+// When statuses are given, only the transactions whose proposal has one of them are returned.
+func (s *Multisig) ConfigTransactions(ctx context.Context, statuses ...ProposalStatus) ([]KeyedAccount[squads_multisig_program.ConfigTransaction], error) {
+	transactions, err := listProgramAccounts[squads_multisig_program.ConfigTransaction](ctx, s.client, s.multisigPda, squads_multisig_program.ConfigTransactionDiscriminator)
+	if err != nil {
+		return nil, err
+	}
+	index := func(transaction *squads_multisig_program.ConfigTransaction) uint64 { return transaction.Index }
+	transactions, err = filterByProposalStatus(ctx, s, transactions, index, statuses)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(transactions, func(a, b KeyedAccount[squads_multisig_program.ConfigTransaction]) int {
+		return cmp.Compare(index(a.Account), index(b.Account))
+	})
+	return transactions, nil
+}
+
+// Batches lists the batches of the multisig sorted by index.
+// When statuses are given, only the batches whose proposal has one of them are returned.
+func (s *Multisig) Batches(ctx context.Context, statuses ...ProposalStatus) ([]KeyedAccount[squads_multisig_program.Batch], error) {
+	batches, err := listProgramAccounts[squads_multisig_program.Batch](ctx, s.client, s.multisigPda, squads_multisig_program.BatchDiscriminator)
+	if err != nil {
+		return nil, err
+	}
+	index := func(batch *squads_multisig_program.Batch) uint64 { return batch.Index }
+	batches, err = filterByProposalStatus(ctx, s, batches, index, statuses)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(batches, func(a, b KeyedAccount[squads_multisig_program.Batch]) int {
+		return cmp.Compare(index(a.Account), index(b.Account))
+	})
+	return batches, nil
+}
+
+// SpendingLimits lists the spending limits of the multisig sorted by vault index, then address.
+// When statuses are given, only the spending limits with one of them are returned.
+func (s *Multisig) SpendingLimits(ctx context.Context, statuses ...SpendingLimitStatus) ([]KeyedAccount[squads_multisig_program.SpendingLimit], error) {
+	spendingLimits, err := listProgramAccounts[squads_multisig_program.SpendingLimit](ctx, s.client, s.multisigPda, squads_multisig_program.SpendingLimitDiscriminator)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) > 0 {
+		spendingLimits = slices.DeleteFunc(spendingLimits, func(spendingLimit KeyedAccount[squads_multisig_program.SpendingLimit]) bool {
+			return !slices.Contains(statuses, GetSpendingLimitStatus(spendingLimit.Account))
+		})
+	}
+	slices.SortFunc(spendingLimits, func(a, b KeyedAccount[squads_multisig_program.SpendingLimit]) int {
+		return cmp.Or(
+			cmp.Compare(a.Account.VaultIndex, b.Account.VaultIndex),
+			comparePublicKeys(a.Address, b.Address),
+		)
+	})
+	return spendingLimits, nil
+}
+
+// TransactionBuffers lists the transaction buffers of the multisig sorted by buffer index, then address.
+// When statuses are given, only the buffers with one of them are returned.
+func (s *Multisig) TransactionBuffers(ctx context.Context, statuses ...TransactionBufferStatus) ([]KeyedAccount[squads_multisig_program.TransactionBuffer], error) {
+	buffers, err := listProgramAccounts[squads_multisig_program.TransactionBuffer](ctx, s.client, s.multisigPda, squads_multisig_program.TransactionBufferDiscriminator)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) > 0 {
+		buffers = slices.DeleteFunc(buffers, func(buffer KeyedAccount[squads_multisig_program.TransactionBuffer]) bool {
+			return !slices.Contains(statuses, GetTransactionBufferStatus(buffer.Account))
+		})
+	}
+	slices.SortFunc(buffers, func(a, b KeyedAccount[squads_multisig_program.TransactionBuffer]) int {
+		return cmp.Or(
+			cmp.Compare(a.Account.BufferIndex, b.Account.BufferIndex),
+			comparePublicKeys(a.Address, b.Address),
+		)
+	})
+	return buffers, nil
+}
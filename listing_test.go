@@ -0,0 +1,136 @@
+package squads
+
+import (
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func setAccounts(t *testing.T, client *squadstest.Client, accounts ...interface{}) {
+	t.Helper()
+	for _, account := range accounts {
+		if err := client.SetBorshAccount(solana.NewWallet().PublicKey(), account); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_ListTransactions(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	other := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+
+	setAccounts(t, client,
+		squads_multisig_program.Proposal{Multisig: multisigPda, TransactionIndex: 3, Status: &squads_multisig_program.ProposalStatusApproved{}},
+		squads_multisig_program.Proposal{Multisig: multisigPda, TransactionIndex: 1, Status: &squads_multisig_program.ProposalStatusExecuted{}},
+		squads_multisig_program.Proposal{Multisig: multisigPda, TransactionIndex: 2, Status: &squads_multisig_program.ProposalStatusActive{}},
+		squads_multisig_program.Proposal{Multisig: other, TransactionIndex: 4, Status: &squads_multisig_program.ProposalStatusActive{}},
+		squads_multisig_program.VaultTransaction{Multisig: multisigPda, Index: 3},
+		squads_multisig_program.VaultTransaction{Multisig: multisigPda, Index: 1},
+		squads_multisig_program.VaultTransaction{Multisig: multisigPda, Index: 5},
+		squads_multisig_program.VaultTransaction{Multisig: other, Index: 2},
+		squads_multisig_program.ConfigTransaction{Multisig: multisigPda, Index: 2},
+		squads_multisig_program.Batch{Multisig: multisigPda, Index: 4},
+	)
+
+	proposals, err := s.Proposals(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals) != 3 || proposals[0].Account.TransactionIndex != 1 || proposals[2].Account.TransactionIndex != 3 {
+		t.Fatalf("unexpected proposals %+v", proposals)
+	}
+	active, err := s.Proposals(t.Context(), ProposalStatusActive, ProposalStatusApproved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 2 || active[0].Account.TransactionIndex != 2 {
+		t.Fatalf("unexpected active proposals %+v", active)
+	}
+
+	transactions, err := s.VaultTransactions(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var indexes []uint64
+	for _, transaction := range transactions {
+		indexes = append(indexes, transaction.Account.Index)
+	}
+	if len(indexes) != 3 || indexes[0] != 1 || indexes[1] != 3 || indexes[2] != 5 {
+		t.Fatalf("unexpected vault transactions %v", indexes)
+	}
+
+	// transaction 5 has no proposal yet
+	approved, err := s.VaultTransactions(t.Context(), ProposalStatusApproved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approved) != 1 || approved[0].Account.Index != 3 {
+		t.Fatalf("unexpected approved vault transactions %+v", approved)
+	}
+
+	configTransactions, err := s.ConfigTransactions(t.Context(), ProposalStatusActive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configTransactions) != 1 || configTransactions[0].Account.Index != 2 {
+		t.Fatalf("unexpected config transactions %+v", configTransactions)
+	}
+
+	batches, err := s.Batches(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || batches[0].Account.Index != 4 {
+		t.Fatalf("unexpected batches %+v", batches)
+	}
+	if batches, err := s.Batches(t.Context(), ProposalStatusActive); err != nil || len(batches) != 0 {
+		t.Fatalf("unexpected batches %+v: %v", batches, err)
+	}
+}
+
+func Test_ListSpendingLimitsAndBuffers(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+
+	setAccounts(t, client,
+		squads_multisig_program.SpendingLimit{Multisig: multisigPda, VaultIndex: 1, Amount: 10, RemainingAmount: 0},
+		squads_multisig_program.SpendingLimit{Multisig: multisigPda, VaultIndex: 0, Amount: 10, RemainingAmount: 5},
+		squads_multisig_program.TransactionBuffer{Multisig: multisigPda, BufferIndex: 1, FinalBufferSize: 4, Buffer: []byte{1, 2}},
+		squads_multisig_program.TransactionBuffer{Multisig: multisigPda, BufferIndex: 0, FinalBufferSize: 2, Buffer: []byte{1, 2}},
+	)
+
+	spendingLimits, err := s.SpendingLimits(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spendingLimits) != 2 || spendingLimits[0].Account.VaultIndex != 0 {
+		t.Fatalf("unexpected spending limits %+v", spendingLimits)
+	}
+	exhausted, err := s.SpendingLimits(t.Context(), SpendingLimitExhausted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exhausted) != 1 || exhausted[0].Account.VaultIndex != 1 {
+		t.Fatalf("unexpected exhausted spending limits %+v", exhausted)
+	}
+
+	buffers, err := s.TransactionBuffers(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buffers) != 2 || buffers[0].Account.BufferIndex != 0 {
+		t.Fatalf("unexpected buffers %+v", buffers)
+	}
+	uploading, err := s.TransactionBuffers(t.Context(), TransactionBufferUploading)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploading) != 1 || uploading[0].Account.BufferIndex != 1 {
+		t.Fatalf("unexpected uploading buffers %+v", uploading)
+	}
+}
@@ -7,17 +7,16 @@ import (
 	ag_binary "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/solana-go"
 	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
-	"github.com/gagliardetto/solana-go/rpc"
 )
 
 // Multisig represents a multisig wallet
 type Multisig struct {
 	multisigPda solana.PublicKey
-	client      *rpc.Client
+	client      RPCClient
 }
 
 // New creates a new Multisig instance
-func New(client *rpc.Client, multisigPda solana.PublicKey) *Multisig {
+func New(client RPCClient, multisigPda solana.PublicKey) *Multisig {
 	return &Multisig{
 		multisigPda: multisigPda,
 		client:      client,
@@ -56,6 +55,15 @@ func (s *Multisig) VaultTransactionAccount(ctx context.Context, transactionPda s
 	return account, nil
 }
 
+// ConfigTransactionAccount retrieves the config transaction account information
+func (s *Multisig) ConfigTransactionAccount(ctx context.Context, transactionPda solana.PublicKey) (*squads_multisig_program.ConfigTransaction, error) {
+	out, err := s.client.GetAccountInfo(ctx, transactionPda)
+	if err != nil {
+		return nil, err
+	}
+	return squads_multisig_program.DecodeConfigTransaction(out.Value.Data.GetBinary())
+}
+
 // ProposalAccount retrieves the proposal account information
 func (s *Multisig) ProposalAccount(ctx context.Context, proposalPda solana.PublicKey) (*squads_multisig_program.Proposal, error) {
 	out, err := s.client.GetAccountInfo(ctx, proposalPda)
@@ -90,20 +98,12 @@ func (s *Multisig) MultisigAddMemeberIx(ctx context.Context, configAuthority, re
 }
 
 // MultisigAddMemeberTx creates a transaction to add a member to the multisig
-func (s *Multisig) MultisigAddMemeberTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, member squads_multisig_program.Member) (*solana.Transaction, error) {
+func (s *Multisig) MultisigAddMemeberTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, member squads_multisig_program.Member, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.MultisigAddMemeberIx(ctx, configAuthority, rentPayer, member)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentPayer, opts)
 }
 
 // MultisigRemoveMemberIx creates an instruction to remove a member from the multisig
@@ -124,20 +124,12 @@ func (s *Multisig) MultisigRemoveMemberIx(ctx context.Context, configAuthority,
 }
 
 // MultisigRemoveMemberTx creates a transaction to remove a member from the multisig
-func (s *Multisig) MultisigRemoveMemberTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, member solana.PublicKey) (*solana.Transaction, error) {
+func (s *Multisig) MultisigRemoveMemberTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, member solana.PublicKey, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.MultisigRemoveMemberIx(ctx, configAuthority, rentPayer, member)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentPayer, opts)
 }
 
 // MultisigChangeThresholdIx creates an instruction to change the threshold of the multisig
@@ -158,20 +150,12 @@ func (s *Multisig) MultisigChangeThresholdIx(ctx context.Context, configAuthorit
 }
 
 // MultisigChangeThresholdTx creates a transaction to change the threshold of the multisig
-func (s *Multisig) MultisigChangeThresholdTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, threshold uint16) (*solana.Transaction, error) {
+func (s *Multisig) MultisigChangeThresholdTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, threshold uint16, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.MultisigChangeThresholdIx(ctx, configAuthority, rentPayer, threshold)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentPayer, opts)
 }
 
 // MultisigSetConfigAuthorityIx creates an instruction to set the config authority of the multisig
@@ -192,20 +176,12 @@ func (s *Multisig) MultisigSetConfigAuthorityIx(ctx context.Context, configAutho
 }
 
 // MultisigSetConfigAuthorityTx creates a transaction to set the config authority of the multisig
-func (s *Multisig) MultisigSetConfigAuthorityTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, newConfigAuthority solana.PublicKey) (*solana.Transaction, error) {
+func (s *Multisig) MultisigSetConfigAuthorityTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, newConfigAuthority solana.PublicKey, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.MultisigSetConfigAuthorityIx(ctx, configAuthority, rentPayer, newConfigAuthority)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentPayer, opts)
 }
 
 // MultisigSetRentCollectorIx creates an instruction to set the rent collector of the multisig
@@ -226,20 +202,12 @@ func (s *Multisig) MultisigSetRentCollectorIx(ctx context.Context, configAuthori
 }
 
 // MultisigSetRentCollectorTx creates a transaction to set the rent collector of the multisig
-func (s *Multisig) MultisigSetRentCollectorTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, rentCollector *solana.PublicKey) (*solana.Transaction, error) {
+func (s *Multisig) MultisigSetRentCollectorTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, rentCollector *solana.PublicKey, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.MultisigSetRentCollectorIx(ctx, configAuthority, rentPayer, rentCollector)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentPayer, opts)
 }
 
 // MultisigSetTimeLockIx creates an instruction to set the time lock for the multisig
@@ -260,20 +228,12 @@ func (s *Multisig) MultisigSetTimeLockIx(ctx context.Context, configAuthority, r
 }
 
 // MultisigSetTimeLockTx creates a transaction to set the time lock for the multisig
-func (s *Multisig) MultisigSetTimeLockTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, timelock uint32) (*solana.Transaction, error) {
+func (s *Multisig) MultisigSetTimeLockTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, timelock uint32, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.MultisigSetTimeLockIx(ctx, configAuthority, rentPayer, timelock)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentPayer, opts)
 }
 
 // MultisigAddSpendingLimitIx creates an instruction to add a spending limit to the multisig
@@ -291,20 +251,12 @@ func (s *Multisig) MultisigAddSpendingLimitIx(ctx context.Context, configAuthori
 }
 
 // MultisigAddSpendingLimitTx creates a transaction to add a spending limit to the multisig
-func (s *Multisig) MultisigAddSpendingLimitTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, spendingLimitPda solana.PublicKey, args *squads_multisig_program.MultisigAddSpendingLimitArgs) (*solana.Transaction, error) {
+func (s *Multisig) MultisigAddSpendingLimitTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, spendingLimitPda solana.PublicKey, args *squads_multisig_program.MultisigAddSpendingLimitArgs, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.MultisigAddSpendingLimitIx(ctx, configAuthority, rentPayer, spendingLimitPda, args)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentPayer, opts)
 }
 
 // MultisigRemoveSpendingLimitIx creates an instruction to remove a spending limit from the multisig
@@ -322,20 +274,12 @@ func (s *Multisig) MultisigRemoveSpendingLimitIx(ctx context.Context, configAuth
 }
 
 // MultisigRemoveSpendingLimitTx creates a transaction to remove a spending limit from the multisig
-func (s *Multisig) MultisigRemoveSpendingLimitTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, spendingLimitPda solana.PublicKey) (*solana.Transaction, error) {
+func (s *Multisig) MultisigRemoveSpendingLimitTx(ctx context.Context, configAuthority, rentPayer solana.PublicKey, spendingLimitPda solana.PublicKey, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.MultisigRemoveSpendingLimitIx(ctx, configAuthority, rentPayer, spendingLimitPda)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentPayer, opts)
 }
 
 // SpendingLimitUseIx creates an instruction to use a spending limit from a multisig vault
@@ -358,28 +302,60 @@ func (s *Multisig) SpendingLimitUseIx(ctx context.Context, member, spendingLimit
 }
 
 // SpendingLimitUseTx creates a complete transaction to use a spending limit from a multisig vault
-func (s *Multisig) SpendingLimitUseTx(ctx context.Context, member, spendingLimitPda, vault, destination, mint, vaultTokenAccount, destinationTokenAccount solana.PublicKey, args *squads_multisig_program.SpendingLimitUseArgs) (*solana.Transaction, error) {
+func (s *Multisig) SpendingLimitUseTx(ctx context.Context, member, spendingLimitPda, vault, destination, mint, vaultTokenAccount, destinationTokenAccount solana.PublicKey, args *squads_multisig_program.SpendingLimitUseArgs, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.SpendingLimitUseIx(ctx, member, spendingLimitPda, vault, destination, mint, vaultTokenAccount, destinationTokenAccount, args)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, member, opts)
+}
+
+// VaultTransactionMessageOpts configures how instructions are compiled into the message of a vault or batch transaction.
+// A nil *VaultTransactionMessageOpts compiles every account into the static keys.
+type VaultTransactionMessageOpts struct {
+	// AddressLookupTables are fetched, and the accounts they hold are compiled into lookups instead of static keys
+	AddressLookupTables []solana.PublicKey
+	// EphemeralSigners are placeholder signer keys, EphemeralSigners[i] is replaced by the ephemeral
+	// signer PDA at index i of the transaction (see ReplaceEphemeralSigners)
+	EphemeralSigners []solana.PublicKey
+}
+
+// vaultTransactionMessageBytes compiles instructions into a multisig transaction message with the vault as payer.
+// The placeholders of messageOpts are rewritten into ephemeral signers of transactionPda, their count is returned.
+func (s *Multisig) vaultTransactionMessageBytes(ctx context.Context, transactionPda solana.PublicKey, vaultIndex uint8, instructions []solana.Instruction, messageOpts *VaultTransactionMessageOpts) ([]byte, uint8, error) {
+	if messageOpts == nil {
+		messageOpts = &VaultTransactionMessageOpts{}
+	}
+	vaultPda, err := GetVaultPda(s.multisigPda, vaultIndex)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(member),
-	)
+	instructions, ephemeralSigners, _, err := ReplaceEphemeralSigners(transactionPda, vaultPda, messageOpts.EphemeralSigners, instructions)
+	if err != nil {
+		return nil, 0, err
+	}
+	addressLookupTableAccounts, err := s.AddressLookupTableAccounts(ctx, messageOpts.AddressLookupTables)
+	if err != nil {
+		return nil, 0, err
+	}
+	txMessageBytes, err := TransactionMessageToMultisigTransactionMessageBytes(TransactionMessage{
+		PayerKey:        vaultPda,
+		Instructions:    instructions,
+		RecentBlockhash: solana.Hash{}, //unused ,canbe zero hash
+	}, addressLookupTableAccounts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return txMessageBytes, ephemeralSigners, nil
 }
 
 // VaultTransactionCreateIx creates an instruction to create a vault transaction
 func (s *Multisig) VaultTransactionCreateIx(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction) (solana.Instruction, error) {
-	vaultPda, err := GetVaultPda(s.multisigPda, vaultIndex)
-	if err != nil {
-		return nil, err
-	}
+	return s.VaultTransactionCreateIxWithOpts(ctx, creatorAndPayer, vaultIndex, transactionIndex, instructions, nil)
+}
+
+// VaultTransactionCreateIxWithOpts creates an instruction to create a vault transaction whose message is compiled with messageOpts
+func (s *Multisig) VaultTransactionCreateIxWithOpts(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction, messageOpts *VaultTransactionMessageOpts) (solana.Instruction, error) {
 	if transactionIndex == 0 {
 		multisigInfo, err := s.MultisigAccount(ctx)
 		if err != nil {
@@ -393,15 +369,14 @@ func (s *Multisig) VaultTransactionCreateIx(ctx context.Context, creatorAndPayer
 		return nil, err
 	}
 
-	txMessageBytes, err := TransactionMessageToMultisigTransactionMessageBytes(TransactionMessage{
-		PayerKey:        vaultPda,
-		Instructions:    instructions,
-		RecentBlockhash: solana.Hash{}, //unused ,canbe zero hash
-	}, []addresslookuptable.KeyedAddressLookupTable{})
+	txMessageBytes, ephemeralSigners, err := s.vaultTransactionMessageBytes(ctx, transactionPda, vaultIndex, instructions, messageOpts)
+	if err != nil {
+		return nil, err
+	}
 
 	args := squads_multisig_program.VaultTransactionCreateArgs{
 		VaultIndex:         vaultIndex,
-		EphemeralSigners:   0,
+		EphemeralSigners:   ephemeralSigners,
 		TransactionMessage: txMessageBytes,
 	}
 
@@ -418,20 +393,17 @@ func (s *Multisig) VaultTransactionCreateIx(ctx context.Context, creatorAndPayer
 }
 
 // VaultTransactionCreateTx creates a transaction to create a vault transaction
-func (s *Multisig) VaultTransactionCreateTx(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction) (*solana.Transaction, error) {
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	ix, err := s.VaultTransactionCreateIx(ctx, creatorAndPayer, vaultIndex, transactionIndex, instructions)
+func (s *Multisig) VaultTransactionCreateTx(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction, opts ...TxOption) (*solana.Transaction, error) {
+	return s.VaultTransactionCreateTxWithOpts(ctx, creatorAndPayer, vaultIndex, transactionIndex, instructions, nil, opts...)
+}
+
+// VaultTransactionCreateTxWithOpts creates a transaction to create a vault transaction whose message is compiled with messageOpts
+func (s *Multisig) VaultTransactionCreateTxWithOpts(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction, messageOpts *VaultTransactionMessageOpts, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.VaultTransactionCreateIxWithOpts(ctx, creatorAndPayer, vaultIndex, transactionIndex, instructions, messageOpts)
 	if err != nil {
 		return nil, err
 	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(creatorAndPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, creatorAndPayer, opts)
 }
 
 // ProposalCreateIx creates an instruction to create a proposal
@@ -456,29 +428,22 @@ func (s *Multisig) ProposalCreateIx(ctx context.Context, creatorAndPayer solana.
 }
 
 // ProposalCreateTx creates a transaction to create a proposal
-func (s *Multisig) ProposalCreateTx(ctx context.Context, creatorAndPayer solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
+func (s *Multisig) ProposalCreateTx(ctx context.Context, creatorAndPayer solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ProposalCreateIx(ctx, creatorAndPayer, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
 
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(creatorAndPayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, creatorAndPayer, opts)
 }
 
 // VaultTransactionAndProposalTx creates a transaction that includes both vault transaction creation and proposal creation
-func (s *Multisig) VaultTransactionAndProposalTx(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction, autoApprove bool) (*solana.Transaction, error) {
-	vaultPda, err := GetVaultPda(s.multisigPda, vaultIndex)
-	if err != nil {
-		return nil, err
-	}
+func (s *Multisig) VaultTransactionAndProposalTx(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction, autoApprove bool, opts ...TxOption) (*solana.Transaction, error) {
+	return s.VaultTransactionAndProposalTxWithOpts(ctx, creatorAndPayer, vaultIndex, transactionIndex, instructions, nil, autoApprove, opts...)
+}
+
+// VaultTransactionAndProposalTxWithOpts is VaultTransactionAndProposalTx with the vault transaction message compiled with messageOpts
+func (s *Multisig) VaultTransactionAndProposalTxWithOpts(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction, messageOpts *VaultTransactionMessageOpts, autoApprove bool, opts ...TxOption) (*solana.Transaction, error) {
 	if transactionIndex == 0 {
 		multisigInfo, err := s.MultisigAccount(ctx)
 		if err != nil {
@@ -491,22 +456,14 @@ func (s *Multisig) VaultTransactionAndProposalTx(ctx context.Context, creatorAnd
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	txMessageBytes, err := TransactionMessageToMultisigTransactionMessageBytes(TransactionMessage{
-		PayerKey:        vaultPda,
-		Instructions:    instructions,
-		RecentBlockhash: recent.Value.Blockhash,
-	}, []addresslookuptable.KeyedAddressLookupTable{})
+	txMessageBytes, ephemeralSigners, err := s.vaultTransactionMessageBytes(ctx, transactionPda, vaultIndex, instructions, messageOpts)
 	if err != nil {
 		return nil, err
 	}
 
 	args := squads_multisig_program.VaultTransactionCreateArgs{
 		VaultIndex:         vaultIndex,
-		EphemeralSigners:   0,
+		EphemeralSigners:   ephemeralSigners,
 		TransactionMessage: txMessageBytes,
 	}
 
@@ -550,11 +507,7 @@ func (s *Multisig) VaultTransactionAndProposalTx(ctx context.Context, creatorAnd
 		ixs = append(ixs, paIx)
 	}
 
-	return solana.NewTransaction(
-		ixs,
-		recent.Value.Blockhash,
-		solana.TransactionPayer(creatorAndPayer),
-	)
+	return buildTransaction(ctx, s.client, ixs, creatorAndPayer, opts)
 }
 
 // ProposalApproveIx creates an instruction to approve a proposal
@@ -577,20 +530,12 @@ func (s *Multisig) ProposalApproveIx(ctx context.Context, voter solana.PublicKey
 }
 
 // ProposalApproveTx creates a transaction to approve a proposal
-func (s *Multisig) ProposalApproveTx(ctx context.Context, voter solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
+func (s *Multisig) ProposalApproveTx(ctx context.Context, voter solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ProposalApproveIx(ctx, voter, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(voter),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, voter, opts)
 }
 
 // ProposalRejectIx creates an instruction to reject a proposal.
@@ -613,20 +558,12 @@ func (s *Multisig) ProposalRejectIx(ctx context.Context, voter solana.PublicKey,
 }
 
 // ProposalRejectTx creates a transaction to reject a proposal.
-func (s *Multisig) ProposalRejectTx(ctx context.Context, voter solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
+func (s *Multisig) ProposalRejectTx(ctx context.Context, voter solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ProposalRejectIx(ctx, voter, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(voter),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, voter, opts)
 }
 
 // ProposalCancelIx creates an instruction to cancel a proposal.
@@ -649,38 +586,39 @@ func (s *Multisig) ProposalCancelIx(ctx context.Context, voter solana.PublicKey,
 }
 
 // ProposalCancelTx creates a transaction to cancel a proposal.
-func (s *Multisig) ProposalCancelTx(ctx context.Context, voter solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
+func (s *Multisig) ProposalCancelTx(ctx context.Context, voter solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ProposalCancelIx(ctx, voter, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(voter),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, voter, opts)
 }
 
 // VaultTransactionExecuteIx creates an instruction to execute a vault transaction
 func (s *Multisig) VaultTransactionExecuteIx(ctx context.Context, executor solana.PublicKey, transactionIndex uint64) (solana.Instruction, error) {
+	ix, _, err := s.vaultTransactionExecuteIx(ctx, executor, transactionIndex)
+	return ix, err
+}
+
+// vaultTransactionExecuteIx creates the execute instruction along with the address lookup tables used by the vault transaction
+func (s *Multisig) vaultTransactionExecuteIx(ctx context.Context, executor solana.PublicKey, transactionIndex uint64) (solana.Instruction, []addresslookuptable.KeyedAddressLookupTable, error) {
 	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	proposalPda, err := GetProposalPda(s.multisigPda, transactionIndex)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	vaultTransaction, err := s.VaultTransactionAccount(ctx, transactionPda)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	remainingAccounts, addressLookupTableAccounts, err := s.vaultTransactionMessageRemainingAccounts(ctx, vaultTransaction.Message)
+	if err != nil {
+		return nil, nil, err
 	}
-	additionalAccounts := vaultTransaction.Message.AccountKeys
 
 	ixb := squads_multisig_program.NewVaultTransactionExecuteInstruction(
 		s.multisigPda,
@@ -689,38 +627,20 @@ func (s *Multisig) VaultTransactionExecuteIx(ctx context.Context, executor solan
 		executor,
 	)
 
-	// Append additional accounts with dynamic properties
-	for i, accountKey := range additionalAccounts {
-		isWritable := false
-		// Determine if the account is writable based on the message structure
-		if i < int(vaultTransaction.Message.NumWritableSigners) {
-			isWritable = true // Writable signer
-		} else if (i - int(vaultTransaction.Message.NumSigners)) < int(vaultTransaction.Message.NumWritableNonSigners) {
-			isWritable = true // Writable non-signer
-		}
-		// Additional accounts are typically not signers in multisig execution
-		ixb.AccountMetaSlice = append(ixb.AccountMetaSlice,
-			solana.NewAccountMeta(accountKey, isWritable, false))
-	}
+	// Append the accounts referenced by the stored message
+	ixb.AccountMetaSlice = append(ixb.AccountMetaSlice, remainingAccounts...)
 
-	return ixb.Build(), nil
+	return ixb.Build(), addressLookupTableAccounts, nil
 }
 
-// VaultTransactionExecuteTx creates a transaction to execute a vault transaction
-func (s *Multisig) VaultTransactionExecuteTx(ctx context.Context, executor solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
-	ix, err := s.VaultTransactionExecuteIx(ctx, executor, transactionIndex)
+// VaultTransactionExecuteTx creates a transaction to execute a vault transaction.
+// If the vault transaction uses address lookup tables, the transaction is compiled as v0 against the same tables.
+func (s *Multisig) VaultTransactionExecuteTx(ctx context.Context, executor solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
+	ix, addressLookupTableAccounts, err := s.vaultTransactionExecuteIx(ctx, executor, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(executor),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, executor, opts, solana.TransactionAddressTables(transactionAddressTables(addressLookupTableAccounts)))
 }
 
 // VaultTransactionAccountsCloseIx creates an instruction to close transaction accounts associated with a vault
@@ -747,20 +667,12 @@ func (s *Multisig) VaultTransactionAccountsCloseIx(ctx context.Context, feePayer
 }
 
 // VaultTransactionAccountsCloseTx creates a complete transaction to close transaction accounts associated with a vault
-func (s *Multisig) VaultTransactionAccountsCloseTx(ctx context.Context, feePayer solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
+func (s *Multisig) VaultTransactionAccountsCloseTx(ctx context.Context, feePayer solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.VaultTransactionAccountsCloseIx(ctx, feePayer, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(feePayer),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, feePayer, opts)
 }
 
 func (s *Multisig) ConfigTransactionCreateIx(ctx context.Context, creator solana.PublicKey, transactionIndex uint64, args *squads_multisig_program.ConfigTransactionCreateArgs) (solana.Instruction, error) {
@@ -768,8 +680,11 @@ func (s *Multisig) ConfigTransactionCreateIx(ctx context.Context, creator solana
 	if err != nil {
 		return nil, err
 	}
+	// the generated encoder writes config actions without their variant index
+	argsWithVariants := *args
+	argsWithVariants.Actions = squads_multisig_program.ConfigActionsWithVariants(args.Actions)
 	ix := squads_multisig_program.NewConfigTransactionCreateInstruction(
-		*args,
+		argsWithVariants,
 		s.multisigPda,
 		transactionPda,
 		creator,
@@ -780,22 +695,18 @@ func (s *Multisig) ConfigTransactionCreateIx(ctx context.Context, creator solana
 	return ix, nil
 }
 
-func (s *Multisig) ConfigTransactionCreateTx(ctx context.Context, creator solana.PublicKey, transactionIndex uint64, args *squads_multisig_program.ConfigTransactionCreateArgs) (*solana.Transaction, error) {
+func (s *Multisig) ConfigTransactionCreateTx(ctx context.Context, creator solana.PublicKey, transactionIndex uint64, args *squads_multisig_program.ConfigTransactionCreateArgs, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ConfigTransactionCreateIx(ctx, creator, transactionIndex, args)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(creator),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, creator, opts)
 }
 
+// ConfigTransactionExecuteIx creates an instruction to execute a config transaction.
+// The config transaction is loaded to pass the spending limits its actions add or remove.
+// feePayer pays the rent of added spending limits and of the multisig growing, and receives the rent of removed spending limits.
+// args is deprecated and ignored, the actions are read from the config transaction; pass nil.
 func (s *Multisig) ConfigTransactionExecuteIx(ctx context.Context, member, feePayer solana.PublicKey, transactionIndex uint64, args *squads_multisig_program.ConfigTransactionCreateArgs) (solana.Instruction, error) {
 	proposalPda, err := GetProposalPda(s.multisigPda, transactionIndex)
 	if err != nil {
@@ -805,32 +716,63 @@ func (s *Multisig) ConfigTransactionExecuteIx(ctx context.Context, member, feePa
 	if err != nil {
 		return nil, err
 	}
-	ix := squads_multisig_program.NewConfigTransactionExecuteInstruction(
+	configTransaction, err := s.ConfigTransactionAccount(ctx, transactionPda)
+	if err != nil {
+		return nil, err
+	}
+	remainingAccounts, err := s.configTransactionRemainingAccounts(configTransaction.Actions)
+	if err != nil {
+		return nil, err
+	}
+
+	ixb := squads_multisig_program.NewConfigTransactionExecuteInstruction(
 		s.multisigPda,
 		member,
 		proposalPda,
 		transactionPda,
 		feePayer,
 		solana.SystemProgramID,
-	).Build()
+	)
 
-	return ix, nil
+	// Append the spending limits created or closed by the actions
+	ixb.AccountMetaSlice = append(ixb.AccountMetaSlice, remainingAccounts...)
+
+	return ixb.Build(), nil
 }
 
-func (s *Multisig) ConfigTransactionExecuteTx(ctx context.Context, member, feePayer solana.PublicKey, transactionIndex uint64, args *squads_multisig_program.ConfigTransactionCreateArgs) (*solana.Transaction, error) {
+// ConfigTransactionExecuteTx creates a transaction to execute a config transaction, see ConfigTransactionExecuteIx
+func (s *Multisig) ConfigTransactionExecuteTx(ctx context.Context, member, feePayer solana.PublicKey, transactionIndex uint64, args *squads_multisig_program.ConfigTransactionCreateArgs, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ConfigTransactionExecuteIx(ctx, member, feePayer, transactionIndex, args)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, feePayer, opts)
+}
+
+// configTransactionRemainingAccounts returns the writable spending limit accounts the program looks up
+// for the AddSpendingLimit and RemoveSpendingLimit actions. The other actions only touch the multisig.
+func (s *Multisig) configTransactionRemainingAccounts(actions []squads_multisig_program.ConfigAction) (solana.AccountMetaSlice, error) {
+	var remainingAccounts solana.AccountMetaSlice
+	for _, action := range actions {
+		var spendingLimitPda solana.PublicKey
+		switch a := action.(type) {
+		case *squads_multisig_program.ConfigActionAddSpendingLimit:
+			pda, err := GetSpendingLimitPda(s.multisigPda, a.CreateKey)
+			if err != nil {
+				return nil, err
+			}
+			spendingLimitPda = pda
+		case *squads_multisig_program.ConfigActionRemoveSpendingLimit:
+			spendingLimitPda = a.SpendingLimit
+		default:
+			continue
+		}
+		if remainingAccounts.GetKeys().Contains(spendingLimitPda) {
+			continue
+		}
+		remainingAccounts = append(remainingAccounts, solana.Meta(spendingLimitPda).WRITE())
 	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(feePayer),
-	)
+	return remainingAccounts, nil
 }
 
 // ProposalActivateIx creates an instruction to activate a proposal.
@@ -848,20 +790,12 @@ func (s *Multisig) ProposalActivateIx(ctx context.Context, member solana.PublicK
 }
 
 // ProposalActivateTx creates a transaction to activate a proposal.
-func (s *Multisig) ProposalActivateTx(ctx context.Context, member solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
+func (s *Multisig) ProposalActivateTx(ctx context.Context, member solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ProposalActivateIx(ctx, member, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(member),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, member, opts)
 }
 
 // ProposalCancelV2Ix creates an instruction to cancel a proposal using the V2 instruction.
@@ -881,20 +815,12 @@ func (s *Multisig) ProposalCancelV2Ix(ctx context.Context, member solana.PublicK
 }
 
 // ProposalCancelV2Tx creates a transaction to cancel a proposal using the V2 instruction.
-func (s *Multisig) ProposalCancelV2Tx(ctx context.Context, member solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
+func (s *Multisig) ProposalCancelV2Tx(ctx context.Context, member solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ProposalCancelV2Ix(ctx, member, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(member),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, member, opts)
 }
 
 // ConfigTransactionAccountsCloseIx creates an instruction to close a config transaction and its proposal.
@@ -918,18 +844,10 @@ func (s *Multisig) ConfigTransactionAccountsCloseIx(ctx context.Context, rentCol
 }
 
 // ConfigTransactionAccountsCloseTx creates a transaction to close a config transaction and its proposal.
-func (s *Multisig) ConfigTransactionAccountsCloseTx(ctx context.Context, rentCollector solana.PublicKey, transactionIndex uint64) (*solana.Transaction, error) {
+func (s *Multisig) ConfigTransactionAccountsCloseTx(ctx context.Context, rentCollector solana.PublicKey, transactionIndex uint64, opts ...TxOption) (*solana.Transaction, error) {
 	ix, err := s.ConfigTransactionAccountsCloseIx(ctx, rentCollector, transactionIndex)
 	if err != nil {
 		return nil, err
 	}
-	recent, err := s.client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, err
-	}
-	return solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(rentCollector),
-	)
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, rentCollector, opts)
 }
@@ -0,0 +1,167 @@
+package squads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+)
+
+const (
+	// NonceAccountSize is the size of a nonce account
+	NonceAccountSize = 80
+	// NonceAccountRentExemption is the rent-exempt balance of a nonce account at the default rent,
+	// the same on every cluster
+	NonceAccountRentExemption = (NonceAccountSize + 128) * 3480 * 2
+)
+
+// nonceStateInitialized is the state of a nonce account holding a durable nonce
+const nonceStateInitialized = 1
+
+// ErrNotNonceAccount is returned for accounts that do not hold an initialized durable nonce
+var ErrNotNonceAccount = errors.New("not an initialized nonce account")
+
+// NonceAccount is the state of a durable nonce account
+type NonceAccount struct {
+	Address   solana.PublicKey
+	Authority solana.PublicKey
+	// Nonce is used as the blockhash of the transactions advancing the account
+	Nonce                solana.Hash
+	LamportsPerSignature uint64
+	Lamports             uint64
+}
+
+// DurableNonce is a nonce account with the authority that signs its advancement
+type DurableNonce struct {
+	Account   solana.PublicKey
+	Authority solana.PublicKey
+}
+
+// WithDurableNonce builds the transaction against a durable nonce instead of a recent blockhash:
+// the nonce value is fetched and used as the blockhash, and an AdvanceNonceAccount instruction is
+// prepended, so the transaction stays valid until the nonce is advanced.
+// The authority must sign the transaction.
+// A nonce can only be used by one transaction: builders returning a sequence take the option once
+// per transaction, in the order of the returned transactions, and a nonce account can not be passed twice.
+func WithDurableNonce(nonceAccount, authority solana.PublicKey) TxOption {
+	return func(o *txOptions) {
+		o.nonces = append(o.nonces, DurableNonce{Account: nonceAccount, Authority: authority})
+	}
+}
+
+// GetNonceAccount fetches and decodes a durable nonce account
+func GetNonceAccount(ctx context.Context, client RPCClient, address solana.PublicKey) (*NonceAccount, error) {
+	out, err := client.GetAccountInfo(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNonceAccount(address, out.Value.Owner, out.Value.Lamports, out.Value.Data.GetBinary())
+}
+
+func decodeNonceAccount(address, owner solana.PublicKey, lamports uint64, data []byte) (*NonceAccount, error) {
+	if !owner.Equals(solana.SystemProgramID) || len(data) != NonceAccountSize {
+		return nil, fmt.Errorf("%s: %w", address, ErrNotNonceAccount)
+	}
+	var account system.NonceAccount
+	if err := account.UnmarshalWithDecoder(ag_binary.NewBinDecoder(data)); err != nil {
+		return nil, err
+	}
+	if account.State != nonceStateInitialized {
+		return nil, fmt.Errorf("%s: %w", address, ErrNotNonceAccount)
+	}
+	return &NonceAccount{
+		Address:              address,
+		Authority:            account.AuthorizedPubkey,
+		Nonce:                solana.Hash(account.Nonce),
+		LamportsPerSignature: account.FeeCalculator.LamportsPerSignature,
+		Lamports:             lamports,
+	}, nil
+}
+
+// fetchNonces returns the current value of the durable nonces, checking their authority.
+// A nonce account used twice is rejected: the second transaction would be invalid once the first one advances it.
+func fetchNonces(ctx context.Context, client RPCClient, nonces []DurableNonce) ([]solana.Hash, error) {
+	addresses := make([]solana.PublicKey, len(nonces))
+	seen := make(map[solana.PublicKey]bool, len(nonces))
+	for i, nonce := range nonces {
+		if seen[nonce.Account] {
+			return nil, fmt.Errorf("nonce account %s is used more than once", nonce.Account)
+		}
+		seen[nonce.Account] = true
+		addresses[i] = nonce.Account
+	}
+	out, err := client.GetMultipleAccounts(ctx, addresses...)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]solana.Hash, len(nonces))
+	for i, nonce := range nonces {
+		account := out.Value[i]
+		if account == nil {
+			return nil, fmt.Errorf("%s: %w", nonce.Account, ErrNotNonceAccount)
+		}
+		decoded, err := decodeNonceAccount(nonce.Account, account.Owner, account.Lamports, account.Data.GetBinary())
+		if err != nil {
+			return nil, err
+		}
+		if !decoded.Authority.Equals(nonce.Authority) {
+			return nil, fmt.Errorf("nonce account %s has authority %s, not %s", nonce.Account, decoded.Authority, nonce.Authority)
+		}
+		values[i] = decoded.Nonce
+	}
+	return values, nil
+}
+
+// AdvanceNonceAccountIx creates the instruction advancing a nonce account, the first instruction of a durable nonce transaction
+func AdvanceNonceAccountIx(nonceAccount, authority solana.PublicKey) solana.Instruction {
+	return system.NewAdvanceNonceAccountInstruction(nonceAccount, solana.SysVarRecentBlockHashesPubkey, authority).Build()
+}
+
+// CreateNonceAccountIxs creates the instructions to create and initialize a nonce account funded by payer.
+// The nonce account must sign, it is usually a freshly generated keypair.
+func CreateNonceAccountIxs(payer, nonceAccount, authority solana.PublicKey) []solana.Instruction {
+	return []solana.Instruction{
+		system.NewCreateAccountInstruction(NonceAccountRentExemption, NonceAccountSize, solana.SystemProgramID, payer, nonceAccount).Build(),
+		system.NewInitializeNonceAccountInstruction(authority, nonceAccount, solana.SysVarRecentBlockHashesPubkey, solana.SysVarRentPubkey).Build(),
+	}
+}
+
+// CreateNonceAccountTx creates a transaction to create a nonce account, signed by payer and nonceAccount
+func CreateNonceAccountTx(ctx context.Context, client RPCClient, payer, nonceAccount, authority solana.PublicKey, opts ...TxOption) (*solana.Transaction, error) {
+	return buildTransaction(ctx, client, CreateNonceAccountIxs(payer, nonceAccount, authority), payer, opts)
+}
+
+// VaultNonceAccountProposalTx creates a vault transaction and its proposal that create a nonce account funded by the vault.
+// The nonce account is an ephemeral signer of the vault transaction, its address is returned.
+// The authority signs the transactions using the nonce, so it must be a wallet: a vault cannot sign outside of
+// a vault transaction. The authority can also withdraw the balance of the nonce account.
+// If transactionIndex is 0, the next transaction index of the multisig is used.
+func (s *Multisig) VaultNonceAccountProposalTx(ctx context.Context, creatorAndPayer solana.PublicKey, vaultIndex uint8, transactionIndex uint64, authority solana.PublicKey, autoApprove bool, opts ...TxOption) (*solana.Transaction, solana.PublicKey, error) {
+	if transactionIndex == 0 {
+		multisigInfo, err := s.MultisigAccount(ctx)
+		if err != nil {
+			return nil, solana.PublicKey{}, err
+		}
+		transactionIndex = multisigInfo.TransactionIndex + 1
+	}
+	vaultPda, err := GetVaultPda(s.multisigPda, vaultIndex)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+	nonceAccount, err := GetEphemeralSignerPda(transactionPda, 0)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+	tx, err := s.VaultTransactionAndProposalTx(ctx, creatorAndPayer, vaultIndex, transactionIndex, CreateNonceAccountIxs(vaultPda, nonceAccount, authority), autoApprove, opts...)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+	return tx, nonceAccount, nil
+}
@@ -0,0 +1,99 @@
+package squads
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_DurableNonceBuilders(t *testing.T) {
+	client := squadstest.NewClient()
+	nonceAccount, authority, voter := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	nonce := solana.Hash{7, 7, 7}
+	if err := client.SetNonceAccount(nonceAccount, authority, nonce); err != nil {
+		t.Fatal(err)
+	}
+	info, err := GetNonceAccount(t.Context(), client, nonceAccount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Authority != authority || info.Nonce != nonce || info.LamportsPerSignature != 5000 {
+		t.Fatalf("unexpected nonce account %+v", info)
+	}
+
+	s := New(client, solana.NewWallet().PublicKey())
+	tx, err := s.ProposalApproveTx(t.Context(), voter, 1, WithDurableNonce(nonceAccount, authority), WithComputeUnitPrice(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Message.RecentBlockhash != nonce || !usesDurableNonce(tx) || len(tx.Message.Instructions) != 3 {
+		t.Fatal("transaction does not use the durable nonce")
+	}
+	env, err := ExportEnvelope(tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Nonce == nil || env.Nonce.Account != nonceAccount || env.Nonce.Authority != authority {
+		t.Fatalf("unexpected envelope nonce %+v", env.Nonce)
+	}
+
+	if _, err := s.ProposalApproveTx(t.Context(), voter, 1, WithDurableNonce(nonceAccount, voter)); err == nil {
+		t.Fatal("expected an error for the wrong nonce authority")
+	}
+	if _, err := s.ProposalApproveTx(t.Context(), voter, 1, WithDurableNonce(voter, authority)); !errors.Is(err, ErrNotNonceAccount) {
+		t.Fatalf("expected ErrNotNonceAccount, got %v", err)
+	}
+
+	// a nonce is used by a single transaction of a sequence
+	builder, err := newTxBuilder(t.Context(), client, []TxOption{WithDurableNonce(nonceAccount, authority)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix, err := s.ProposalApproveIx(t.Context(), voter, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.build(t.Context(), []solana.Instruction{ix}, voter); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.build(t.Context(), []solana.Instruction{ix}, voter); err == nil {
+		t.Fatal("expected an error when the nonces are exhausted")
+	}
+
+	if _, err := newTxBuilder(t.Context(), client, []TxOption{WithDurableNonce(nonceAccount, authority), WithDurableNonce(nonceAccount, authority)}); err == nil {
+		t.Fatal("expected an error for a nonce account used twice")
+	}
+}
+
+func Test_VaultNonceAccountProposal(t *testing.T) {
+	multisigPda, creator, authority := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	s := New(squadstest.NewClient(), multisigPda)
+	tx, nonceAccount, err := s.VaultNonceAccountProposalTx(t.Context(), creator, 0, 5, authority, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionPda, err := GetTransactionPda(multisigPda, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := GetEphemeralSignerPda(transactionPda, 0); nonceAccount != expected {
+		t.Fatalf("nonce account %s is not the first ephemeral signer %s", nonceAccount, expected)
+	}
+
+	compiled := tx.Message.Instructions[0]
+	accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := squads_multisig_program.DecodeInstruction(accounts, compiled.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	create, ok := decoded.Impl.(*squads_multisig_program.VaultTransactionCreate)
+	if !ok || create.Args.EphemeralSigners != 1 {
+		t.Fatalf("unexpected vault transaction %+v", decoded.Impl)
+	}
+}
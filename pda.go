@@ -6,15 +6,16 @@ import (
 )
 
 var (
-	SEED_PREFIX            = []byte("multisig")
-	SEED_PROGRAM_CONFIG    = []byte("program_config")
-	SEED_MULTISIG          = []byte("multisig")
-	SEED_VAULT             = []byte("vault")
-	SEED_TRANSACTION       = []byte("transaction")
-	SEED_PROPOSAL          = []byte("proposal")
-	SEED_BATCH_TRANSACTION = []byte("batch_transaction")
-	SEED_EPHEMERAL_SIGNER  = []byte("ephemeral_signer")
-	SEED_SPENDING_LIMIT    = []byte("spending_limit")
+	SEED_PREFIX             = []byte("multisig")
+	SEED_PROGRAM_CONFIG     = []byte("program_config")
+	SEED_MULTISIG           = []byte("multisig")
+	SEED_VAULT              = []byte("vault")
+	SEED_TRANSACTION        = []byte("transaction")
+	SEED_PROPOSAL           = []byte("proposal")
+	SEED_BATCH_TRANSACTION  = []byte("batch_transaction")
+	SEED_EPHEMERAL_SIGNER   = []byte("ephemeral_signer")
+	SEED_SPENDING_LIMIT     = []byte("spending_limit")
+	SEED_TRANSACTION_BUFFER = []byte("transaction_buffer")
 )
 
 func GetProgramConfigPda() (solana.PublicKey, error) {
@@ -144,3 +145,37 @@ func GetSpendingLimitPda(multisigPda solana.PublicKey, createKey solana.PublicKe
 	}
 	return pk, nil
 }
+
+func GetTransactionBufferPda(multisigPda solana.PublicKey, creator solana.PublicKey, bufferIndex uint8) (solana.PublicKey, error) {
+	pk, _, err := solana.FindProgramAddress(
+		[][]byte{
+			SEED_PREFIX,
+			multisigPda.Bytes(),
+			SEED_TRANSACTION_BUFFER,
+			creator.Bytes(),
+			[]byte{bufferIndex},
+		},
+		squads_multisig_program.ProgramID,
+	)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return pk, nil
+}
+
+// GetAssociatedTokenAddress derives the associated token account of owner for a mint of
+// tokenProgram, solana.TokenProgramID or solana.Token2022ProgramID
+func GetAssociatedTokenAddress(owner solana.PublicKey, mint solana.PublicKey, tokenProgram solana.PublicKey) (solana.PublicKey, error) {
+	pk, _, err := solana.FindProgramAddress(
+		[][]byte{
+			owner.Bytes(),
+			tokenProgram.Bytes(),
+			mint.Bytes(),
+		},
+		solana.SPLAssociatedTokenAccountProgramID,
+	)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return pk, nil
+}
@@ -0,0 +1,185 @@
+package squads
+
+import (
+	"context"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// ProgramConfig administers the global config of the multisig program.
+// Use squads_multisig_program.SetProgramID to target a custom deployment.
+type ProgramConfig struct {
+	client RPCClient
+}
+
+// NewProgramConfig creates a new ProgramConfig instance
+func NewProgramConfig(client RPCClient) *ProgramConfig {
+	return &ProgramConfig{
+		client: client,
+	}
+}
+
+// ProgramConfigAccount retrieves the program config account information
+func (p *ProgramConfig) ProgramConfigAccount(ctx context.Context) (*squads_multisig_program.ProgramConfig, error) {
+	programConfigPda, err := GetProgramConfigPda()
+	if err != nil {
+		return nil, err
+	}
+	out, err := p.client.GetAccountInfo(ctx, programConfigPda)
+	if err != nil {
+		return nil, err
+	}
+	data := out.Value.Data.GetBinary()
+
+	account := &squads_multisig_program.ProgramConfig{}
+	decoder := ag_binary.NewBorshDecoder(data)
+	if err := account.UnmarshalWithDecoder(decoder); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Authority returns the authority allowed to update the program config
+func (p *ProgramConfig) Authority(ctx context.Context) (solana.PublicKey, error) {
+	account, err := p.ProgramConfigAccount(ctx)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return account.Authority, nil
+}
+
+// MultisigCreationFee returns the lamports charged for creating a new multisig
+func (p *ProgramConfig) MultisigCreationFee(ctx context.Context) (uint64, error) {
+	account, err := p.ProgramConfigAccount(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return account.MultisigCreationFee, nil
+}
+
+// Treasury returns the account the multisig creation fee is sent to
+func (p *ProgramConfig) Treasury(ctx context.Context) (solana.PublicKey, error) {
+	account, err := p.ProgramConfigAccount(ctx)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	return account.Treasury, nil
+}
+
+// ProgramConfigInitIx creates an instruction to initialize the program config.
+// The initializer is hard-coded in the program and pays for the account rent.
+func (p *ProgramConfig) ProgramConfigInitIx(ctx context.Context, initializer, authority solana.PublicKey, multisigCreationFee uint64, treasury solana.PublicKey) (solana.Instruction, error) {
+	programConfigPda, err := GetProgramConfigPda()
+	if err != nil {
+		return nil, err
+	}
+	args := squads_multisig_program.ProgramConfigInitArgs{
+		Authority:           authority,
+		MultisigCreationFee: multisigCreationFee,
+		Treasury:            treasury,
+	}
+
+	ix := squads_multisig_program.NewProgramConfigInitInstruction(
+		args,
+		programConfigPda,
+		initializer,
+		solana.SystemProgramID,
+	).Build()
+
+	return ix, nil
+}
+
+// ProgramConfigInitTx creates a transaction to initialize the program config
+func (p *ProgramConfig) ProgramConfigInitTx(ctx context.Context, initializer, authority solana.PublicKey, multisigCreationFee uint64, treasury solana.PublicKey, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := p.ProgramConfigInitIx(ctx, initializer, authority, multisigCreationFee, treasury)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, p.client, []solana.Instruction{ix}, initializer, opts)
+}
+
+// ProgramConfigSetAuthorityIx creates an instruction to set the authority of the program config
+func (p *ProgramConfig) ProgramConfigSetAuthorityIx(ctx context.Context, authority, newAuthority solana.PublicKey) (solana.Instruction, error) {
+	programConfigPda, err := GetProgramConfigPda()
+	if err != nil {
+		return nil, err
+	}
+	args := squads_multisig_program.ProgramConfigSetAuthorityArgs{
+		NewAuthority: newAuthority,
+	}
+
+	ix := squads_multisig_program.NewProgramConfigSetAuthorityInstruction(
+		args,
+		programConfigPda,
+		authority,
+	).Build()
+
+	return ix, nil
+}
+
+// ProgramConfigSetAuthorityTx creates a transaction to set the authority of the program config
+func (p *ProgramConfig) ProgramConfigSetAuthorityTx(ctx context.Context, authority, newAuthority solana.PublicKey, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := p.ProgramConfigSetAuthorityIx(ctx, authority, newAuthority)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, p.client, []solana.Instruction{ix}, authority, opts)
+}
+
+// ProgramConfigSetMultisigCreationFeeIx creates an instruction to set the multisig creation fee of the program config
+func (p *ProgramConfig) ProgramConfigSetMultisigCreationFeeIx(ctx context.Context, authority solana.PublicKey, newMultisigCreationFee uint64) (solana.Instruction, error) {
+	programConfigPda, err := GetProgramConfigPda()
+	if err != nil {
+		return nil, err
+	}
+	args := squads_multisig_program.ProgramConfigSetMultisigCreationFeeArgs{
+		NewMultisigCreationFee: newMultisigCreationFee,
+	}
+
+	ix := squads_multisig_program.NewProgramConfigSetMultisigCreationFeeInstruction(
+		args,
+		programConfigPda,
+		authority,
+	).Build()
+
+	return ix, nil
+}
+
+// ProgramConfigSetMultisigCreationFeeTx creates a transaction to set the multisig creation fee of the program config
+func (p *ProgramConfig) ProgramConfigSetMultisigCreationFeeTx(ctx context.Context, authority solana.PublicKey, newMultisigCreationFee uint64, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := p.ProgramConfigSetMultisigCreationFeeIx(ctx, authority, newMultisigCreationFee)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, p.client, []solana.Instruction{ix}, authority, opts)
+}
+
+// ProgramConfigSetTreasuryIx creates an instruction to set the treasury of the program config
+func (p *ProgramConfig) ProgramConfigSetTreasuryIx(ctx context.Context, authority, newTreasury solana.PublicKey) (solana.Instruction, error) {
+	programConfigPda, err := GetProgramConfigPda()
+	if err != nil {
+		return nil, err
+	}
+	args := squads_multisig_program.ProgramConfigSetTreasuryArgs{
+		NewTreasury: newTreasury,
+	}
+
+	ix := squads_multisig_program.NewProgramConfigSetTreasuryInstruction(
+		args,
+		programConfigPda,
+		authority,
+	).Build()
+
+	return ix, nil
+}
+
+// ProgramConfigSetTreasuryTx creates a transaction to set the treasury of the program config
+func (p *ProgramConfig) ProgramConfigSetTreasuryTx(ctx context.Context, authority, newTreasury solana.PublicKey, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := p.ProgramConfigSetTreasuryIx(ctx, authority, newTreasury)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, p.client, []solana.Instruction{ix}, authority, opts)
+}
@@ -0,0 +1,97 @@
+package squads
+
+import (
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_ProgramConfigAccessors(t *testing.T) {
+	client := squadstest.NewClient()
+	p := NewProgramConfig(client)
+	programConfigPda, err := GetProgramConfigPda()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authority, treasury := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	err = client.SetBorshAccount(programConfigPda, squads_multisig_program.ProgramConfig{Authority: authority, MultisigCreationFee: 1_000_000, Treasury: treasury})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := p.Authority(t.Context()); err != nil || got != authority {
+		t.Fatalf("unexpected authority %s: %v", got, err)
+	}
+	if got, err := p.MultisigCreationFee(t.Context()); err != nil || got != 1_000_000 {
+		t.Fatalf("unexpected multisig creation fee %d: %v", got, err)
+	}
+	if got, err := p.Treasury(t.Context()); err != nil || got != treasury {
+		t.Fatalf("unexpected treasury %s: %v", got, err)
+	}
+
+	client.DeleteAccount(programConfigPda)
+	if _, err := p.ProgramConfigAccount(t.Context()); err == nil {
+		t.Fatal("expected an error for a missing program config")
+	}
+}
+
+func Test_ProgramConfigBuilders(t *testing.T) {
+	client := squadstest.NewClient()
+	p := NewProgramConfig(client)
+	programConfigPda, err := GetProgramConfigPda()
+	if err != nil {
+		t.Fatal(err)
+	}
+	initializer, authority, newAuthority, treasury := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	tx, err := p.ProgramConfigInitTx(t.Context(), initializer, authority, 5000, treasury)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tx.Message.AccountKeys[0].Equals(initializer) {
+		t.Fatal("the initializer must pay the transaction")
+	}
+	init, ok := squadsInstructionsOf(t, tx)[0].Impl.(*squads_multisig_program.ProgramConfigInit)
+	if !ok || init.Args.Authority != authority || init.Args.MultisigCreationFee != 5000 || init.Args.Treasury != treasury {
+		t.Fatalf("unexpected ProgramConfigInit %+v", init)
+	}
+	assertAccounts(t, "ProgramConfigInit", init.AccountMetaSlice, programConfigPda, initializer, solana.SystemProgramID)
+	if !init.AccountMetaSlice[0].IsWritable || !init.AccountMetaSlice[1].IsSigner {
+		t.Error("the program config must be writable and the initializer a signer")
+	}
+
+	tx, err = p.ProgramConfigSetAuthorityTx(t.Context(), authority, newAuthority)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setAuthority, ok := squadsInstructionsOf(t, tx)[0].Impl.(*squads_multisig_program.ProgramConfigSetAuthority)
+	if !ok || setAuthority.Args.NewAuthority != newAuthority {
+		t.Fatalf("unexpected ProgramConfigSetAuthority %+v", setAuthority)
+	}
+	assertAccounts(t, "ProgramConfigSetAuthority", setAuthority.AccountMetaSlice, programConfigPda, authority)
+
+	tx, err = p.ProgramConfigSetMultisigCreationFeeTx(t.Context(), authority, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setFee, ok := squadsInstructionsOf(t, tx)[0].Impl.(*squads_multisig_program.ProgramConfigSetMultisigCreationFee)
+	if !ok || setFee.Args.NewMultisigCreationFee != 42 {
+		t.Fatalf("unexpected ProgramConfigSetMultisigCreationFee %+v", setFee)
+	}
+	assertAccounts(t, "ProgramConfigSetMultisigCreationFee", setFee.AccountMetaSlice, programConfigPda, authority)
+
+	tx, err = p.ProgramConfigSetTreasuryTx(t.Context(), authority, treasury)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setTreasury, ok := squadsInstructionsOf(t, tx)[0].Impl.(*squads_multisig_program.ProgramConfigSetTreasury)
+	if !ok || setTreasury.Args.NewTreasury != treasury {
+		t.Fatalf("unexpected ProgramConfigSetTreasury %+v", setTreasury)
+	}
+	assertAccounts(t, "ProgramConfigSetTreasury", setTreasury.AccountMetaSlice, programConfigPda, authority)
+	if !setTreasury.AccountMetaSlice[1].IsSigner || !tx.Message.AccountKeys[0].Equals(authority) {
+		t.Error("the authority must sign and pay")
+	}
+}
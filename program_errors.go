@@ -0,0 +1,145 @@
+// Code generated by go run ./internal/generrors. DO NOT EDIT.
+
+package squads
+
+// Custom errors of the multisig program, use errors.Is to match them.
+var (
+	// ErrDuplicateMember: Found multiple members with the same pubkey
+	ErrDuplicateMember = &ProgramError{Code: 6000, Name: "DuplicateMember", Msg: "Found multiple members with the same pubkey"}
+	// ErrEmptyMembers: Members array is empty
+	ErrEmptyMembers = &ProgramError{Code: 6001, Name: "EmptyMembers", Msg: "Members array is empty"}
+	// ErrTooManyMembers: Too many members, can be up to 65535
+	ErrTooManyMembers = &ProgramError{Code: 6002, Name: "TooManyMembers", Msg: "Too many members, can be up to 65535"}
+	// ErrInvalidThreshold: Invalid threshold, must be between 1 and number of members with Vote permission
+	ErrInvalidThreshold = &ProgramError{Code: 6003, Name: "InvalidThreshold", Msg: "Invalid threshold, must be between 1 and number of members with Vote permission"}
+	// ErrUnauthorized: Attempted to perform an unauthorized action
+	ErrUnauthorized = &ProgramError{Code: 6004, Name: "Unauthorized", Msg: "Attempted to perform an unauthorized action"}
+	// ErrNotAMember: Provided pubkey is not a member of multisig
+	ErrNotAMember = &ProgramError{Code: 6005, Name: "NotAMember", Msg: "Provided pubkey is not a member of multisig"}
+	// ErrInvalidTransactionMessage: TransactionMessage is malformed.
+	ErrInvalidTransactionMessage = &ProgramError{Code: 6006, Name: "InvalidTransactionMessage", Msg: "TransactionMessage is malformed."}
+	// ErrStaleProposal: Proposal is stale
+	ErrStaleProposal = &ProgramError{Code: 6007, Name: "StaleProposal", Msg: "Proposal is stale"}
+	// ErrInvalidProposalStatus: Invalid proposal status
+	ErrInvalidProposalStatus = &ProgramError{Code: 6008, Name: "InvalidProposalStatus", Msg: "Invalid proposal status"}
+	// ErrInvalidTransactionIndex: Invalid transaction index
+	ErrInvalidTransactionIndex = &ProgramError{Code: 6009, Name: "InvalidTransactionIndex", Msg: "Invalid transaction index"}
+	// ErrAlreadyApproved: Member already approved the transaction
+	ErrAlreadyApproved = &ProgramError{Code: 6010, Name: "AlreadyApproved", Msg: "Member already approved the transaction"}
+	// ErrAlreadyRejected: Member already rejected the transaction
+	ErrAlreadyRejected = &ProgramError{Code: 6011, Name: "AlreadyRejected", Msg: "Member already rejected the transaction"}
+	// ErrAlreadyCancelled: Member already cancelled the transaction
+	ErrAlreadyCancelled = &ProgramError{Code: 6012, Name: "AlreadyCancelled", Msg: "Member already cancelled the transaction"}
+	// ErrInvalidNumberOfAccounts: Wrong number of accounts provided
+	ErrInvalidNumberOfAccounts = &ProgramError{Code: 6013, Name: "InvalidNumberOfAccounts", Msg: "Wrong number of accounts provided"}
+	// ErrInvalidAccount: Invalid account provided
+	ErrInvalidAccount = &ProgramError{Code: 6014, Name: "InvalidAccount", Msg: "Invalid account provided"}
+	// ErrRemoveLastMember: Cannot remove last member
+	ErrRemoveLastMember = &ProgramError{Code: 6015, Name: "RemoveLastMember", Msg: "Cannot remove last member"}
+	// ErrNoVoters: Members don't include any voters
+	ErrNoVoters = &ProgramError{Code: 6016, Name: "NoVoters", Msg: "Members don't include any voters"}
+	// ErrNoProposers: Members don't include any proposers
+	ErrNoProposers = &ProgramError{Code: 6017, Name: "NoProposers", Msg: "Members don't include any proposers"}
+	// ErrNoExecutors: Members don't include any executors
+	ErrNoExecutors = &ProgramError{Code: 6018, Name: "NoExecutors", Msg: "Members don't include any executors"}
+	// ErrInvalidStaleTransactionIndex: `stale_transaction_index` must be <= `transaction_index`
+	ErrInvalidStaleTransactionIndex = &ProgramError{Code: 6019, Name: "InvalidStaleTransactionIndex", Msg: "`stale_transaction_index` must be <= `transaction_index`"}
+	// ErrNotSupportedForControlled: Instruction not supported for controlled multisig
+	ErrNotSupportedForControlled = &ProgramError{Code: 6020, Name: "NotSupportedForControlled", Msg: "Instruction not supported for controlled multisig"}
+	// ErrTimeLockNotReleased: Proposal time lock has not been released
+	ErrTimeLockNotReleased = &ProgramError{Code: 6021, Name: "TimeLockNotReleased", Msg: "Proposal time lock has not been released"}
+	// ErrNoActions: Config transaction must have at least one action
+	ErrNoActions = &ProgramError{Code: 6022, Name: "NoActions", Msg: "Config transaction must have at least one action"}
+	// ErrMissingAccount: Missing account
+	ErrMissingAccount = &ProgramError{Code: 6023, Name: "MissingAccount", Msg: "Missing account"}
+	// ErrInvalidMint: Invalid mint
+	ErrInvalidMint = &ProgramError{Code: 6024, Name: "InvalidMint", Msg: "Invalid mint"}
+	// ErrInvalidDestination: Invalid destination
+	ErrInvalidDestination = &ProgramError{Code: 6025, Name: "InvalidDestination", Msg: "Invalid destination"}
+	// ErrSpendingLimitExceeded: Spending limit exceeded
+	ErrSpendingLimitExceeded = &ProgramError{Code: 6026, Name: "SpendingLimitExceeded", Msg: "Spending limit exceeded"}
+	// ErrDecimalsMismatch: Decimals don't match the mint
+	ErrDecimalsMismatch = &ProgramError{Code: 6027, Name: "DecimalsMismatch", Msg: "Decimals don't match the mint"}
+	// ErrUnknownPermission: Member has unknown permission
+	ErrUnknownPermission = &ProgramError{Code: 6028, Name: "UnknownPermission", Msg: "Member has unknown permission"}
+	// ErrProtectedAccount: Account is protected, it cannot be passed into a CPI as writable
+	ErrProtectedAccount = &ProgramError{Code: 6029, Name: "ProtectedAccount", Msg: "Account is protected, it cannot be passed into a CPI as writable"}
+	// ErrTimeLockExceedsMaxAllowed: Time lock exceeds the maximum allowed (90 days)
+	ErrTimeLockExceedsMaxAllowed = &ProgramError{Code: 6030, Name: "TimeLockExceedsMaxAllowed", Msg: "Time lock exceeds the maximum allowed (90 days)"}
+	// ErrIllegalAccountOwner: Account is not owned by Multisig program
+	ErrIllegalAccountOwner = &ProgramError{Code: 6031, Name: "IllegalAccountOwner", Msg: "Account is not owned by Multisig program"}
+	// ErrRentReclamationDisabled: Rent reclamation is disabled for this multisig
+	ErrRentReclamationDisabled = &ProgramError{Code: 6032, Name: "RentReclamationDisabled", Msg: "Rent reclamation is disabled for this multisig"}
+	// ErrInvalidRentCollector: Invalid rent collector address
+	ErrInvalidRentCollector = &ProgramError{Code: 6033, Name: "InvalidRentCollector", Msg: "Invalid rent collector address"}
+	// ErrProposalForAnotherMultisig: Proposal is for another multisig
+	ErrProposalForAnotherMultisig = &ProgramError{Code: 6034, Name: "ProposalForAnotherMultisig", Msg: "Proposal is for another multisig"}
+	// ErrTransactionForAnotherMultisig: Transaction is for another multisig
+	ErrTransactionForAnotherMultisig = &ProgramError{Code: 6035, Name: "TransactionForAnotherMultisig", Msg: "Transaction is for another multisig"}
+	// ErrTransactionNotMatchingProposal: Transaction doesn't match proposal
+	ErrTransactionNotMatchingProposal = &ProgramError{Code: 6036, Name: "TransactionNotMatchingProposal", Msg: "Transaction doesn't match proposal"}
+	// ErrTransactionNotLastInBatch: Transaction is not last in batch
+	ErrTransactionNotLastInBatch = &ProgramError{Code: 6037, Name: "TransactionNotLastInBatch", Msg: "Transaction is not last in batch"}
+	// ErrBatchNotEmpty: Batch is not empty
+	ErrBatchNotEmpty = &ProgramError{Code: 6038, Name: "BatchNotEmpty", Msg: "Batch is not empty"}
+	// ErrSpendingLimitInvalidAmount: Invalid SpendingLimit amount
+	ErrSpendingLimitInvalidAmount = &ProgramError{Code: 6039, Name: "SpendingLimitInvalidAmount", Msg: "Invalid SpendingLimit amount"}
+	// ErrInvalidInstructionArgs: Invalid Instruction Arguments
+	ErrInvalidInstructionArgs = &ProgramError{Code: 6040, Name: "InvalidInstructionArgs", Msg: "Invalid Instruction Arguments"}
+	// ErrFinalBufferHashMismatch: Final message buffer hash doesnt match the expected hash
+	ErrFinalBufferHashMismatch = &ProgramError{Code: 6041, Name: "FinalBufferHashMismatch", Msg: "Final message buffer hash doesnt match the expected hash"}
+	// ErrFinalBufferSizeExceeded: Final buffer size cannot exceed 4000 bytes
+	ErrFinalBufferSizeExceeded = &ProgramError{Code: 6042, Name: "FinalBufferSizeExceeded", Msg: "Final buffer size cannot exceed 4000 bytes"}
+	// ErrFinalBufferSizeMismatch: Final buffer size mismatch
+	ErrFinalBufferSizeMismatch = &ProgramError{Code: 6043, Name: "FinalBufferSizeMismatch", Msg: "Final buffer size mismatch"}
+	// ErrMultisigCreateDeprecated: multisig_create has been deprecated. Use multisig_create_v2 instead.
+	ErrMultisigCreateDeprecated = &ProgramError{Code: 6044, Name: "MultisigCreateDeprecated", Msg: "multisig_create has been deprecated. Use multisig_create_v2 instead."}
+)
+
+var programErrors = map[uint32]*ProgramError{
+	6000: ErrDuplicateMember,
+	6001: ErrEmptyMembers,
+	6002: ErrTooManyMembers,
+	6003: ErrInvalidThreshold,
+	6004: ErrUnauthorized,
+	6005: ErrNotAMember,
+	6006: ErrInvalidTransactionMessage,
+	6007: ErrStaleProposal,
+	6008: ErrInvalidProposalStatus,
+	6009: ErrInvalidTransactionIndex,
+	6010: ErrAlreadyApproved,
+	6011: ErrAlreadyRejected,
+	6012: ErrAlreadyCancelled,
+	6013: ErrInvalidNumberOfAccounts,
+	6014: ErrInvalidAccount,
+	6015: ErrRemoveLastMember,
+	6016: ErrNoVoters,
+	6017: ErrNoProposers,
+	6018: ErrNoExecutors,
+	6019: ErrInvalidStaleTransactionIndex,
+	6020: ErrNotSupportedForControlled,
+	6021: ErrTimeLockNotReleased,
+	6022: ErrNoActions,
+	6023: ErrMissingAccount,
+	6024: ErrInvalidMint,
+	6025: ErrInvalidDestination,
+	6026: ErrSpendingLimitExceeded,
+	6027: ErrDecimalsMismatch,
+	6028: ErrUnknownPermission,
+	6029: ErrProtectedAccount,
+	6030: ErrTimeLockExceedsMaxAllowed,
+	6031: ErrIllegalAccountOwner,
+	6032: ErrRentReclamationDisabled,
+	6033: ErrInvalidRentCollector,
+	6034: ErrProposalForAnotherMultisig,
+	6035: ErrTransactionForAnotherMultisig,
+	6036: ErrTransactionNotMatchingProposal,
+	6037: ErrTransactionNotLastInBatch,
+	6038: ErrBatchNotEmpty,
+	6039: ErrSpendingLimitInvalidAmount,
+	6040: ErrInvalidInstructionArgs,
+	6041: ErrFinalBufferHashMismatch,
+	6042: ErrFinalBufferSizeExceeded,
+	6043: ErrFinalBufferSizeMismatch,
+	6044: ErrMultisigCreateDeprecated,
+}
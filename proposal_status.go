@@ -0,0 +1,125 @@
+package squads
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+)
+
+// ProposalState is a detailed view of a proposal, evaluated against the multisig it belongs to.
+// The program only keeps the timestamp of the current status, so at most one of the status timestamps is set.
+type ProposalState struct {
+	TransactionIndex uint64         `json:"transactionIndex"`
+	Status           ProposalStatus `json:"status"`
+
+	DraftedAt   *time.Time `json:"draftedAt,omitempty"`
+	ActivatedAt *time.Time `json:"activatedAt,omitempty"`
+	RejectedAt  *time.Time `json:"rejectedAt,omitempty"`
+	ApprovedAt  *time.Time `json:"approvedAt,omitempty"`
+	ExecutedAt  *time.Time `json:"executedAt,omitempty"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+
+	Threshold uint16             `json:"threshold"`
+	Approved  []solana.PublicKey `json:"approved"`
+	Rejected  []solana.PublicKey `json:"rejected"`
+	Cancelled []solana.PublicKey `json:"cancelled"`
+	// ApprovalsNeeded is the number of approvals still needed to reach the threshold
+	ApprovalsNeeded int `json:"approvalsNeeded"`
+	// RejectionsNeeded is the number of rejections still needed to reject the proposal
+	RejectionsNeeded int `json:"rejectionsNeeded"`
+	// PendingVoters are the members with the Vote permission who have neither approved nor rejected
+	PendingVoters []solana.PublicKey `json:"pendingVoters"`
+	// Stale is set when a config change made the proposal stale, it can no longer be approved or rejected
+	Stale bool `json:"stale"`
+	// CannotPass is set when the proposal can no longer be approved: too many members rejected it,
+	// it was rejected or cancelled, or it is stale before being approved
+	CannotPass bool `json:"cannotPass"`
+}
+
+// Timestamp returns when the proposal entered its current status. Executing proposals carry no timestamp.
+func (ps *ProposalState) Timestamp() (time.Time, bool) {
+	for _, t := range []*time.Time{ps.DraftedAt, ps.ActivatedAt, ps.RejectedAt, ps.ApprovedAt, ps.ExecutedAt, ps.CancelledAt} {
+		if t != nil {
+			return *t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NewProposalState evaluates a proposal against the current members and threshold of its multisig
+func NewProposalState(multisig *squads_multisig_program.Multisig, proposal *squads_multisig_program.Proposal) *ProposalState {
+	ps := &ProposalState{
+		TransactionIndex: proposal.TransactionIndex,
+		Status:           GetProposalStatus(proposal.Status),
+		Threshold:        multisig.Threshold,
+		Approved:         proposal.Approved,
+		Rejected:         proposal.Rejected,
+		Cancelled:        proposal.Cancelled,
+		PendingVoters:    []solana.PublicKey{},
+	}
+
+	unix := func(timestamp int64) *time.Time {
+		t := time.Unix(timestamp, 0).UTC()
+		return &t
+	}
+	switch status := proposal.Status.(type) {
+	case *squads_multisig_program.ProposalStatusDraft:
+		ps.DraftedAt = unix(status.Timestamp)
+	case *squads_multisig_program.ProposalStatusActive:
+		ps.ActivatedAt = unix(status.Timestamp)
+	case *squads_multisig_program.ProposalStatusRejected:
+		ps.RejectedAt = unix(status.Timestamp)
+	case *squads_multisig_program.ProposalStatusApproved:
+		ps.ApprovedAt = unix(status.Timestamp)
+	case *squads_multisig_program.ProposalStatusExecuted:
+		ps.ExecutedAt = unix(status.Timestamp)
+	case *squads_multisig_program.ProposalStatusCancelled:
+		ps.CancelledAt = unix(status.Timestamp)
+	}
+
+	voters := 0
+	for _, member := range multisig.Members {
+		if !Permission(member.Permissions.Mask).Has(Vote) {
+			continue
+		}
+		voters++
+		if !slices.Contains(proposal.Approved, member.Key) && !slices.Contains(proposal.Rejected, member.Key) {
+			ps.PendingVoters = append(ps.PendingVoters, member.Key)
+		}
+	}
+
+	// The program rejects a proposal once the rejections reach the cutoff, the point from which the
+	// remaining voters can no longer reach the threshold
+	rejectionCutoff := voters - int(multisig.Threshold) + 1
+	ps.ApprovalsNeeded = max(int(multisig.Threshold)-len(proposal.Approved), 0)
+	ps.RejectionsNeeded = max(rejectionCutoff-len(proposal.Rejected), 0)
+
+	ps.Stale = proposal.TransactionIndex <= multisig.StaleTransactionIndex
+	switch ps.Status {
+	case ProposalStatusDraft, ProposalStatusActive:
+		ps.CannotPass = ps.Stale || len(proposal.Approved)+len(ps.PendingVoters) < int(multisig.Threshold)
+	case ProposalStatusRejected, ProposalStatusCancelled:
+		ps.CannotPass = true
+	}
+	return ps
+}
+
+// ProposalState fetches a proposal and its multisig and evaluates the proposal status
+func (s *Multisig) ProposalState(ctx context.Context, transactionIndex uint64) (*ProposalState, error) {
+	multisig, err := s.MultisigAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proposalPda, err := GetProposalPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := s.ProposalAccount(ctx, proposalPda)
+	if err != nil {
+		return nil, err
+	}
+	return NewProposalState(multisig, proposal), nil
+}
@@ -0,0 +1,143 @@
+package squads
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func testMultisig(threshold uint16, members ...squads_multisig_program.Member) *squads_multisig_program.Multisig {
+	return &squads_multisig_program.Multisig{
+		Threshold: threshold,
+		Members:   members,
+	}
+}
+
+func testMember(permissions Permission) squads_multisig_program.Member {
+	return squads_multisig_program.Member{
+		Key:         solana.NewWallet().PublicKey(),
+		Permissions: squads_multisig_program.Permissions{Mask: uint8(permissions)},
+	}
+}
+
+func Test_ProposalStateVotes(t *testing.T) {
+	all := Initiate | Vote | Execute
+	a, b, c, d := testMember(all), testMember(Vote), testMember(Vote), testMember(Initiate|Execute)
+	multisig := testMultisig(2, a, b, c, d)
+
+	proposal := &squads_multisig_program.Proposal{
+		TransactionIndex: 4,
+		Status:           &squads_multisig_program.ProposalStatusActive{Timestamp: 1700000000},
+		Approved:         []solana.PublicKey{a.Key},
+	}
+	ps := NewProposalState(multisig, proposal)
+	if ps.Status != ProposalStatusActive || ps.ActivatedAt == nil || !ps.ActivatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("unexpected status %v activated at %v", ps.Status, ps.ActivatedAt)
+	}
+	if ts, ok := ps.Timestamp(); !ok || ts.Unix() != 1700000000 {
+		t.Fatalf("unexpected timestamp %v", ts)
+	}
+	if ps.ApprovalsNeeded != 1 || ps.RejectionsNeeded != 2 {
+		t.Fatalf("approvals needed %d, rejections needed %d", ps.ApprovalsNeeded, ps.RejectionsNeeded)
+	}
+	// d has no Vote permission
+	if !slices.Equal(ps.PendingVoters, []solana.PublicKey{b.Key, c.Key}) {
+		t.Fatalf("unexpected pending voters %v", ps.PendingVoters)
+	}
+	if ps.CannotPass || ps.Stale {
+		t.Fatal("proposal can still pass")
+	}
+
+	// once b rejects, only c is left and one more approval is still possible
+	proposal.Rejected = []solana.PublicKey{b.Key}
+	if ps := NewProposalState(multisig, proposal); ps.CannotPass || ps.RejectionsNeeded != 1 {
+		t.Fatalf("unexpected state %+v", ps)
+	}
+
+	// with a and c rejecting only b could approve, which is below the threshold
+	proposal.Approved = nil
+	proposal.Rejected = []solana.PublicKey{a.Key, c.Key}
+	if ps := NewProposalState(multisig, proposal); !ps.CannotPass || ps.RejectionsNeeded != 0 || ps.ApprovalsNeeded != 2 {
+		t.Fatalf("unexpected state %+v", ps)
+	}
+}
+
+func Test_ProposalStateStaleAndFinal(t *testing.T) {
+	a := testMember(Vote)
+	multisig := testMultisig(1, a)
+	multisig.StaleTransactionIndex = 5
+
+	stale := NewProposalState(multisig, &squads_multisig_program.Proposal{
+		TransactionIndex: 5,
+		Status:           &squads_multisig_program.ProposalStatusActive{},
+	})
+	if !stale.Stale || !stale.CannotPass {
+		t.Fatalf("expected a stale proposal that cannot pass: %+v", stale)
+	}
+
+	executed := NewProposalState(multisig, &squads_multisig_program.Proposal{
+		TransactionIndex: 6,
+		Status:           &squads_multisig_program.ProposalStatusExecuted{Timestamp: 42},
+		Approved:         []solana.PublicKey{a.Key},
+	})
+	if executed.CannotPass || executed.ExecutedAt == nil || executed.ActivatedAt != nil {
+		t.Fatalf("unexpected executed state %+v", executed)
+	}
+
+	cancelled := NewProposalState(multisig, &squads_multisig_program.Proposal{
+		TransactionIndex: 7,
+		Status:           &squads_multisig_program.ProposalStatusCancelled{Timestamp: 43},
+	})
+	if !cancelled.CannotPass || cancelled.CancelledAt == nil {
+		t.Fatalf("unexpected cancelled state %+v", cancelled)
+	}
+
+	out, err := json.Marshal(cancelled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ProposalState
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Status != ProposalStatusCancelled {
+		t.Fatalf("unexpected status %s in %s", decoded.Status, out)
+	}
+}
+
+func Test_MultisigProposalState(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+
+	a := testMember(Vote)
+	if err := client.SetBorshAccount(multisigPda, *testMultisig(1, a)); err != nil {
+		t.Fatal(err)
+	}
+	proposalPda, err := GetProposalPda(multisigPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.SetBorshAccount(proposalPda, squads_multisig_program.Proposal{
+		Multisig:         multisigPda,
+		TransactionIndex: 1,
+		Status:           &squads_multisig_program.ProposalStatusApproved{Timestamp: 1700000000},
+		Approved:         []solana.PublicKey{a.Key},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps, err := s.ProposalState(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.Status != ProposalStatusApproved || ps.ApprovedAt == nil || ps.ApprovalsNeeded != 0 || len(ps.PendingVoters) != 0 {
+		t.Fatalf("unexpected state %+v", ps)
+	}
+}
@@ -0,0 +1,360 @@
+package squads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+const (
+	// DefaultRebroadcastInterval is how often an unconfirmed transaction is sent again
+	DefaultRebroadcastInterval = 2 * time.Second
+	// DefaultConfirmPollInterval is how often the signature status is polled
+	DefaultConfirmPollInterval = 500 * time.Millisecond
+	// DefaultMaxRebuilds is how many times an expired transaction is rebuilt when a Rebuild callback is set
+	DefaultMaxRebuilds = 3
+
+	// maxBlockhashAge is the number of blocks a recent blockhash stays valid for
+	maxBlockhashAge = 150
+)
+
+// ErrBlockhashExpired is returned when the blockhash of a transaction expired before it was confirmed
+var ErrBlockhashExpired = errors.New("blockhash expired before the transaction was confirmed")
+
+// SendClient is the RPC API used to send and confirm transactions, *rpc.Client implements it
+type SendClient interface {
+	RPCClient
+	TransactionSender
+	SimulateTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResponse, error)
+	GetSignatureStatuses(ctx context.Context, searchTransactionHistory bool, transactionSignatures ...solana.Signature) (*rpc.GetSignatureStatusesResult, error)
+	GetBlockHeight(ctx context.Context, commitment rpc.CommitmentType) (uint64, error)
+}
+
+var _ SendClient = (*rpc.Client)(nil)
+
+// TransactionGetter fetches landed transactions, *rpc.Client implements it.
+// When the client of SendAndConfirm implements it, the logs of a failed transaction are fetched
+// to tell which program raised its custom error.
+type TransactionGetter interface {
+	GetTransaction(ctx context.Context, txSig solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error)
+}
+
+var _ TransactionGetter = (*rpc.Client)(nil)
+
+// RebuildFunc builds and signs a new transaction when the blockhash of the previous one expired
+type RebuildFunc func(ctx context.Context) (*solana.Transaction, error)
+
+// SendOpts configures SendAndConfirm
+type SendOpts struct {
+	// Commitment the transaction must reach, rpc.CommitmentConfirmed if empty
+	Commitment rpc.CommitmentType
+	// SkipPreflight sends the transaction without simulating it first
+	SkipPreflight bool
+	// LastValidBlockHeight of the transaction blockhash. When zero it is looked up, which is exact only
+	// if the transaction uses the latest blockhash. Durable nonce transactions never expire.
+	LastValidBlockHeight uint64
+	// RebroadcastInterval is DefaultRebroadcastInterval if zero
+	RebroadcastInterval time.Duration
+	// PollInterval is DefaultConfirmPollInterval if zero
+	PollInterval time.Duration
+	// WebsocketURL, when set, is used to be notified of the confirmation with signatureSubscribe.
+	// The signature status is polled as well, in case the subscription fails.
+	WebsocketURL string
+	// Rebuild is called when the blockhash expired, the transaction is not rebuilt if nil
+	Rebuild RebuildFunc
+	// MaxRebuilds is DefaultMaxRebuilds if zero
+	MaxRebuilds int
+}
+
+// SendResult is the outcome of SendAndConfirm
+type SendResult struct {
+	Signature solana.Signature `json:"signature"`
+	// Slot the transaction landed in
+	Slot               uint64                     `json:"slot"`
+	ConfirmationStatus rpc.ConfirmationStatusType `json:"confirmationStatus"`
+	// Broadcasts counts the times the last transaction was sent
+	Broadcasts int `json:"broadcasts"`
+	// Rebuilds counts the times the transaction was rebuilt after its blockhash expired
+	Rebuilds int `json:"rebuilds"`
+	// Err is the failure of a transaction that landed, a *TransactionError for custom program errors.
+	// Its multisig program error is only set when the logs could be fetched, see TransactionGetter.
+	Err error `json:"-"`
+}
+
+// SendAndConfirm sends a signed transaction and waits for it to reach the commitment.
+// The transaction is simulated first, program errors are returned as *TransactionError. It is then sent again
+// every RebroadcastInterval until it is confirmed or its blockhash expires, in which case it is rebuilt with
+// opts.Rebuild, or ErrBlockhashExpired is returned. When the transaction landed but failed, the result is
+// returned along with its Err. opts may be nil.
+func SendAndConfirm(ctx context.Context, client SendClient, tx *solana.Transaction, opts *SendOpts) (*SendResult, error) {
+	if opts == nil {
+		opts = &SendOpts{}
+	}
+	o := *opts
+	if o.Commitment == "" {
+		o.Commitment = rpc.CommitmentConfirmed
+	}
+	if o.RebroadcastInterval <= 0 {
+		o.RebroadcastInterval = DefaultRebroadcastInterval
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultConfirmPollInterval
+	}
+	if o.MaxRebuilds <= 0 {
+		o.MaxRebuilds = DefaultMaxRebuilds
+	}
+
+	result := &SendResult{}
+	lastValidBlockHeight := o.LastValidBlockHeight
+	for {
+		err := sendOnce(ctx, client, tx, lastValidBlockHeight, &o, result)
+		if !errors.Is(err, ErrBlockhashExpired) || o.Rebuild == nil || result.Rebuilds >= o.MaxRebuilds {
+			if err != nil {
+				return result, err
+			}
+			return result, result.Err
+		}
+		if tx, err = o.Rebuild(ctx); err != nil {
+			return result, fmt.Errorf("rebuild expired transaction: %w", err)
+		}
+		result.Rebuilds++
+		lastValidBlockHeight = 0
+	}
+}
+
+// SendAndConfirm sends and confirms tx with the client of the multisig, which must implement SendClient
+func (s *Multisig) SendAndConfirm(ctx context.Context, tx *solana.Transaction, opts *SendOpts) (*SendResult, error) {
+	client, ok := s.client.(SendClient)
+	if !ok {
+		return nil, fmt.Errorf("client %T can not send transactions", s.client)
+	}
+	return SendAndConfirm(ctx, client, tx, opts)
+}
+
+func sendOnce(ctx context.Context, client SendClient, tx *solana.Transaction, lastValidBlockHeight uint64, opts *SendOpts, result *SendResult) error {
+	if len(tx.Signatures) == 0 {
+		return errors.New("transaction is not signed")
+	}
+	result.Signature = tx.Signatures[0]
+	result.Broadcasts = 0
+
+	if !opts.SkipPreflight {
+		if err := preflight(ctx, client, tx, opts.Commitment); err != nil {
+			return err
+		}
+	}
+	if lastValidBlockHeight == 0 && !usesDurableNonce(tx) {
+		var err error
+		if lastValidBlockHeight, err = estimateLastValidBlockHeight(ctx, client, tx, opts.Commitment); err != nil {
+			return err
+		}
+	}
+
+	send := func() error {
+		_, err := client.SendTransactionWithOpts(ctx, tx, rpc.TransactionOpts{
+			SkipPreflight:       true,
+			PreflightCommitment: opts.Commitment,
+		})
+		if err != nil {
+			if txErr, ok := DecodeTransactionError(err); ok {
+				return txErr
+			}
+			return err
+		}
+		result.Broadcasts++
+		return nil
+	}
+	if err := send(); err != nil {
+		return err
+	}
+
+	var notified <-chan *ws.SignatureResult
+	if opts.WebsocketURL != "" {
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		notified = subscribeSignature(subCtx, opts.WebsocketURL, result.Signature, opts.Commitment)
+	}
+	poll := time.NewTicker(opts.PollInterval)
+	defer poll.Stop()
+	rebroadcast := time.NewTicker(opts.RebroadcastInterval)
+	defer rebroadcast.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res, ok := <-notified:
+			if !ok {
+				notified = nil
+				continue
+			}
+			result.Slot = res.Context.Slot
+			result.ConfirmationStatus = confirmationStatusOf(opts.Commitment)
+			if res.Value.Err != nil {
+				result.Err = statusError(ctx, client, result.Signature, res.Value.Err)
+			}
+			return nil
+		case <-poll.C:
+			done, err := checkStatus(ctx, client, result, opts.Commitment)
+			if done || err != nil {
+				return err
+			}
+		case <-rebroadcast.C:
+			if lastValidBlockHeight > 0 {
+				height, err := client.GetBlockHeight(ctx, opts.Commitment)
+				if err == nil && height > lastValidBlockHeight {
+					// it may have landed since the last poll
+					if done, err := checkStatus(ctx, client, result, opts.Commitment); done || err != nil {
+						return err
+					}
+					return fmt.Errorf("%w: signature %s, block height %d > %d", ErrBlockhashExpired, result.Signature, height, lastValidBlockHeight)
+				}
+			}
+			// rebroadcast failures are transient, the status polling decides the outcome
+			_ = send()
+		}
+	}
+}
+
+// preflight simulates the transaction, mapping program errors to *TransactionError
+func preflight(ctx context.Context, client SendClient, tx *solana.Transaction, commitment rpc.CommitmentType) error {
+	out, err := client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{Commitment: commitment})
+	if err != nil {
+		if txErr, ok := DecodeTransactionError(err); ok {
+			return txErr
+		}
+		return fmt.Errorf("preflight simulation: %w", err)
+	}
+	if out.Value == nil || out.Value.Err == nil {
+		return nil
+	}
+	if txErr, ok := DecodeSimulationError(out.Value); ok {
+		return txErr
+	}
+	if s, ok := out.Value.Err.(string); ok && s == "BlockhashNotFound" {
+		return fmt.Errorf("%w: blockhash not found", ErrBlockhashExpired)
+	}
+	return fmt.Errorf("preflight simulation failed: %v\n%s", out.Value.Err, strings.Join(out.Value.Logs, "\n"))
+}
+
+// checkStatus polls the signature status, it reports done once the transaction reached the commitment
+func checkStatus(ctx context.Context, client SendClient, result *SendResult, commitment rpc.CommitmentType) (bool, error) {
+	out, err := client.GetSignatureStatuses(ctx, false, result.Signature)
+	if err != nil || len(out.Value) != 1 || out.Value[0] == nil {
+		// the node may not know the signature yet, keep polling
+		return false, nil
+	}
+	status := out.Value[0]
+	result.Slot = status.Slot
+	result.ConfirmationStatus = status.ConfirmationStatus
+	if status.Err != nil {
+		result.Err = statusError(ctx, client, result.Signature, status.Err)
+		return true, nil
+	}
+	return reachedCommitment(status.ConfirmationStatus, commitment), nil
+}
+
+// statusError decodes the error of a landed transaction, with its logs when the client can fetch them
+func statusError(ctx context.Context, client SendClient, signature solana.Signature, v interface{}) error {
+	index, code, ok := decodeInstructionError(v)
+	if !ok {
+		return fmt.Errorf("transaction %s failed: %v", signature, v)
+	}
+	return newTransactionError(index, code, transactionLogs(ctx, client, signature), nil)
+}
+
+// transactionLogs returns the logs of a landed transaction, nil when they are not available
+func transactionLogs(ctx context.Context, client SendClient, signature solana.Signature) []string {
+	getter, ok := client.(TransactionGetter)
+	if !ok {
+		return nil
+	}
+	version := uint64(0)
+	out, err := getter.GetTransaction(ctx, signature, &rpc.GetTransactionOpts{
+		Encoding: solana.EncodingBase64,
+		// processed is not supported by getTransaction
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &version,
+	})
+	if err != nil || out == nil || out.Meta == nil {
+		return nil
+	}
+	return out.Meta.LogMessages
+}
+
+func reachedCommitment(status rpc.ConfirmationStatusType, commitment rpc.CommitmentType) bool {
+	switch commitment {
+	case rpc.CommitmentFinalized:
+		return status == rpc.ConfirmationStatusFinalized
+	case rpc.CommitmentConfirmed:
+		return status == rpc.ConfirmationStatusConfirmed || status == rpc.ConfirmationStatusFinalized
+	}
+	return status != ""
+}
+
+func confirmationStatusOf(commitment rpc.CommitmentType) rpc.ConfirmationStatusType {
+	switch commitment {
+	case rpc.CommitmentFinalized:
+		return rpc.ConfirmationStatusFinalized
+	case rpc.CommitmentProcessed:
+		return rpc.ConfirmationStatusProcessed
+	}
+	return rpc.ConfirmationStatusConfirmed
+}
+
+// estimateLastValidBlockHeight returns the last valid block height of the latest blockhash if the transaction
+// uses it, or a full blockhash lifetime from the current block height otherwise
+func estimateLastValidBlockHeight(ctx context.Context, client SendClient, tx *solana.Transaction, commitment rpc.CommitmentType) (uint64, error) {
+	latest, err := client.GetLatestBlockhash(ctx, commitment)
+	if err != nil {
+		return 0, err
+	}
+	if latest.Value.Blockhash == tx.Message.RecentBlockhash {
+		return latest.Value.LastValidBlockHeight, nil
+	}
+	height, err := client.GetBlockHeight(ctx, commitment)
+	if err != nil {
+		return 0, err
+	}
+	return height + maxBlockhashAge, nil
+}
+
+// usesDurableNonce tells whether the transaction starts by advancing a durable nonce
+func usesDurableNonce(tx *solana.Transaction) bool {
+	if len(tx.Message.Instructions) == 0 {
+		return false
+	}
+	first := tx.Message.Instructions[0]
+	programID, err := tx.Message.ResolveProgramIDIndex(first.ProgramIDIndex)
+	return err == nil && programID == solana.SystemProgramID && isAdvanceNonce(first.Data)
+}
+
+// subscribeSignature delivers the signatureSubscribe notification of signature, the channel is closed
+// without a value if the subscription fails
+func subscribeSignature(ctx context.Context, url string, signature solana.Signature, commitment rpc.CommitmentType) <-chan *ws.SignatureResult {
+	out := make(chan *ws.SignatureResult, 1)
+	go func() {
+		defer close(out)
+		conn, err := ws.Connect(ctx, url)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		sub, err := conn.SignatureSubscribe(signature, commitment)
+		if err != nil {
+			return
+		}
+		defer sub.Unsubscribe()
+		res, err := sub.Recv(ctx)
+		if err == nil {
+			out <- res
+		}
+	}()
+	return out
+}
@@ -0,0 +1,174 @@
+package squads
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var fastSend = &SendOpts{RebroadcastInterval: 5 * time.Millisecond, PollInterval: time.Millisecond}
+
+func testSignedTransfer(t *testing.T, blockhash solana.Hash) (*solana.Transaction, solana.PrivateKey) {
+	t.Helper()
+	payer := solana.NewWallet().PrivateKey
+	ix := system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build()
+	tx, err := solana.NewTransaction([]solana.Instruction{ix}, blockhash, solana.TransactionPayer(payer.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SignTransaction(t.Context(), tx, NewKeypairSigner(payer)); err != nil {
+		t.Fatal(err)
+	}
+	return tx, payer
+}
+
+func Test_SendAndConfirmRebroadcasts(t *testing.T) {
+	client := squadstest.NewClient()
+	recent, _ := client.GetLatestBlockhash(t.Context(), "")
+	tx, _ := testSignedTransfer(t, recent.Value.Blockhash)
+
+	// the first broadcast is dropped, the second lands
+	client.OnSend(func(sent *solana.Transaction) error {
+		if len(client.SentTransactions()) == 2 {
+			client.SetSignatureStatus(sent.Signatures[0], &rpc.SignatureStatusesResult{Slot: 42, ConfirmationStatus: rpc.ConfirmationStatusConfirmed})
+		}
+		return nil
+	})
+	result, err := SendAndConfirm(t.Context(), client, tx, fastSend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Signature != tx.Signatures[0] || result.Slot != 42 || result.Broadcasts != 2 || result.ConfirmationStatus != rpc.ConfirmationStatusConfirmed {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func Test_SendAndConfirmPreflightError(t *testing.T) {
+	client := squadstest.NewClient()
+	recent, _ := client.GetLatestBlockhash(t.Context(), "")
+	tx, _ := testSignedTransfer(t, recent.Value.Blockhash)
+
+	client.OnSimulate(func(tx *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResult, error) {
+		return &rpc.SimulateTransactionResult{
+			Err:  map[string]interface{}{"InstructionError": []interface{}{0, map[string]interface{}{"Custom": 6021}}},
+			Logs: []string{"Program " + squads_multisig_program.ProgramID.String() + " failed: custom program error: 0x1785"},
+		}, nil
+	})
+	_, err := SendAndConfirm(t.Context(), client, tx, fastSend)
+	var txErr *TransactionError
+	if !errors.As(err, &txErr) || !errors.Is(err, ErrTimeLockNotReleased) {
+		t.Fatalf("expected ErrTimeLockNotReleased, got %v", err)
+	}
+	if len(client.SentTransactions()) != 0 {
+		t.Fatal("a transaction failing preflight was sent")
+	}
+}
+
+func Test_SendAndConfirmLandedFailure(t *testing.T) {
+	client := squadstest.NewClient()
+	recent, _ := client.GetLatestBlockhash(t.Context(), "")
+	tx, _ := testSignedTransfer(t, recent.Value.Blockhash)
+
+	client.OnSend(func(sent *solana.Transaction) error {
+		client.SetSignatureStatus(sent.Signatures[0], &rpc.SignatureStatusesResult{
+			Slot:               7,
+			ConfirmationStatus: rpc.ConfirmationStatusProcessed,
+			Err:                map[string]interface{}{"InstructionError": []interface{}{0, map[string]interface{}{"Custom": 6021}}},
+		})
+		client.SetTransactionLogs(sent.Signatures[0], []string{
+			"Program " + squads_multisig_program.ProgramID.String() + " invoke [1]",
+			"Program " + squads_multisig_program.ProgramID.String() + " failed: custom program error: 0x1785",
+		})
+		return nil
+	})
+	result, err := SendAndConfirm(t.Context(), client, tx, fastSend)
+	var txErr *TransactionError
+	if !errors.Is(err, ErrTimeLockNotReleased) || !errors.As(err, &txErr) || len(txErr.Logs) != 2 || result.Err != err || result.Slot != 7 {
+		t.Fatalf("unexpected result %+v, error %v", result, err)
+	}
+
+	// without the logs the failing program is unknown
+	tx, _ = testSignedTransfer(t, recent.Value.Blockhash)
+	client.OnSend(func(sent *solana.Transaction) error {
+		client.SetSignatureStatus(sent.Signatures[0], &rpc.SignatureStatusesResult{
+			Slot:               8,
+			ConfirmationStatus: rpc.ConfirmationStatusProcessed,
+			Err:                map[string]interface{}{"InstructionError": []interface{}{0, map[string]interface{}{"Custom": 6021}}},
+		})
+		return nil
+	})
+	_, err = SendAndConfirm(t.Context(), client, tx, fastSend)
+	if !errors.As(err, &txErr) || txErr.Code != 6021 || txErr.Err != nil {
+		t.Fatalf("expected an unmapped error, got %v", err)
+	}
+}
+
+func Test_SendAndConfirmRebuildsExpired(t *testing.T) {
+	client := squadstest.NewClient()
+	client.SetBlockHeight(100)
+	tx, payer := testSignedTransfer(t, solana.Hash{1})
+
+	fresh := solana.Hash{2}
+	client.OnSend(func(sent *solana.Transaction) error {
+		if sent.Message.RecentBlockhash == fresh {
+			client.SetSignatureStatus(sent.Signatures[0], &rpc.SignatureStatusesResult{Slot: 9, ConfirmationStatus: rpc.ConfirmationStatusFinalized})
+		}
+		return nil
+	})
+
+	opts := *fastSend
+	opts.LastValidBlockHeight = 99
+	_, err := SendAndConfirm(t.Context(), client, tx, &opts)
+	if !errors.Is(err, ErrBlockhashExpired) {
+		t.Fatalf("expected ErrBlockhashExpired, got %v", err)
+	}
+
+	opts.Rebuild = func(ctx context.Context) (*solana.Transaction, error) {
+		ix := system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build()
+		rebuilt, err := solana.NewTransaction([]solana.Instruction{ix}, fresh, solana.TransactionPayer(payer.PublicKey()))
+		if err != nil {
+			return nil, err
+		}
+		client.SetBlockHeight(101)
+		client.SetLatestBlockhash(fresh, 200)
+		return rebuilt, SignTransaction(ctx, rebuilt, NewKeypairSigner(payer))
+	}
+	result, err := SendAndConfirm(t.Context(), client, tx, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rebuilds != 1 || result.Slot != 9 || result.Signature == tx.Signatures[0] {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func Test_SendAndConfirmWebsocket(t *testing.T) {
+	client := squadstest.NewClient()
+	server := squadstest.NewWebsocketServer(client)
+	defer server.Close()
+	recent, _ := client.GetLatestBlockhash(t.Context(), "")
+	tx, _ := testSignedTransfer(t, recent.Value.Blockhash)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	go func() {
+		if err := server.WaitForSubscriptions(ctx, 1); err == nil {
+			server.PublishSignature(tx.Signatures[0], nil)
+		}
+	}()
+	// the status is never polled before the notification arrives
+	result, err := SendAndConfirm(ctx, client, tx, &SendOpts{WebsocketURL: server.URL(), PollInterval: time.Hour, RebroadcastInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Signature != tx.Signatures[0] || result.ConfirmationStatus != rpc.ConfirmationStatusConfirmed {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
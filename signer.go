@@ -0,0 +1,267 @@
+package squads
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ErrSignerNotFound is returned when no signer is available for a public key
+var ErrSignerNotFound = errors.New("signer not found")
+
+// Signer signs transaction messages for a public key.
+// The key may be held locally, in a keystore or by a remote signing service.
+type Signer interface {
+	PublicKey() solana.PublicKey
+	// SignMessage signs the serialized transaction message
+	SignMessage(ctx context.Context, message []byte) (solana.Signature, error)
+}
+
+// KeypairSigner signs with a private key held in memory
+type KeypairSigner struct {
+	key solana.PrivateKey
+}
+
+var _ Signer = (*KeypairSigner)(nil)
+
+// NewKeypairSigner creates a signer for a private key
+func NewKeypairSigner(key solana.PrivateKey) *KeypairSigner {
+	return &KeypairSigner{key: key}
+}
+
+// NewKeypairSignerFromFile loads a solana-keygen JSON keypair file
+func NewKeypairSignerFromFile(path string) (*KeypairSigner, error) {
+	key, err := solana.PrivateKeyFromSolanaKeygenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load keypair %s: %w", path, err)
+	}
+	return NewKeypairSigner(key), nil
+}
+
+func (k *KeypairSigner) PublicKey() solana.PublicKey {
+	return k.key.PublicKey()
+}
+
+func (k *KeypairSigner) SignMessage(ctx context.Context, message []byte) (solana.Signature, error) {
+	return k.key.Sign(message)
+}
+
+// Keystore holds the solana-keygen JSON keypairs of a directory, indexed by public key
+type Keystore struct {
+	signers map[solana.PublicKey]*KeypairSigner
+}
+
+// OpenKeystore loads every *.json keypair file of dir
+func OpenKeystore(dir string) (*Keystore, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	ks := &Keystore{signers: make(map[solana.PublicKey]*KeypairSigner, len(paths))}
+	for _, path := range paths {
+		signer, err := NewKeypairSignerFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		ks.signers[signer.PublicKey()] = signer
+	}
+	return ks, nil
+}
+
+// Signer returns the signer of a public key, ErrSignerNotFound if the keystore does not hold it
+func (ks *Keystore) Signer(publicKey solana.PublicKey) (Signer, error) {
+	signer, ok := ks.signers[publicKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not in the keystore", ErrSignerNotFound, publicKey)
+	}
+	return signer, nil
+}
+
+// Signers returns every signer of the keystore, sorted by public key
+func (ks *Keystore) Signers() []Signer {
+	signers := make([]Signer, 0, len(ks.signers))
+	for _, signer := range ks.signers {
+		signers = append(signers, signer)
+	}
+	slices.SortFunc(signers, func(a, b Signer) int {
+		return comparePublicKeys(a.PublicKey(), b.PublicKey())
+	})
+	return signers
+}
+
+// RemoteSignRequest is the body posted to a remote signer
+type RemoteSignRequest struct {
+	PublicKey solana.PublicKey `json:"publicKey"`
+	// Message is the base64 encoded transaction message
+	Message string `json:"message"`
+}
+
+// RemoteSignResponse is the body returned by a remote signer, Error is set on failure
+type RemoteSignResponse struct {
+	Signature solana.Signature `json:"signature"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// RemoteSignerOpts configures a RemoteSigner
+type RemoteSignerOpts struct {
+	// HTTPClient sends the requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// Header is added to every request, e.g. an Authorization header
+	Header http.Header
+}
+
+// RemoteSigner asks an HTTP signing service for signatures.
+// It posts a RemoteSignRequest as JSON to the endpoint and expects a RemoteSignResponse,
+// the returned signature is verified against the public key before use.
+type RemoteSigner struct {
+	endpoint  string
+	publicKey solana.PublicKey
+	client    *http.Client
+	header    http.Header
+}
+
+var _ Signer = (*RemoteSigner)(nil)
+
+// NewRemoteSigner creates a signer for a key held by the signing service at endpoint, opts may be nil
+func NewRemoteSigner(endpoint string, publicKey solana.PublicKey, opts *RemoteSignerOpts) *RemoteSigner {
+	if opts == nil {
+		opts = &RemoteSignerOpts{}
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RemoteSigner{endpoint: endpoint, publicKey: publicKey, client: client, header: opts.Header.Clone()}
+}
+
+func (r *RemoteSigner) PublicKey() solana.PublicKey {
+	return r.publicKey
+}
+
+func (r *RemoteSigner) SignMessage(ctx context.Context, message []byte) (solana.Signature, error) {
+	body, err := json.Marshal(RemoteSignRequest{
+		PublicKey: r.publicKey,
+		Message:   base64.StdEncoding.EncodeToString(message),
+	})
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("remote signer %s: %w", r.publicKey, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("remote signer %s: %w", r.publicKey, err)
+	}
+	var out RemoteSignResponse
+	if err := json.Unmarshal(raw, &out); err != nil && resp.StatusCode == http.StatusOK {
+		return solana.Signature{}, fmt.Errorf("remote signer %s: decode response: %w", r.publicKey, err)
+	}
+	if resp.StatusCode != http.StatusOK || out.Error != "" {
+		msg := out.Error
+		if msg == "" {
+			msg = strings.TrimSpace(string(raw))
+		}
+		return solana.Signature{}, fmt.Errorf("remote signer %s: %s: %s", r.publicKey, resp.Status, msg)
+	}
+	if !out.Signature.Verify(r.publicKey, message) {
+		return solana.Signature{}, fmt.Errorf("remote signer %s: invalid signature", r.publicKey)
+	}
+	return out.Signature, nil
+}
+
+// SignTransaction signs tx with the signers whose key the message requires.
+// Signatures already present are kept, so a transaction can be signed in several passes.
+// It fails with ErrSignerNotFound when a required signature is still missing.
+func SignTransaction(ctx context.Context, tx *solana.Transaction, signers ...Signer) error {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	required := tx.Message.AccountKeys[:tx.Message.Header.NumRequiredSignatures]
+	if len(tx.Signatures) != len(required) {
+		signatures := make([]solana.Signature, len(required))
+		copy(signatures, tx.Signatures)
+		tx.Signatures = signatures
+	}
+
+	var missing []string
+	for i, key := range required {
+		if !tx.Signatures[i].IsZero() {
+			continue
+		}
+		idx := slices.IndexFunc(signers, func(s Signer) bool { return s != nil && s.PublicKey() == key })
+		if idx < 0 {
+			missing = append(missing, key.String())
+			continue
+		}
+		signature, err := signers[idx].SignMessage(ctx, message)
+		if err != nil {
+			return err
+		}
+		tx.Signatures[i] = signature
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing signatures of %s", ErrSignerNotFound, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// TransactionSender sends signed transactions, *rpc.Client implements it
+type TransactionSender interface {
+	SendTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts rpc.TransactionOpts) (solana.Signature, error)
+}
+
+var _ TransactionSender = (*rpc.Client)(nil)
+
+// TransactionSigners assigns signers to the roles of a transaction.
+// A signer may hold several roles, e.g. a member that creates, approves and pays for a proposal.
+type TransactionSigners struct {
+	// FeePayer pays the fees, it must be the payer the transaction was built with
+	FeePayer Signer
+	// Creator creates transactions and proposals, or executes them
+	Creator Signer
+	// Voter approves, rejects or cancels proposals
+	Voter Signer
+	// Others are any additional signers, such as the create key of a new multisig
+	Others []Signer
+}
+
+func (ts TransactionSigners) all() []Signer {
+	signers := append([]Signer{ts.FeePayer, ts.Creator, ts.Voter}, ts.Others...)
+	return slices.DeleteFunc(signers, func(s Signer) bool { return s == nil })
+}
+
+// SignAndSend collects the signatures of tx from signers, then sends and confirms it with SendAndConfirm.
+// opts may be nil, a Rebuild function must sign the transaction it builds.
+func (s *Multisig) SignAndSend(ctx context.Context, tx *solana.Transaction, signers TransactionSigners, opts *SendOpts) (*SendResult, error) {
+	if signers.FeePayer != nil && signers.FeePayer.PublicKey() != tx.Message.AccountKeys[0] {
+		return nil, fmt.Errorf("fee payer %s does not match the transaction payer %s", signers.FeePayer.PublicKey(), tx.Message.AccountKeys[0])
+	}
+	if err := SignTransaction(ctx, tx, signers.all()...); err != nil {
+		return nil, err
+	}
+	return s.SendAndConfirm(ctx, tx, opts)
+}
@@ -0,0 +1,125 @@
+package squads
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func writeKeypair(t *testing.T, path string, key solana.PrivateKey) {
+	t.Helper()
+	numbers := make([]int, len(key))
+	for i, b := range key {
+		numbers[i] = int(b)
+	}
+	raw, err := json.Marshal(numbers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_SignAndSend(t *testing.T) {
+	client := squadstest.NewClient()
+	voter := testMember(Initiate | Vote | Execute)
+	multisigPda := solana.NewWallet().PublicKey()
+	if err := client.SetBorshAccount(multisigPda, *testMultisig(1, voter)); err != nil {
+		t.Fatal(err)
+	}
+	s := New(client, multisigPda)
+
+	// the voter key is held by a remote service, the fee payer in a keystore
+	voterKey, payerKey := solana.NewWallet().PrivateKey, solana.NewWallet().PrivateKey
+	server := squadstest.NewSignerServer(voterKey)
+	defer server.Close()
+	dir := t.TempDir()
+	writeKeypair(t, filepath.Join(dir, "payer.json"), payerKey)
+	ks, err := OpenKeystore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer, err := ks.Signer(payerKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := NewRemoteSigner(server.URL(), voterKey.PublicKey(), &RemoteSignerOpts{
+		Header: http.Header{"Authorization": {"Bearer token"}},
+	})
+
+	ix, err := s.ProposalApproveIx(t.Context(), voterKey.PublicKey(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recent, err := client.GetLatestBlockhash(t.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := solana.NewTransaction([]solana.Instruction{ix}, recent.Value.Blockhash, solana.TransactionPayer(payerKey.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.OnSend(func(sent *solana.Transaction) error {
+		client.SetSignatureStatus(sent.Signatures[0], &rpc.SignatureStatusesResult{Slot: 7, ConfirmationStatus: rpc.ConfirmationStatusConfirmed})
+		return nil
+	})
+	if _, err := s.SignAndSend(t.Context(), tx, TransactionSigners{FeePayer: remote, Voter: payer}, nil); err == nil {
+		t.Fatal("expected a fee payer mismatch")
+	}
+	if _, err := s.SignAndSend(t.Context(), tx, TransactionSigners{FeePayer: payer}, nil); !errors.Is(err, ErrSignerNotFound) {
+		t.Fatalf("expected ErrSignerNotFound, got %v", err)
+	}
+
+	result, err := s.SignAndSend(t.Context(), tx, TransactionSigners{FeePayer: payer, Voter: remote}, &SendOpts{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Slot != 7 || result.ConfirmationStatus != rpc.ConfirmationStatusConfirmed {
+		t.Fatalf("unexpected send result %+v", result)
+	}
+	sent := client.SentTransactions()
+	if len(sent) != 1 || sent[0].Signatures[0] != result.Signature {
+		t.Fatalf("unexpected sent transactions %v", sent)
+	}
+	if server.Requests() != 1 || server.LastHeader().Get("Authorization") != "Bearer token" {
+		t.Fatalf("unexpected remote requests %d %v", server.Requests(), server.LastHeader())
+	}
+}
+
+func Test_RemoteSignerErrors(t *testing.T) {
+	server := squadstest.NewSignerServer()
+	defer server.Close()
+
+	unknown := NewRemoteSigner(server.URL(), solana.NewWallet().PublicKey(), nil)
+	if _, err := unknown.SignMessage(t.Context(), []byte("message")); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Fatalf("expected an unknown key error, got %v", err)
+	}
+
+	// signatures made with another key are rejected
+	impostor := solana.NewWallet().PrivateKey
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req RemoteSignRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		message, _ := base64.StdEncoding.DecodeString(req.Message)
+		signature, _ := impostor.Sign(message)
+		json.NewEncoder(w).Encode(RemoteSignResponse{Signature: signature})
+	}))
+	defer fake.Close()
+	signer := NewRemoteSigner(fake.URL, solana.NewWallet().PublicKey(), nil)
+	if _, err := signer.SignMessage(t.Context(), []byte("message")); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Fatalf("expected an invalid signature error, got %v", err)
+	}
+}
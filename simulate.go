@@ -0,0 +1,280 @@
+package squads
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// lamportsPerSignature is the base fee of a transaction signature
+const lamportsPerSignature = 5000
+
+// TransactionSimulator is implemented by clients able to simulate transactions, such as *rpc.Client
+type TransactionSimulator interface {
+	SimulateTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResponse, error)
+}
+
+// BalanceChange is the lamport balance of an account before and after a simulation
+type BalanceChange struct {
+	Account solana.PublicKey `json:"account"`
+	Pre     uint64           `json:"pre"`
+	Post    uint64           `json:"post"`
+}
+
+// Delta returns Post - Pre
+func (c BalanceChange) Delta() *big.Int {
+	return new(big.Int).Sub(new(big.Int).SetUint64(c.Post), new(big.Int).SetUint64(c.Pre))
+}
+
+// TokenBalanceChange is the balance of a token account before and after a simulation,
+// in base units of the mint. Accounts created or closed by the simulation have a zero Pre or Post.
+type TokenBalanceChange struct {
+	BalanceChange
+	Mint  solana.PublicKey `json:"mint"`
+	Owner solana.PublicKey `json:"owner"`
+}
+
+// VaultTransactionSimulation is the outcome of simulating a vault transaction
+type VaultTransactionSimulation struct {
+	TransactionIndex uint64 `json:"transactionIndex"`
+	// Direct is set when the inner message was simulated with the vault as payer, because the proposal
+	// is not executable yet. The transaction fee is then left out of the vault balance change.
+	Direct bool `json:"direct"`
+	// PreSlot is the slot the balances before the simulation were read at, Slot the slot the simulation ran at.
+	// They are read by separate requests, so Slot can be later than PreSlot and a change made in between
+	// shows up in the reported balances.
+	PreSlot uint64 `json:"preSlot"`
+	Slot    uint64 `json:"slot"`
+	// Lamports and Tokens hold the balances of the vault and the writable accounts that changed
+	Lamports      []BalanceChange      `json:"lamports"`
+	Tokens        []TokenBalanceChange `json:"tokens"`
+	Logs          []string             `json:"logs"`
+	UnitsConsumed uint64               `json:"unitsConsumed"`
+	// Err is the simulation failure, a *TransactionError for program errors, nil on success
+	Err error `json:"-"`
+}
+
+// SimulateVaultTransaction simulates a vault transaction and reports its effect on balances.
+// An executable proposal is simulated through VaultTransactionExecute, as the first member with the execute permission
+// holding the transaction fee; otherwise, e.g. before approval, the inner message is simulated directly with the vault
+// as payer, and the vault must hold the transaction fee.
+// Signatures are not verified and the blockhash is replaced by the node, so no signer is needed.
+// The client must implement TransactionSimulator. A failing simulation is reported in Err, not as an error.
+func (s *Multisig) SimulateVaultTransaction(ctx context.Context, transactionIndex uint64) (*VaultTransactionSimulation, error) {
+	simulator, ok := s.client.(TransactionSimulator)
+	if !ok {
+		return nil, fmt.Errorf("client %T can not simulate transactions", s.client)
+	}
+	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	vaultTransaction, err := s.VaultTransactionAccount(ctx, transactionPda)
+	if err != nil {
+		return nil, err
+	}
+	vaultPda, err := GetVaultPda(s.multisigPda, vaultTransaction.VaultIndex)
+	if err != nil {
+		return nil, err
+	}
+	lookups := make([]solana.PublicKey, 0, len(vaultTransaction.Message.AddressTableLookups))
+	for _, lookup := range vaultTransaction.Message.AddressTableLookups {
+		lookups = append(lookups, lookup.AccountKey)
+	}
+	addressLookupTableAccounts, err := s.AddressLookupTableAccounts(ctx, lookups)
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := DecompileVaultTransactionMessage(vaultTransaction.Message, addressLookupTableAccounts)
+	if err != nil {
+		return nil, err
+	}
+	tables := solana.TransactionAddressTables(transactionAddressTables(addressLookupTableAccounts))
+
+	executor, err := s.simulationExecutor(ctx, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	var tx *solana.Transaction
+	if executor != nil {
+		ix, _, err := s.vaultTransactionExecuteIx(ctx, *executor, transactionIndex)
+		if err != nil {
+			return nil, err
+		}
+		tx, err = solana.NewTransaction([]solana.Instruction{ix}, solana.Hash{}, solana.TransactionPayer(*executor), tables)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		tx, err = solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(vaultPda), tables)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	watched := solana.PublicKeySlice{vaultPda}
+	for _, account := range writableAccounts(instructions) {
+		if !watched.Contains(account) {
+			watched = append(watched, account)
+		}
+	}
+	pre, err := s.client.GetMultipleAccounts(ctx, watched...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := simulator.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		SigVerify:              false,
+		ReplaceRecentBlockhash: true,
+		Commitment:             rpc.CommitmentConfirmed,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: watched,
+		},
+	})
+	if err != nil {
+		if txErr, ok := DecodeTransactionError(err); ok {
+			return &VaultTransactionSimulation{TransactionIndex: transactionIndex, Direct: executor == nil, PreSlot: pre.Context.Slot, Err: txErr}, nil
+		}
+		return nil, fmt.Errorf("simulate vault transaction: %w", err)
+	}
+	if out.Value == nil {
+		return nil, fmt.Errorf("simulate vault transaction: empty result")
+	}
+
+	result := &VaultTransactionSimulation{
+		TransactionIndex: transactionIndex,
+		Direct:           executor == nil,
+		PreSlot:          pre.Context.Slot,
+		Slot:             out.Context.Slot,
+		Logs:             out.Value.Logs,
+	}
+	if out.Value.UnitsConsumed != nil {
+		result.UnitsConsumed = *out.Value.UnitsConsumed
+	}
+	if out.Value.Err != nil {
+		if txErr, ok := DecodeSimulationError(out.Value); ok {
+			result.Err = txErr
+		} else {
+			result.Err = fmt.Errorf("simulation failed: %v\n%s", out.Value.Err, strings.Join(out.Value.Logs, "\n"))
+		}
+		// the node returns no account state for a failed simulation
+		return result, nil
+	}
+	if len(out.Value.Accounts) != len(watched) {
+		return nil, fmt.Errorf("simulate vault transaction: %d accounts returned, %d requested", len(out.Value.Accounts), len(watched))
+	}
+
+	for i, account := range watched {
+		before, after := pre.Value[i], out.Value.Accounts[i]
+		change := BalanceChange{Account: account, Pre: lamportsOf(before), Post: lamportsOf(after)}
+		if result.Direct && account.Equals(vaultPda) {
+			change.Post += lamportsPerSignature * uint64(len(tx.Signatures))
+		}
+		if change.Pre != change.Post {
+			result.Lamports = append(result.Lamports, change)
+		}
+
+		preToken, preOk := parseTokenAccount(before)
+		postToken, postOk := parseTokenAccount(after)
+		if !preOk && !postOk {
+			continue
+		}
+		token := TokenBalanceChange{BalanceChange: BalanceChange{Account: account}}
+		if preOk {
+			token.Mint, token.Owner, token.Pre = preToken.mint, preToken.owner, preToken.amount
+		}
+		if postOk {
+			token.Mint, token.Owner, token.Post = postToken.mint, postToken.owner, postToken.amount
+		}
+		if token.Pre != token.Post {
+			result.Tokens = append(result.Tokens, token)
+		}
+	}
+	return result, nil
+}
+
+// simulationExecutor returns a member able to execute the transaction now and pay its fee, nil when the proposal
+// is missing or not executable, e.g. still waiting for approvals, or when no executor holds the fee
+func (s *Multisig) simulationExecutor(ctx context.Context, transactionIndex uint64) (*solana.PublicKey, error) {
+	proposalPda, err := GetProposalPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.client.GetAccountInfo(ctx, proposalPda); err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			// no proposal yet
+			return nil, nil
+		}
+		return nil, err
+	}
+	readiness, err := s.ExecutionReadiness(ctx, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !readiness.Executable {
+		return nil, nil
+	}
+	multisig, err := s.MultisigAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var executors []solana.PublicKey
+	for _, member := range multisig.Members {
+		if Permission(member.Permissions.Mask).Has(Execute) {
+			executors = append(executors, member.Key)
+		}
+	}
+	if len(executors) == 0 {
+		return nil, nil
+	}
+	// a payer without lamports fails the simulation with AccountNotFound
+	accounts, err := s.client.GetMultipleAccounts(ctx, executors...)
+	if err != nil {
+		return nil, err
+	}
+	for i, account := range accounts.Value {
+		if lamportsOf(account) >= lamportsPerSignature {
+			return &executors[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func lamportsOf(account *rpc.Account) uint64 {
+	if account == nil {
+		return 0
+	}
+	return account.Lamports
+}
+
+type tokenAccount struct {
+	mint, owner solana.PublicKey
+	amount      uint64
+}
+
+// parseTokenAccount decodes an SPL Token or Token-2022 account, mints and other accounts are rejected
+func parseTokenAccount(account *rpc.Account) (tokenAccount, bool) {
+	if account == nil || account.Data == nil {
+		return tokenAccount{}, false
+	}
+	if !account.Owner.Equals(solana.TokenProgramID) && !account.Owner.Equals(solana.Token2022ProgramID) {
+		return tokenAccount{}, false
+	}
+	data := account.Data.GetBinary()
+	// Token-2022 accounts with extensions are followed by the account type, 2 for token accounts
+	if len(data) < 165 || (len(data) > 165 && data[165] != 2) {
+		return tokenAccount{}, false
+	}
+	return tokenAccount{
+		mint:   solana.PublicKeyFromBytes(data[0:32]),
+		owner:  solana.PublicKeyFromBytes(data[32:64]),
+		amount: binary.LittleEndian.Uint64(data[64:72]),
+	}, true
+}
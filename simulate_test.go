@@ -0,0 +1,154 @@
+package squads
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func testTokenAccountData(mint, owner solana.PublicKey, amount uint64) []byte {
+	data := make([]byte, 165)
+	copy(data[0:32], mint[:])
+	copy(data[32:64], owner[:])
+	binary.LittleEndian.PutUint64(data[64:72], amount)
+	data[108] = 1 // initialized
+	return data
+}
+
+func Test_SimulateVaultTransaction(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+	executor := testMember(Vote | Execute)
+	if err := client.SetBorshAccount(multisigPda, *testMultisig(1, testMember(Vote), executor)); err != nil {
+		t.Fatal(err)
+	}
+
+	vaultPda, err := GetVaultPda(multisigPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionPda, err := GetTransactionPda(multisigPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposalPda, err := GetProposalPda(multisigPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, mint := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	vaultTokens, recipientTokens := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	client.SetAccount(vaultPda, solana.SystemProgramID, 1_000_000, nil)
+	client.SetAccount(vaultTokens, solana.TokenProgramID, 2_039_280, testTokenAccountData(mint, vaultPda, 100))
+	client.SetAccount(recipientTokens, solana.TokenProgramID, 2_039_280, testTokenAccountData(mint, recipient, 0))
+
+	instructions := []solana.Instruction{
+		system.NewTransferInstruction(1000, vaultPda, recipient).Build(),
+		token.NewTransferInstruction(40, vaultTokens, recipientTokens, vaultPda, nil).Build(),
+	}
+	data, err := TransactionMessageToMultisigTransactionMessageBytes(TransactionMessage{PayerKey: vaultPda, Instructions: instructions}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := DecodeTransactionMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.SetBorshAccount(transactionPda, squads_multisig_program.VaultTransaction{Multisig: multisigPda, Index: 1, Message: message})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setProposal := func(status squads_multisig_program.ProposalStatus) {
+		t.Helper()
+		err := client.SetBorshAccount(proposalPda, squads_multisig_program.Proposal{Multisig: multisigPda, TransactionIndex: 1, Status: status})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	setProposal(&squads_multisig_program.ProposalStatusActive{Timestamp: 1700000000})
+	client.SetClock(100, 1700000000)
+
+	var payer solana.PublicKey
+	client.OnSimulate(func(tx *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResult, error) {
+		payer = tx.Message.AccountKeys[0]
+		if opts.SigVerify || !opts.ReplaceRecentBlockhash || opts.Accounts == nil {
+			t.Errorf("unexpected simulation options %+v", opts)
+			return &rpc.SimulateTransactionResult{}, nil
+		}
+		fee := uint64(0)
+		if payer == vaultPda {
+			fee = 5000
+		}
+		post := map[solana.PublicKey]*rpc.Account{
+			vaultPda:        {Lamports: 1_000_000 - 1000 - fee, Owner: solana.SystemProgramID},
+			recipient:       {Lamports: 1000, Owner: solana.SystemProgramID},
+			vaultTokens:     {Lamports: 2_039_280, Owner: solana.TokenProgramID, Data: rpc.DataBytesOrJSONFromBytes(testTokenAccountData(mint, vaultPda, 60))},
+			recipientTokens: {Lamports: 2_039_280, Owner: solana.TokenProgramID, Data: rpc.DataBytesOrJSONFromBytes(testTokenAccountData(mint, recipient, 40))},
+		}
+		accounts := make([]*rpc.Account, len(opts.Accounts.Addresses))
+		for i, address := range opts.Accounts.Addresses {
+			accounts[i] = post[address]
+		}
+		units := uint64(4500)
+		return &rpc.SimulateTransactionResult{Logs: []string{"Program log: ok"}, UnitsConsumed: &units, Accounts: accounts}, nil
+	})
+
+	// a pending proposal is simulated directly from the vault
+	result, err := s.SimulateVaultTransaction(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Direct || payer != vaultPda || result.Err != nil || result.UnitsConsumed != 4500 || len(result.Logs) != 1 || result.PreSlot == 0 || result.Slot < result.PreSlot {
+		t.Fatalf("unexpected simulation %+v", result)
+	}
+	if len(result.Lamports) != 2 || result.Lamports[0].Account != vaultPda || result.Lamports[0].Delta().Int64() != -1000 ||
+		result.Lamports[1].Account != recipient || result.Lamports[1].Delta().Int64() != 1000 {
+		t.Fatalf("unexpected lamport changes %+v", result.Lamports)
+	}
+	if len(result.Tokens) != 2 || result.Tokens[0].Delta().Int64() != -40 || result.Tokens[1].Delta().Int64() != 40 ||
+		result.Tokens[1].Mint != mint || result.Tokens[1].Owner != recipient {
+		t.Fatalf("unexpected token changes %+v", result.Tokens)
+	}
+
+	// an executable proposal whose executor can not pay the fee is still simulated directly
+	setProposal(&squads_multisig_program.ProposalStatusApproved{Timestamp: 1700000000})
+	result, err = s.SimulateVaultTransaction(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Direct || payer != vaultPda {
+		t.Fatalf("expected a direct simulation without a funded executor, got %+v", result)
+	}
+
+	// an executable proposal goes through VaultTransactionExecute
+	client.SetAccount(executor.Key, solana.SystemProgramID, 5000, nil)
+	result, err = s.SimulateVaultTransaction(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Direct || payer != executor.Key || len(result.Lamports) != 2 || result.Lamports[0].Delta().Int64() != -1000 {
+		t.Fatalf("unexpected simulation %+v", result)
+	}
+
+	client.OnSimulate(func(tx *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResult, error) {
+		return &rpc.SimulateTransactionResult{
+			Logs: []string{"Program " + squads_multisig_program.ProgramID.String() + " failed: custom program error: 0x1789"},
+			Err:  map[string]interface{}{"InstructionError": []interface{}{0, map[string]interface{}{"Custom": 6025}}},
+		}, nil
+	})
+	result, err = s.SimulateVaultTransaction(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var txErr *TransactionError
+	if !errors.As(result.Err, &txErr) || len(result.Lamports) != 0 {
+		t.Fatalf("expected a decoded program error, got %+v", result)
+	}
+}
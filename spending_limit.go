@@ -0,0 +1,258 @@
+package squads
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Reset periods of spending limits, in seconds. A month is 30 days for the program.
+const (
+	SpendingLimitDaySeconds   = 24 * 60 * 60
+	SpendingLimitWeekSeconds  = 7 * SpendingLimitDaySeconds
+	SpendingLimitMonthSeconds = 30 * SpendingLimitDaySeconds
+)
+
+// nativeMintDecimals are the decimals the program expects for SOL spending limits
+const nativeMintDecimals = 9
+
+// ErrNoSpendingLimit is returned when no spending limit of the multisig allows a transfer
+var ErrNoSpendingLimit = errors.New("no spending limit allows the transfer")
+
+// SpendingLimitPeriodSeconds returns the reset period of a spending limit in seconds, 0 for one-time limits
+func SpendingLimitPeriodSeconds(period squads_multisig_program.Period) int64 {
+	switch period {
+	case squads_multisig_program.PeriodDay:
+		return SpendingLimitDaySeconds
+	case squads_multisig_program.PeriodWeek:
+		return SpendingLimitWeekSeconds
+	case squads_multisig_program.PeriodMonth:
+		return SpendingLimitMonthSeconds
+	}
+	return 0
+}
+
+// spendingLimitLastReset returns the LastReset the program would record on a use at clusterTime:
+// once more than a period passed, it moves forward by whole periods and the remaining amount is reset
+func spendingLimitLastReset(spendingLimit *squads_multisig_program.SpendingLimit, clusterTime time.Time) (int64, bool) {
+	period := SpendingLimitPeriodSeconds(spendingLimit.Period)
+	if period == 0 {
+		return spendingLimit.LastReset, false
+	}
+	passed := clusterTime.Unix() - spendingLimit.LastReset
+	if passed <= period {
+		return spendingLimit.LastReset, false
+	}
+	return spendingLimit.LastReset + passed/period*period, true
+}
+
+// SpendingLimitRemaining returns the amount a spending limit allows at the given cluster time.
+// It applies the period reset the program performs on use, which RemainingAmount does not reflect until then.
+func SpendingLimitRemaining(spendingLimit *squads_multisig_program.SpendingLimit, clusterTime time.Time) uint64 {
+	if _, reset := spendingLimitLastReset(spendingLimit, clusterTime); reset {
+		return spendingLimit.Amount
+	}
+	return spendingLimit.RemainingAmount
+}
+
+// SpendingLimitNextReset returns the first cluster time after clusterTime at which the remaining amount
+// is reset to Amount, zero for one-time limits
+func SpendingLimitNextReset(spendingLimit *squads_multisig_program.SpendingLimit, clusterTime time.Time) time.Time {
+	period := SpendingLimitPeriodSeconds(spendingLimit.Period)
+	if period == 0 {
+		return time.Time{}
+	}
+	lastReset, _ := spendingLimitLastReset(spendingLimit, clusterTime)
+	// the program resets once strictly more than a period passed
+	return time.Unix(lastReset+period+1, 0).UTC()
+}
+
+// SpendingLimitRequest describes a transfer out of a vault through a spending limit.
+// Mint is the zero public key for SOL. Amount is in base units of the mint.
+type SpendingLimitRequest struct {
+	Member      solana.PublicKey
+	Mint        solana.PublicKey
+	Destination solana.PublicKey
+	Amount      uint64
+}
+
+// CheckSpendingLimit returns the program error using the spending limit for the request at the given
+// cluster time would fail with, nil when it is allowed
+func CheckSpendingLimit(spendingLimit *squads_multisig_program.SpendingLimit, request SpendingLimitRequest, clusterTime time.Time) error {
+	if !spendingLimit.Mint.Equals(request.Mint) {
+		return ErrInvalidMint
+	}
+	if !slices.Contains(spendingLimit.Members, request.Member) {
+		return ErrUnauthorized
+	}
+	if len(spendingLimit.Destinations) > 0 && !slices.Contains(spendingLimit.Destinations, request.Destination) {
+		return ErrInvalidDestination
+	}
+	if request.Amount > SpendingLimitRemaining(spendingLimit, clusterTime) {
+		return ErrSpendingLimitExceeded
+	}
+	return nil
+}
+
+// SelectSpendingLimit picks the spending limit of the multisig to use for the request at the current cluster time.
+// Among the limits allowing it, recurring limits resetting soonest come first, as their amount would
+// otherwise be lost at the reset, then the smallest remaining amount, keeping larger limits available.
+// It returns an error wrapping ErrNoSpendingLimit when no limit allows the request.
+func (s *Multisig) SelectSpendingLimit(ctx context.Context, request SpendingLimitRequest) (*KeyedAccount[squads_multisig_program.SpendingLimit], error) {
+	spendingLimits, err := s.SpendingLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusterTime, err := s.ClusterTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return selectSpendingLimit(spendingLimits, request, clusterTime)
+}
+
+func selectSpendingLimit(spendingLimits []KeyedAccount[squads_multisig_program.SpendingLimit], request SpendingLimitRequest, clusterTime time.Time) (*KeyedAccount[squads_multisig_program.SpendingLimit], error) {
+	var candidates []KeyedAccount[squads_multisig_program.SpendingLimit]
+	var reasons []error
+	for _, spendingLimit := range spendingLimits {
+		if err := CheckSpendingLimit(spendingLimit.Account, request, clusterTime); err != nil {
+			if !errors.Is(err, ErrInvalidMint) {
+				reasons = append(reasons, fmt.Errorf("spending limit %s: %w", spendingLimit.Address, err))
+			}
+			continue
+		}
+		candidates = append(candidates, spendingLimit)
+	}
+	if len(candidates) == 0 {
+		return nil, errors.Join(append([]error{ErrNoSpendingLimit}, reasons...)...)
+	}
+	slices.SortStableFunc(candidates, func(a, b KeyedAccount[squads_multisig_program.SpendingLimit]) int {
+		aReset, bReset := SpendingLimitNextReset(a.Account, clusterTime), SpendingLimitNextReset(b.Account, clusterTime)
+		return cmp.Or(
+			// one-time limits last
+			cmp.Compare(boolToInt(aReset.IsZero()), boolToInt(bReset.IsZero())),
+			aReset.Compare(bReset),
+			cmp.Compare(SpendingLimitRemaining(a.Account, clusterTime), SpendingLimitRemaining(b.Account, clusterTime)),
+		)
+	})
+	return &candidates[0], nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// MintInfo is the token program and decimals of a mint
+type MintInfo struct {
+	Mint solana.PublicKey
+	// TokenProgram is solana.TokenProgramID or solana.Token2022ProgramID
+	TokenProgram solana.PublicKey
+	Decimals     uint8
+}
+
+// GetMintInfo fetches a SPL Token or Token-2022 mint
+func GetMintInfo(ctx context.Context, client RPCClient, mint solana.PublicKey) (*MintInfo, error) {
+	out, err := client.GetAccountInfo(ctx, mint)
+	if err != nil {
+		return nil, err
+	}
+	owner, data := out.Value.Owner, out.Value.Data.GetBinary()
+	if !owner.Equals(solana.TokenProgramID) && !owner.Equals(solana.Token2022ProgramID) {
+		return nil, fmt.Errorf("mint %s is owned by %s, not a token program", mint, owner)
+	}
+	// Token-2022 mints with extensions are padded to the token account size and followed by the account type, 1 for mints
+	if len(data) < 82 || (len(data) > 82 && (len(data) <= 165 || data[165] != 1)) {
+		return nil, fmt.Errorf("account %s is not a mint", mint)
+	}
+	// mint authority option (36 bytes) and supply (8 bytes) come before the decimals
+	return &MintInfo{Mint: mint, TokenProgram: owner, Decimals: data[44]}, nil
+}
+
+// SpendingLimitTransfer is a transfer through a spending limit with the accounts SpendingLimitUse needs
+type SpendingLimitTransfer struct {
+	Request       SpendingLimitRequest
+	SpendingLimit KeyedAccount[squads_multisig_program.SpendingLimit]
+	Vault         solana.PublicKey
+	// Mint is nil for SOL
+	Mint *MintInfo
+	// VaultTokenAccount and DestinationTokenAccount are the associated token accounts of the vault and the
+	// destination, zero for SOL. The destination token account must exist.
+	VaultTokenAccount       solana.PublicKey
+	DestinationTokenAccount solana.PublicKey
+}
+
+// PrepareSpendingLimitTransfer selects the spending limit for the request, see SelectSpendingLimit, and resolves
+// the vault, the mint decimals and the associated token accounts of the transfer
+func (s *Multisig) PrepareSpendingLimitTransfer(ctx context.Context, request SpendingLimitRequest) (*SpendingLimitTransfer, error) {
+	spendingLimit, err := s.SelectSpendingLimit(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	vaultPda, err := GetVaultPda(s.multisigPda, spendingLimit.Account.VaultIndex)
+	if err != nil {
+		return nil, err
+	}
+	transfer := &SpendingLimitTransfer{Request: request, SpendingLimit: *spendingLimit, Vault: vaultPda}
+	if request.Mint.IsZero() {
+		return transfer, nil
+	}
+	if transfer.Mint, err = GetMintInfo(ctx, s.client, request.Mint); err != nil {
+		return nil, err
+	}
+	if transfer.VaultTokenAccount, err = GetAssociatedTokenAddress(vaultPda, request.Mint, transfer.Mint.TokenProgram); err != nil {
+		return nil, err
+	}
+	if transfer.DestinationTokenAccount, err = GetAssociatedTokenAddress(request.Destination, request.Mint, transfer.Mint.TokenProgram); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// SpendingLimitTransferIx creates a SpendingLimitUse instruction transferring from a vault through the best
+// spending limit for the request. See PrepareSpendingLimitTransfer.
+func (s *Multisig) SpendingLimitTransferIx(ctx context.Context, request SpendingLimitRequest, memo *string) (solana.Instruction, error) {
+	transfer, err := s.PrepareSpendingLimitTransfer(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	args := squads_multisig_program.SpendingLimitUseArgs{Amount: request.Amount, Decimals: nativeMintDecimals, Memo: memo}
+	// optional accounts left out are passed as the program id
+	mint, vaultTokenAccount, destinationTokenAccount, tokenProgram := squads_multisig_program.ProgramID, squads_multisig_program.ProgramID, squads_multisig_program.ProgramID, squads_multisig_program.ProgramID
+	if transfer.Mint != nil {
+		args.Decimals = transfer.Mint.Decimals
+		mint, vaultTokenAccount, destinationTokenAccount, tokenProgram = transfer.Mint.Mint, transfer.VaultTokenAccount, transfer.DestinationTokenAccount, transfer.Mint.TokenProgram
+	}
+	ix := squads_multisig_program.NewSpendingLimitUseInstruction(
+		args,
+		s.multisigPda,
+		request.Member,
+		transfer.SpendingLimit.Address,
+		transfer.Vault,
+		request.Destination,
+		solana.SystemProgramID,
+		mint,
+		vaultTokenAccount,
+		destinationTokenAccount,
+		tokenProgram,
+	).Build()
+
+	return ix, nil
+}
+
+// SpendingLimitTransferTx creates a complete transaction transferring from a vault through the best spending limit
+// for the request, paid by the member
+func (s *Multisig) SpendingLimitTransferTx(ctx context.Context, request SpendingLimitRequest, memo *string, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.SpendingLimitTransferIx(ctx, request, memo)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, request.Member, opts)
+}
@@ -0,0 +1,132 @@
+package squads
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_SpendingLimitRemaining(t *testing.T) {
+	lastReset := int64(1700000000)
+	member, destination := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	spendingLimit := &squads_multisig_program.SpendingLimit{
+		Amount:          100,
+		RemainingAmount: 30,
+		Period:          squads_multisig_program.PeriodDay,
+		LastReset:       lastReset,
+		Members:         []solana.PublicKey{member},
+		Destinations:    []solana.PublicKey{destination},
+	}
+
+	at := func(seconds int64) time.Time { return time.Unix(lastReset+seconds, 0) }
+	if remaining := SpendingLimitRemaining(spendingLimit, at(SpendingLimitDaySeconds)); remaining != 30 {
+		t.Fatalf("expected 30 until a full day passed, got %d", remaining)
+	}
+	if remaining := SpendingLimitRemaining(spendingLimit, at(SpendingLimitDaySeconds+1)); remaining != 100 {
+		t.Fatalf("expected the amount to be reset, got %d", remaining)
+	}
+	if next := SpendingLimitNextReset(spendingLimit, at(3*SpendingLimitDaySeconds+5)); next.Unix() != lastReset+4*SpendingLimitDaySeconds+1 {
+		t.Fatalf("unexpected next reset %s", next)
+	}
+	oneTime := *spendingLimit
+	oneTime.Period = squads_multisig_program.PeriodOneTime
+	if remaining := SpendingLimitRemaining(&oneTime, at(365*SpendingLimitDaySeconds)); remaining != 30 {
+		t.Fatalf("one-time limits never reset, got %d", remaining)
+	}
+
+	request := SpendingLimitRequest{Member: member, Destination: destination, Amount: 50}
+	for _, test := range []struct {
+		name    string
+		request SpendingLimitRequest
+		at      time.Time
+		err     error
+	}{
+		{"allowed after reset", request, at(SpendingLimitDaySeconds + 1), nil},
+		{"exceeded", request, at(60), ErrSpendingLimitExceeded},
+		{"mint", SpendingLimitRequest{Member: member, Destination: destination, Mint: destination}, at(60), ErrInvalidMint},
+		{"member", SpendingLimitRequest{Member: destination, Destination: destination}, at(60), ErrUnauthorized},
+		{"destination", SpendingLimitRequest{Member: member, Destination: member}, at(60), ErrInvalidDestination},
+	} {
+		if err := CheckSpendingLimit(spendingLimit, test.request, test.at); !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func Test_SpendingLimitTransfer(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+	member, destination, mint := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	client.SetClock(100, 1700000000)
+
+	mintData := make([]byte, 82)
+	mintData[44], mintData[45] = 6, 1
+	client.SetAccount(mint, solana.Token2022ProgramID, 1_461_600, mintData)
+
+	oneTime, weekly, daily, other := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	for address, spendingLimit := range map[solana.PublicKey]squads_multisig_program.SpendingLimit{
+		oneTime: {Period: squads_multisig_program.PeriodOneTime, Amount: 1000, RemainingAmount: 1000, VaultIndex: 0},
+		weekly:  {Period: squads_multisig_program.PeriodWeek, Amount: 500, RemainingAmount: 500, LastReset: 1700000000 - 3600, VaultIndex: 2},
+		// exhausted on chain, but its day has passed
+		daily: {Period: squads_multisig_program.PeriodDay, Amount: 200, RemainingAmount: 0, LastReset: 1700000000 - SpendingLimitDaySeconds - 10, VaultIndex: 1},
+		// other members only
+		other: {Period: squads_multisig_program.PeriodDay, Amount: 200, RemainingAmount: 200, LastReset: 1700000000, Members: []solana.PublicKey{destination}},
+	} {
+		spendingLimit.Multisig, spendingLimit.Mint = multisigPda, mint
+		if spendingLimit.Members == nil {
+			spendingLimit.Members = []solana.PublicKey{member}
+		}
+		if err := client.SetBorshAccount(address, spendingLimit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request := SpendingLimitRequest{Member: member, Mint: mint, Destination: destination, Amount: 150}
+	selected, err := s.SelectSpendingLimit(t.Context(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selected.Address != daily {
+		t.Fatalf("expected the daily limit resetting first, got %s", selected.Address)
+	}
+	request.Amount = 800
+	if selected, err = s.SelectSpendingLimit(t.Context(), request); err != nil || selected.Address != oneTime {
+		t.Fatalf("expected the one-time limit, got %+v: %v", selected, err)
+	}
+	request.Amount = 5000
+	if _, err := s.SelectSpendingLimit(t.Context(), request); !errors.Is(err, ErrNoSpendingLimit) || !errors.Is(err, ErrSpendingLimitExceeded) {
+		t.Fatalf("expected ErrNoSpendingLimit, got %v", err)
+	}
+
+	request.Amount = 150
+	tx, err := s.SpendingLimitTransferTx(t.Context(), request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiled := tx.Message.Instructions[0]
+	accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := squads_multisig_program.DecodeInstruction(accounts, compiled.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	use, ok := decoded.Impl.(*squads_multisig_program.SpendingLimitUse)
+	if !ok || use.Args.Amount != 150 || use.Args.Decimals != 6 {
+		t.Fatalf("unexpected instruction %+v", decoded.Impl)
+	}
+	vaultPda, _ := GetVaultPda(multisigPda, 1)
+	vaultTokenAccount, _ := GetAssociatedTokenAddress(vaultPda, mint, solana.Token2022ProgramID)
+	destinationTokenAccount, _ := GetAssociatedTokenAddress(destination, mint, solana.Token2022ProgramID)
+	if use.GetSpendingLimitAccount().PublicKey != daily || use.GetVaultAccount().PublicKey != vaultPda ||
+		use.GetVaultTokenAccountAccount().PublicKey != vaultTokenAccount || use.GetDestinationTokenAccountAccount().PublicKey != destinationTokenAccount ||
+		use.GetTokenProgramAccount().PublicKey != solana.Token2022ProgramID {
+		t.Fatalf("unexpected accounts %+v", use.AccountMetaSlice)
+	}
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
 	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
 )
 
 // CreateMultisigIx returns a "create multisig wallet" instruction
@@ -22,7 +21,7 @@ import (
 // - Instruction for creating a multisig wallet
 // - Public key of the created multisig
 // - Error, if any
-func CreateMultisigIx(ctx context.Context, client *rpc.Client, createKey, creator solana.PublicKey, configAuthority *solana.PublicKey, members []squads_multisig_program.Member, threshold uint16, timelock uint32, rentCollector *solana.PublicKey) (solana.Instruction, solana.PublicKey, error) {
+func CreateMultisigIx(ctx context.Context, client RPCClient, createKey, creator solana.PublicKey, configAuthority *solana.PublicKey, members []squads_multisig_program.Member, threshold uint16, timelock uint32, rentCollector *solana.PublicKey) (solana.Instruction, solana.PublicKey, error) {
 	args := squads_multisig_program.MultisigCreateArgsV2{
 		ConfigAuthority: configAuthority,
 		Threshold:       threshold,
@@ -71,19 +70,11 @@ func CreateMultisigIx(ctx context.Context, client *rpc.Client, createKey, creato
 // - Transaction for creating a multisig wallet
 // - Public key of the created multisig
 // - Error, if any
-func CreateMultisigTx(ctx context.Context, client *rpc.Client, createKey, creator solana.PublicKey, configAuthority *solana.PublicKey, members []squads_multisig_program.Member, threshold uint16, timelock uint32, rentCollector *solana.PublicKey) (*solana.Transaction, solana.PublicKey, error) {
+func CreateMultisigTx(ctx context.Context, client RPCClient, createKey, creator solana.PublicKey, configAuthority *solana.PublicKey, members []squads_multisig_program.Member, threshold uint16, timelock uint32, rentCollector *solana.PublicKey, opts ...TxOption) (*solana.Transaction, solana.PublicKey, error) {
 	ix, multisigPda, err := CreateMultisigIx(ctx, client, createKey, creator, configAuthority, members, threshold, timelock, rentCollector)
 	if err != nil {
 		return nil, multisigPda, err
 	}
-	recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
-	if err != nil {
-		return nil, multisigPda, err
-	}
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{ix},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(creator),
-	)
+	tx, err := buildTransaction(ctx, client, []solana.Instruction{ix}, creator, opts)
 	return tx, multisigPda, err
 }
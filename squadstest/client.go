@@ -0,0 +1,246 @@
+// Package squadstest provides an in-memory RPC client for testing code built on the squads SDK.
+package squadstest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// AddressLookupTableProgramID owns the address lookup table accounts
+var AddressLookupTableProgramID = solana.MustPublicKeyFromBase58("AddressLookupTab1e1111111111111111111111111")
+
+// sysvarOwner owns the sysvar accounts
+var sysvarOwner = solana.MustPublicKeyFromBase58("Sysvar1111111111111111111111111111111111111")
+
+// Client is an in-memory implementation of squads.RPCClient.
+// Accounts are seeded with SetAccount or SetBorshAccount, unknown accounts return rpc.ErrNotFound.
+type Client struct {
+	mu                   sync.RWMutex
+	accounts             map[solana.PublicKey]*rpc.Account
+	blockhash            solana.Hash
+	lastValidBlockHeight uint64
+	slot                 uint64
+	blockHeight          uint64
+
+	sent     []*solana.Transaction
+	onSend   func(tx *solana.Transaction) error
+	simulate func(tx *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResult, error)
+	fees     func(accounts solana.PublicKeySlice) ([]rpc.PriorizationFeeResult, error)
+	statuses map[solana.Signature]*rpc.SignatureStatusesResult
+	logs     map[solana.Signature][]string
+}
+
+// NewClient creates an empty Client
+func NewClient() *Client {
+	return &Client{
+		accounts:             make(map[solana.PublicKey]*rpc.Account),
+		blockhash:            solana.HashFromBytes(bytes.Repeat([]byte{1}, 32)),
+		lastValidBlockHeight: 150,
+		slot:                 1,
+		blockHeight:          1,
+		statuses:             make(map[solana.Signature]*rpc.SignatureStatusesResult),
+		logs:                 make(map[solana.Signature][]string),
+	}
+}
+
+// SetAccount stores an account with raw data
+func (c *Client) SetAccount(address, owner solana.PublicKey, lamports uint64, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accounts[address] = &rpc.Account{
+		Lamports: lamports,
+		Owner:    owner,
+		Data:     rpc.DataBytesOrJSONFromBytes(data),
+	}
+}
+
+// SetBorshAccount stores a Borsh-encoded account owned by the multisig program,
+// such as a squads_multisig_program.Multisig or squads_multisig_program.Proposal.
+func (c *Client) SetBorshAccount(address solana.PublicKey, account interface{}) error {
+	buf := new(bytes.Buffer)
+	if err := ag_binary.NewBorshEncoder(buf).Encode(account); err != nil {
+		return err
+	}
+	c.SetAccount(address, squads_multisig_program.ProgramID, 1_000_000, buf.Bytes())
+	return nil
+}
+
+// SetNonceAccount stores an initialized durable nonce account
+func (c *Client) SetNonceAccount(address, authority solana.PublicKey, nonce solana.Hash) error {
+	buf := new(bytes.Buffer)
+	account := system.NonceAccount{
+		Version:          1,
+		State:            1,
+		AuthorizedPubkey: authority,
+		Nonce:            solana.PublicKey(nonce),
+		FeeCalculator:    system.FeeCalculator{LamportsPerSignature: 5000},
+	}
+	if err := account.MarshalWithEncoder(ag_binary.NewBinEncoder(buf)); err != nil {
+		return err
+	}
+	c.SetAccount(address, solana.SystemProgramID, 1_447_680, buf.Bytes())
+	return nil
+}
+
+// DeleteAccount removes an account
+func (c *Client) DeleteAccount(address solana.PublicKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.accounts, address)
+}
+
+// SetLatestBlockhash sets the blockhash returned by GetLatestBlockhash
+func (c *Client) SetLatestBlockhash(blockhash solana.Hash, lastValidBlockHeight uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blockhash = blockhash
+	c.lastValidBlockHeight = lastValidBlockHeight
+}
+
+// SetSlot sets the context slot of the responses
+func (c *Client) SetSlot(slot uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slot = slot
+}
+
+// SetClock stores the clock sysvar with the given slot and unix timestamp
+func (c *Client) SetClock(slot uint64, unixTimestamp int64) {
+	// slot u64, epoch_start_timestamp i64, epoch u64, leader_schedule_epoch u64, unix_timestamp i64
+	data := make([]byte, 40)
+	binary.LittleEndian.PutUint64(data[0:8], slot)
+	binary.LittleEndian.PutUint64(data[8:16], uint64(unixTimestamp))
+	binary.LittleEndian.PutUint64(data[32:40], uint64(unixTimestamp))
+	c.SetAccount(solana.SysVarClockPubkey, sysvarOwner, 1_169_280, data)
+}
+
+func (c *Client) rpcContext() rpc.RPCContext {
+	return rpc.RPCContext{Context: rpc.Context{Slot: c.slot}}
+}
+
+func (c *Client) GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	acc, ok := c.accounts[account]
+	if !ok {
+		return nil, rpc.ErrNotFound
+	}
+	return &rpc.GetAccountInfoResult{
+		RPCContext: c.rpcContext(),
+		Value:      acc,
+	}, nil
+}
+
+func (c *Client) GetAccountDataInto(ctx context.Context, account solana.PublicKey, inVar interface{}) error {
+	out, err := c.GetAccountInfo(ctx, account)
+	if err != nil {
+		return err
+	}
+	return ag_binary.NewBinDecoder(out.Value.Data.GetBinary()).Decode(inVar)
+}
+
+func (c *Client) GetMultipleAccounts(ctx context.Context, accounts ...solana.PublicKey) (*rpc.GetMultipleAccountsResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := &rpc.GetMultipleAccountsResult{
+		RPCContext: c.rpcContext(),
+		Value:      make([]*rpc.Account, len(accounts)),
+	}
+	for i, account := range accounts {
+		out.Value[i] = c.accounts[account]
+	}
+	return out, nil
+}
+
+func (c *Client) GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &rpc.GetLatestBlockhashResult{
+		RPCContext: c.rpcContext(),
+		Value: &rpc.LatestBlockhashResult{
+			Blockhash:            c.blockhash,
+			LastValidBlockHeight: c.lastValidBlockHeight,
+		},
+	}, nil
+}
+
+// GetProgramAccountsWithOpts returns the accounts owned by the program that match the data size and memcmp filters,
+// sorted by address. The data slice option is honoured.
+func (c *Client) GetProgramAccountsWithOpts(ctx context.Context, publicKey solana.PublicKey, opts *rpc.GetProgramAccountsOpts) (rpc.GetProgramAccountsResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var filters []rpc.RPCFilter
+	var dataSlice *rpc.DataSlice
+	if opts != nil {
+		filters = opts.Filters
+		dataSlice = opts.DataSlice
+	}
+	out := rpc.GetProgramAccountsResult{}
+	for address, account := range c.accounts {
+		if !account.Owner.Equals(publicKey) || !matchFilters(account.Data.GetBinary(), filters) {
+			continue
+		}
+		if dataSlice != nil {
+			sliced := *account
+			sliced.Data = rpc.DataBytesOrJSONFromBytes(sliceData(account.Data.GetBinary(), dataSlice))
+			account = &sliced
+		}
+		out = append(out, &rpc.KeyedAccount{Pubkey: address, Account: account})
+	}
+	slices.SortFunc(out, func(a, b *rpc.KeyedAccount) int {
+		return bytes.Compare(a.Pubkey[:], b.Pubkey[:])
+	})
+	return out, nil
+}
+
+func sliceData(data []byte, dataSlice *rpc.DataSlice) []byte {
+	offset, length := uint64(0), uint64(len(data))
+	if dataSlice.Offset != nil {
+		offset = min(*dataSlice.Offset, uint64(len(data)))
+	}
+	if dataSlice.Length != nil {
+		length = *dataSlice.Length
+	}
+	return slices.Clone(data[offset:min(offset+length, uint64(len(data)))])
+}
+
+func matchFilters(data []byte, filters []rpc.RPCFilter) bool {
+	for _, filter := range filters {
+		if filter.DataSize != 0 && uint64(len(data)) != filter.DataSize {
+			return false
+		}
+		if memcmp := filter.Memcmp; memcmp != nil {
+			end := memcmp.Offset + uint64(len(memcmp.Bytes))
+			if end > uint64(len(data)) || !bytes.Equal(data[memcmp.Offset:end], memcmp.Bytes) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SetAddressLookupTable stores an active address lookup table holding the given addresses
+func (c *Client) SetAddressLookupTable(address solana.PublicKey, addresses []solana.PublicKey) error {
+	state := addresslookuptable.AddressLookupTableState{
+		TypeIndex:        1,
+		DeactivationSlot: math.MaxUint64,
+		Addresses:        addresses,
+	}
+	buf := new(bytes.Buffer)
+	if err := state.MarshalWithEncoder(ag_binary.NewBinEncoder(buf)); err != nil {
+		return err
+	}
+	c.SetAccount(address, AddressLookupTableProgramID, 1_000_000, buf.Bytes())
+	return nil
+}
@@ -0,0 +1,152 @@
+package squadstest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// SendTransactionWithOpts verifies the signatures of the transaction and records it, see SentTransactions.
+// The transaction is not executed, accounts are left unchanged: use OnSend to react to it, e.g. with
+// SetSignatureStatus.
+func (c *Client) SendTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts rpc.TransactionOpts) (solana.Signature, error) {
+	if len(transaction.Signatures) != int(transaction.Message.Header.NumRequiredSignatures) {
+		return solana.Signature{}, fmt.Errorf("transaction has %d signatures, %d required", len(transaction.Signatures), transaction.Message.Header.NumRequiredSignatures)
+	}
+	if err := transaction.VerifySignatures(); err != nil {
+		return solana.Signature{}, err
+	}
+	c.mu.Lock()
+	c.sent = append(c.sent, transaction)
+	onSend := c.onSend
+	c.mu.Unlock()
+	if onSend != nil {
+		if err := onSend(transaction); err != nil {
+			return solana.Signature{}, err
+		}
+	}
+	return transaction.Signatures[0], nil
+}
+
+// SentTransactions returns the transactions sent so far, in order
+func (c *Client) SentTransactions() []*solana.Transaction {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.sent)
+}
+
+// OnSend sets a function called with every transaction sent, an error it returns is returned by the send
+func (c *Client) OnSend(fn func(tx *solana.Transaction) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onSend = fn
+}
+
+// SetSignatureStatus sets the status returned by GetSignatureStatuses for a signature, nil removes it
+func (c *Client) SetSignatureStatus(signature solana.Signature, status *rpc.SignatureStatusesResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if status == nil {
+		delete(c.statuses, signature)
+		return
+	}
+	c.statuses[signature] = status
+}
+
+// GetSignatureStatuses returns the statuses set with SetSignatureStatus, nil for unknown signatures
+func (c *Client) GetSignatureStatuses(ctx context.Context, searchTransactionHistory bool, transactionSignatures ...solana.Signature) (*rpc.GetSignatureStatusesResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := &rpc.GetSignatureStatusesResult{
+		RPCContext: c.rpcContext(),
+		Value:      make([]*rpc.SignatureStatusesResult, len(transactionSignatures)),
+	}
+	for i, signature := range transactionSignatures {
+		out.Value[i] = c.statuses[signature]
+	}
+	return out, nil
+}
+
+// SetTransactionLogs sets the logs returned by GetTransaction for a signature, nil removes them
+func (c *Client) SetTransactionLogs(signature solana.Signature, logs []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if logs == nil {
+		delete(c.logs, signature)
+		return
+	}
+	c.logs[signature] = logs
+}
+
+// GetTransaction returns the logs set with SetTransactionLogs, rpc.ErrNotFound for other signatures
+func (c *Client) GetTransaction(ctx context.Context, txSig solana.Signature, opts *rpc.GetTransactionOpts) (*rpc.GetTransactionResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	logs, ok := c.logs[txSig]
+	if !ok {
+		return nil, rpc.ErrNotFound
+	}
+	out := &rpc.GetTransactionResult{Meta: &rpc.TransactionMeta{LogMessages: logs}}
+	if status := c.statuses[txSig]; status != nil {
+		out.Slot = status.Slot
+	}
+	return out, nil
+}
+
+// SetBlockHeight sets the height returned by GetBlockHeight
+func (c *Client) SetBlockHeight(height uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blockHeight = height
+}
+
+func (c *Client) GetBlockHeight(ctx context.Context, commitment rpc.CommitmentType) (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.blockHeight, nil
+}
+
+// OnSimulate sets the function answering SimulateTransactionWithOpts.
+// Without it every simulation succeeds without logs.
+func (c *Client) OnSimulate(fn func(tx *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResult, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.simulate = fn
+}
+
+func (c *Client) SimulateTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResponse, error) {
+	c.mu.RLock()
+	simulate := c.simulate
+	rpcContext := c.rpcContext()
+	c.mu.RUnlock()
+
+	result := &rpc.SimulateTransactionResult{}
+	if simulate != nil {
+		var err error
+		if result, err = simulate(transaction, opts); err != nil {
+			return nil, err
+		}
+	}
+	return &rpc.SimulateTransactionResponse{RPCContext: rpcContext, Value: result}, nil
+}
+
+// OnPrioritizationFees sets the function answering GetRecentPrioritizationFees.
+// Without it no fee was recently paid.
+func (c *Client) OnPrioritizationFees(fn func(accounts solana.PublicKeySlice) ([]rpc.PriorizationFeeResult, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fees = fn
+}
+
+func (c *Client) GetRecentPrioritizationFees(ctx context.Context, accounts solana.PublicKeySlice) ([]rpc.PriorizationFeeResult, error) {
+	c.mu.RLock()
+	fees := c.fees
+	c.mu.RUnlock()
+	if fees == nil {
+		return nil, nil
+	}
+	return fees(accounts)
+}
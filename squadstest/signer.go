@@ -0,0 +1,97 @@
+package squadstest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// SignerServer is a local HTTP signing service for testing squads.RemoteSigner.
+// It signs with the keys it was created with and answers with an error for any other key.
+type SignerServer struct {
+	server *httptest.Server
+	keys   map[solana.PublicKey]solana.PrivateKey
+
+	mu       sync.Mutex
+	requests int
+	header   http.Header
+}
+
+// NewSignerServer starts a signing service holding keys
+func NewSignerServer(keys ...solana.PrivateKey) *SignerServer {
+	s := &SignerServer{keys: make(map[solana.PublicKey]solana.PrivateKey, len(keys))}
+	for _, key := range keys {
+		s.keys[key.PublicKey()] = key
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL returns the endpoint of the service
+func (s *SignerServer) URL() string {
+	return s.server.URL
+}
+
+// Close stops the service
+func (s *SignerServer) Close() {
+	s.server.Close()
+}
+
+// Requests returns the number of signing requests received
+func (s *SignerServer) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// LastHeader returns the headers of the last request
+func (s *SignerServer) LastHeader() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.header.Clone()
+}
+
+func (s *SignerServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.header = r.Header.Clone()
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	fail := func(status int, msg string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	}
+	if r.Method != http.MethodPost {
+		fail(http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		PublicKey solana.PublicKey `json:"publicKey"`
+		Message   string           `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	message, err := base64.StdEncoding.DecodeString(req.Message)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	key, ok := s.keys[req.PublicKey]
+	if !ok {
+		fail(http.StatusForbidden, "unknown key "+req.PublicKey.String())
+		return
+	}
+	signature, err := key.Sign(message)
+	if err != nil {
+		fail(http.StatusInternalServerError, err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]solana.Signature{"signature": signature})
+}
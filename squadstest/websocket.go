@@ -0,0 +1,297 @@
+package squadstest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gorilla/websocket"
+)
+
+// WebsocketServer is a local stand-in for the websocket endpoint of a Solana RPC node.
+// It serves accountSubscribe and programSubscribe from the accounts of a Client:
+// Publish sends the current state of accounts to the matching subscriptions.
+// signatureSubscribe is answered by PublishSignature.
+type WebsocketServer struct {
+	client   *Client
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu     sync.Mutex
+	conns  map[*wsConn]struct{}
+	subs   map[uint64]*wsSubscription
+	nextID uint64
+}
+
+type wsConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *wsConn) writeJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+type wsSubscription struct {
+	conn    *wsConn
+	method  string
+	address solana.PublicKey
+	filters []rpc.RPCFilter
+	// signature is watched by signatureSubscribe
+	signature solana.Signature
+}
+
+type wsRequest struct {
+	ID     uint64            `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// NewWebsocketServer starts a websocket server backed by the accounts of client
+func NewWebsocketServer(client *Client) *WebsocketServer {
+	s := &WebsocketServer{
+		client: client,
+		conns:  make(map[*wsConn]struct{}),
+		subs:   make(map[uint64]*wsSubscription),
+		nextID: 1,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL returns the ws:// endpoint of the server
+func (s *WebsocketServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+// Close drops every connection and stops the server
+func (s *WebsocketServer) Close() {
+	s.DropConnections()
+	s.server.Close()
+}
+
+// DropConnections closes every open connection, as a node restart or a network failure would
+func (s *WebsocketServer) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.conn.Close()
+	}
+	s.conns = make(map[*wsConn]struct{})
+	s.subs = make(map[uint64]*wsSubscription)
+}
+
+// Subscriptions returns the number of active subscriptions
+func (s *WebsocketServer) Subscriptions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs)
+}
+
+// WaitForSubscriptions blocks until at least n subscriptions are active
+func (s *WebsocketServer) WaitForSubscriptions(ctx context.Context, n int) error {
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for s.Subscriptions() < n {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %d subscriptions, have %d: %w", n, s.Subscriptions(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Publish notifies the subscriptions watching the given accounts of their current state.
+// Deleted accounts are published to account subscriptions as empty accounts owned by the system program.
+func (s *WebsocketServer) Publish(addresses ...solana.PublicKey) error {
+	s.client.mu.RLock()
+	slot := s.client.slot
+	accounts := make([]*rpc.Account, len(addresses))
+	for i, address := range addresses {
+		accounts[i] = s.client.accounts[address]
+	}
+	s.client.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, address := range addresses {
+		account := accounts[i]
+		for id, sub := range s.subs {
+			var value interface{}
+			switch {
+			case sub.method == "accountSubscribe" && sub.address.Equals(address):
+				if account == nil {
+					account = &rpc.Account{Owner: solana.SystemProgramID, Data: rpc.DataBytesOrJSONFromBytes(nil)}
+				}
+				value = account
+			case sub.method == "programSubscribe" && account != nil && account.Owner.Equals(sub.address) &&
+				matchFilters(account.Data.GetBinary(), sub.filters):
+				value = rpc.KeyedAccount{Pubkey: address, Account: account}
+			default:
+				continue
+			}
+			notification := map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  strings.TrimSuffix(sub.method, "Subscribe") + "Notification",
+				"params": map[string]interface{}{
+					"subscription": id,
+					"result": map[string]interface{}{
+						"context": map[string]interface{}{"slot": slot},
+						"value":   value,
+					},
+				},
+			}
+			if err := sub.conn.writeJSON(notification); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PublishSignature notifies the subscriptions watching signature that the transaction landed,
+// with txErr as its error (nil on success). The subscriptions are removed, as a node does.
+func (s *WebsocketServer) PublishSignature(signature solana.Signature, txErr interface{}) error {
+	s.client.mu.RLock()
+	slot := s.client.slot
+	s.client.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sub := range s.subs {
+		if sub.method != "signatureSubscribe" || sub.signature != signature {
+			continue
+		}
+		delete(s.subs, id)
+		notification := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "signatureNotification",
+			"params": map[string]interface{}{
+				"subscription": id,
+				"result": map[string]interface{}{
+					"context": map[string]interface{}{"slot": slot},
+					"value":   map[string]interface{}{"err": txErr},
+				},
+			},
+		}
+		if err := sub.conn.writeJSON(notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WebsocketServer) serve(w http.ResponseWriter, r *http.Request) {
+	c, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn := &wsConn{conn: c}
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.conns, conn)
+		for id, sub := range s.subs {
+			if sub.conn == conn {
+				delete(s.subs, id)
+			}
+		}
+		c.Close()
+	}()
+
+	for {
+		var req wsRequest
+		if err := c.ReadJSON(&req); err != nil {
+			return
+		}
+		result, err := s.handle(conn, req)
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if err != nil {
+			response["error"] = map[string]interface{}{"code": -32602, "message": err.Error()}
+		} else {
+			response["result"] = result
+		}
+		if err := conn.writeJSON(response); err != nil {
+			return
+		}
+	}
+}
+
+func (s *WebsocketServer) handle(conn *wsConn, req wsRequest) (interface{}, error) {
+	switch req.Method {
+	case "accountSubscribe", "programSubscribe":
+		if len(req.Params) == 0 {
+			return nil, fmt.Errorf("missing params")
+		}
+		sub := &wsSubscription{conn: conn, method: req.Method}
+		var address string
+		if err := json.Unmarshal(req.Params[0], &address); err != nil {
+			return nil, err
+		}
+		var err error
+		if sub.address, err = solana.PublicKeyFromBase58(address); err != nil {
+			return nil, err
+		}
+		if len(req.Params) > 1 {
+			var conf struct {
+				Filters []rpc.RPCFilter `json:"filters"`
+			}
+			if err := json.Unmarshal(req.Params[1], &conf); err != nil {
+				return nil, err
+			}
+			sub.filters = conf.Filters
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		id := s.nextID
+		s.nextID++
+		s.subs[id] = sub
+		return id, nil
+	case "signatureSubscribe":
+		if len(req.Params) == 0 {
+			return nil, fmt.Errorf("missing params")
+		}
+		var signature string
+		if err := json.Unmarshal(req.Params[0], &signature); err != nil {
+			return nil, err
+		}
+		sub := &wsSubscription{conn: conn, method: req.Method}
+		var err error
+		if sub.signature, err = solana.SignatureFromBase58(signature); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		id := s.nextID
+		s.nextID++
+		s.subs[id] = sub
+		return id, nil
+	case "accountUnsubscribe", "programUnsubscribe", "signatureUnsubscribe":
+		var id uint64
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params[0], &id); err != nil {
+				return nil, err
+			}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_, ok := s.subs[id]
+		delete(s.subs, id)
+		return ok, nil
+	}
+	return nil, fmt.Errorf("method %s not supported", req.Method)
+}
@@ -0,0 +1,336 @@
+package squads
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/treeout"
+)
+
+// AddressLabels maps well known addresses to the names shown in summaries, e.g. "vault 0"
+type AddressLabels map[solana.PublicKey]string
+
+// Label returns the label of the address, or the address itself
+func (l AddressLabels) Label(address solana.PublicKey) string {
+	if label, ok := l[address]; ok {
+		return label
+	}
+	return address.String()
+}
+
+// InstructionSummary is a human-readable description of an instruction or of a config action
+type InstructionSummary struct {
+	ProgramID   solana.PublicKey `json:"programId"`
+	Program     string           `json:"program"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	// Accounts and Data are set for instructions no decoder understood
+	Accounts []string `json:"accounts,omitempty"`
+	Data     string   `json:"data,omitempty"`
+	// Error is the decoding error, if a registered decoder failed
+	Error string `json:"error,omitempty"`
+}
+
+func (is *InstructionSummary) String() string {
+	if is.Description == "" {
+		return fmt.Sprintf("%s: %s", is.Program, is.Name)
+	}
+	return fmt.Sprintf("%s %s: %s", is.Program, is.Name, is.Description)
+}
+
+// InstructionDecoder decodes an instruction of a program into a summary.
+// Addresses in the description should be rendered with labels.Label.
+type InstructionDecoder func(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error)
+
+// TokenInfo describes a token mint for summaries
+type TokenInfo struct {
+	Symbol   string
+	Decimals uint8
+}
+
+// DecoderRegistry holds the instruction decoders and the known token mints used to summarize transactions
+type DecoderRegistry struct {
+	mu       sync.RWMutex
+	decoders map[solana.PublicKey]InstructionDecoder
+	tokens   map[solana.PublicKey]TokenInfo
+}
+
+// DefaultDecoderRegistry is used by summaries when no registry is given
+var DefaultDecoderRegistry = NewDecoderRegistry()
+
+// NewDecoderRegistry creates a registry with decoders for the System, SPL Token, Token-2022, Associated Token
+// Account, Memo, Compute Budget, BPF Upgradeable Loader and multisig programs
+func NewDecoderRegistry() *DecoderRegistry {
+	r := &DecoderRegistry{
+		decoders: make(map[solana.PublicKey]InstructionDecoder),
+		tokens:   make(map[solana.PublicKey]TokenInfo),
+	}
+	r.Register(solana.SystemProgramID, decodeSystemInstruction)
+	r.Register(solana.TokenProgramID, r.tokenDecoder("SPL Token"))
+	r.Register(solana.Token2022ProgramID, r.tokenDecoder("Token-2022"))
+	r.Register(solana.SPLAssociatedTokenAccountProgramID, r.decodeAssociatedTokenAccountInstruction)
+	r.Register(solana.MemoProgramID, decodeMemoInstruction)
+	r.Register(memoV1ProgramID, decodeMemoInstruction)
+	r.Register(solana.ComputeBudget, decodeComputeBudgetInstruction)
+	r.Register(solana.BPFLoaderUpgradeableProgramID, decodeUpgradeableLoaderInstruction)
+
+	r.RegisterToken(solana.WrappedSol, "wSOL", 9)
+	r.RegisterToken(solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"), "USDC", 6)
+	r.RegisterToken(solana.MustPublicKeyFromBase58("Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB"), "USDT", 6)
+	return r
+}
+
+// Register sets the decoder of a program, replacing any previous one
+func (r *DecoderRegistry) Register(programID solana.PublicKey, decoder InstructionDecoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decoders[programID] = decoder
+}
+
+// RegisterToken sets the symbol and decimals used to format amounts of a mint
+func (r *DecoderRegistry) RegisterToken(mint solana.PublicKey, symbol string, decimals uint8) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[mint] = TokenInfo{Symbol: symbol, Decimals: decimals}
+}
+
+// Token returns the registered info of a mint
+func (r *DecoderRegistry) Token(mint solana.PublicKey) (TokenInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.tokens[mint]
+	return info, ok
+}
+
+// Decode summarizes an instruction. It never fails: instructions of unknown programs, or that the registered
+// decoder rejects, are summarized with their raw accounts and data.
+func (r *DecoderRegistry) Decode(ix solana.Instruction, labels AddressLabels) *InstructionSummary {
+	r.mu.RLock()
+	decoder, ok := r.decoders[ix.ProgramID()]
+	r.mu.RUnlock()
+	// the multisig program id can be changed with SetProgramID, so it is resolved at decode time
+	if !ok && ix.ProgramID().Equals(squads_multisig_program.ProgramID) {
+		decoder, ok = decodeMultisigInstruction, true
+	}
+
+	var decodeErr error
+	if ok {
+		summary, err := decoder(ix, labels)
+		if err == nil {
+			summary.ProgramID = ix.ProgramID()
+			return summary
+		}
+		decodeErr = err
+	}
+
+	data, _ := ix.Data()
+	summary := &InstructionSummary{
+		ProgramID: ix.ProgramID(),
+		Program:   labels.Label(ix.ProgramID()),
+		Name:      "Unknown",
+		Data:      hex.EncodeToString(data),
+	}
+	for _, meta := range ix.Accounts() {
+		summary.Accounts = append(summary.Accounts, accountFlags(meta, labels))
+	}
+	if decodeErr != nil {
+		summary.Error = decodeErr.Error()
+	}
+	return summary
+}
+
+func accountFlags(meta *solana.AccountMeta, labels AddressLabels) string {
+	var flags []string
+	if meta.IsWritable {
+		flags = append(flags, "writable")
+	}
+	if meta.IsSigner {
+		flags = append(flags, "signer")
+	}
+	if len(flags) == 0 {
+		return labels.Label(meta.PublicKey)
+	}
+	return fmt.Sprintf("%s [%s]", labels.Label(meta.PublicKey), strings.Join(flags, ", "))
+}
+
+// SummaryFormat is the output format of a TransactionSummary
+type SummaryFormat string
+
+const (
+	SummaryFormatText SummaryFormat = "text"
+	SummaryFormatJSON SummaryFormat = "json"
+)
+
+// TransactionSummary is a human-readable description of a vault or config transaction
+type TransactionSummary struct {
+	Multisig         solana.PublicKey `json:"multisig"`
+	TransactionIndex uint64           `json:"transactionIndex"`
+	// Kind is "vault" or "config"
+	Kind       string            `json:"kind"`
+	Creator    solana.PublicKey  `json:"creator"`
+	VaultIndex *uint8            `json:"vaultIndex,omitempty"`
+	Vault      *solana.PublicKey `json:"vault,omitempty"`
+	// Instructions are the instructions executed by a vault transaction
+	Instructions []*InstructionSummary `json:"instructions,omitempty"`
+	// Actions are the actions applied by a config transaction
+	Actions []*InstructionSummary `json:"actions,omitempty"`
+}
+
+// EncodeToTree renders the summary as a tree
+func (ts *TransactionSummary) EncodeToTree(parent treeout.Branches) {
+	parent.Child(fmt.Sprintf("Multisig: %s", ts.Multisig))
+	parent.Child(fmt.Sprintf("Creator: %s", ts.Creator))
+	if ts.VaultIndex != nil && ts.Vault != nil {
+		parent.Child(fmt.Sprintf("Vault: %d (%s)", *ts.VaultIndex, ts.Vault))
+	}
+	encodeSummaries := func(title string, summaries []*InstructionSummary) {
+		parent.Child(title).ParentFunc(func(branch treeout.Branches) {
+			for i, summary := range summaries {
+				child := branch.Child(fmt.Sprintf("[%d] %s", i, summary))
+				if summary.Error != "" {
+					child.Child(fmt.Sprintf("Error: %s", summary.Error))
+				}
+				if len(summary.Accounts) > 0 {
+					child.Child("Accounts").ParentFunc(func(accounts treeout.Branches) {
+						for j, account := range summary.Accounts {
+							accounts.Child(fmt.Sprintf("[%d] %s", j, account))
+						}
+					})
+				}
+				if summary.Data != "" {
+					child.Child(fmt.Sprintf("Data: %s", summary.Data))
+				}
+			}
+		})
+	}
+	if ts.Kind == "config" {
+		encodeSummaries("Actions", ts.Actions)
+	} else {
+		encodeSummaries("Instructions", ts.Instructions)
+	}
+}
+
+// String renders the summary as a text tree
+func (ts *TransactionSummary) String() string {
+	title := "Vault transaction"
+	if ts.Kind == "config" {
+		title = "Config transaction"
+	}
+	tree := treeout.New(fmt.Sprintf("%s #%d", title, ts.TransactionIndex))
+	ts.EncodeToTree(tree)
+	return tree.String()
+}
+
+// Render writes the summary to w as a text tree or as indented JSON
+func (ts *TransactionSummary) Render(w io.Writer, format SummaryFormat) error {
+	switch format {
+	case SummaryFormatText, "":
+		_, err := io.WriteString(w, ts.String())
+		return err
+	case SummaryFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(ts)
+	default:
+		return fmt.Errorf("unknown summary format %q", format)
+	}
+}
+
+// transactionLabels labels the accounts owned by the multisig for a transaction
+func (s *Multisig) transactionLabels(transactionPda solana.PublicKey, vaultIndex uint8, ephemeralSigners uint8) (AddressLabels, error) {
+	labels := AddressLabels{
+		s.multisigPda:                     "multisig",
+		transactionPda:                    "transaction",
+		squads_multisig_program.ProgramID: "Squads",
+	}
+	vaultPda, err := GetVaultPda(s.multisigPda, vaultIndex)
+	if err != nil {
+		return nil, err
+	}
+	labels[vaultPda] = fmt.Sprintf("vault %d", vaultIndex)
+	ephemeralSignerPdas, err := GetEphemeralSignerPdas(transactionPda, ephemeralSigners)
+	if err != nil {
+		return nil, err
+	}
+	for i, pda := range ephemeralSignerPdas {
+		labels[pda] = fmt.Sprintf("ephemeral signer %d", i)
+	}
+	return labels, nil
+}
+
+// VaultTransactionSummary fetches a vault transaction and describes the instructions it executes.
+// A nil registry uses DefaultDecoderRegistry.
+func (s *Multisig) VaultTransactionSummary(ctx context.Context, transactionIndex uint64, registry *DecoderRegistry) (*TransactionSummary, error) {
+	if registry == nil {
+		registry = DefaultDecoderRegistry
+	}
+	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	vaultTransaction, err := s.VaultTransactionAccount(ctx, transactionPda)
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := s.VaultTransactionMessageInstructions(ctx, vaultTransaction.Message)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := s.transactionLabels(transactionPda, vaultTransaction.VaultIndex, uint8(len(vaultTransaction.EphemeralSignerBumps)))
+	if err != nil {
+		return nil, err
+	}
+	vaultPda, err := GetVaultPda(s.multisigPda, vaultTransaction.VaultIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &TransactionSummary{
+		Multisig:         s.multisigPda,
+		TransactionIndex: transactionIndex,
+		Kind:             "vault",
+		Creator:          vaultTransaction.Creator,
+		VaultIndex:       &vaultTransaction.VaultIndex,
+		Vault:            &vaultPda,
+		Instructions:     make([]*InstructionSummary, 0, len(instructions)),
+	}
+	for _, ix := range instructions {
+		summary.Instructions = append(summary.Instructions, registry.Decode(ix, labels))
+	}
+	return summary, nil
+}
+
+// ConfigTransactionSummary fetches a config transaction and describes the actions it applies
+func (s *Multisig) ConfigTransactionSummary(ctx context.Context, transactionIndex uint64, registry *DecoderRegistry) (*TransactionSummary, error) {
+	if registry == nil {
+		registry = DefaultDecoderRegistry
+	}
+	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	configTransaction, err := s.ConfigTransactionAccount(ctx, transactionPda)
+	if err != nil {
+		return nil, err
+	}
+	labels := AddressLabels{s.multisigPda: "multisig"}
+
+	summary := &TransactionSummary{
+		Multisig:         s.multisigPda,
+		TransactionIndex: transactionIndex,
+		Kind:             "config",
+		Creator:          configTransaction.Creator,
+		Actions:          make([]*InstructionSummary, 0, len(configTransaction.Actions)),
+	}
+	for _, action := range configTransaction.Actions {
+		summary.Actions = append(summary.Actions, registry.DescribeConfigAction(action, labels))
+	}
+	return summary, nil
+}
@@ -0,0 +1,423 @@
+package squads
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+var memoV1ProgramID = solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
+
+// formatAmount formats a raw token amount with its decimals and thousands separators, e.g. 1000000000 with
+// 6 decimals is "1,000"
+func formatAmount(amount uint64, decimals uint8) string {
+	digits := strconv.FormatUint(amount, 10)
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	if fraction == "" {
+		return grouped.String()
+	}
+	return grouped.String() + "." + fraction
+}
+
+// formatSol formats lamports as SOL, e.g. "12.5 SOL"
+func formatSol(lamports uint64) string {
+	return formatAmount(lamports, 9) + " SOL"
+}
+
+// accountLabel returns the label of the account at index i of the instruction
+func accountLabel(ix solana.Instruction, i int, labels AddressLabels) string {
+	accounts := ix.Accounts()
+	if i >= len(accounts) {
+		return "<missing account>"
+	}
+	return labels.Label(accounts[i].PublicKey)
+}
+
+func accountKey(ix solana.Instruction, i int) solana.PublicKey {
+	accounts := ix.Accounts()
+	if i >= len(accounts) {
+		return solana.PublicKey{}
+	}
+	return accounts[i].PublicKey
+}
+
+func decodeSystemInstruction(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error) {
+	data, err := ix.Data()
+	if err != nil {
+		return nil, err
+	}
+	inst, err := system.DecodeInstruction(ix.Accounts(), data)
+	if err != nil {
+		return nil, err
+	}
+	summary := &InstructionSummary{
+		Program: "System",
+		Name:    system.InstructionIDToName(inst.TypeID.Uint32()),
+	}
+	switch impl := inst.Impl.(type) {
+	case *system.Transfer:
+		summary.Description = fmt.Sprintf("transfer %s from %s to %s", formatSol(*impl.Lamports), accountLabel(ix, 0, labels), accountLabel(ix, 1, labels))
+	case *system.TransferWithSeed:
+		summary.Description = fmt.Sprintf("transfer %s from %s to %s", formatSol(*impl.Lamports), accountLabel(ix, 0, labels), accountLabel(ix, 2, labels))
+	case *system.CreateAccount:
+		summary.Description = fmt.Sprintf("create account %s with %s and %d bytes owned by %s, funded by %s",
+			accountLabel(ix, 1, labels), formatSol(*impl.Lamports), *impl.Space, labels.Label(*impl.Owner), accountLabel(ix, 0, labels))
+	case *system.CreateAccountWithSeed:
+		summary.Description = fmt.Sprintf("create account %s with %s and %d bytes owned by %s, funded by %s",
+			accountLabel(ix, 1, labels), formatSol(*impl.Lamports), *impl.Space, labels.Label(*impl.Owner), accountLabel(ix, 0, labels))
+	case *system.Assign:
+		summary.Description = fmt.Sprintf("assign %s to %s", accountLabel(ix, 0, labels), labels.Label(*impl.Owner))
+	case *system.Allocate:
+		summary.Description = fmt.Sprintf("allocate %d bytes for %s", *impl.Space, accountLabel(ix, 0, labels))
+	case *system.AdvanceNonceAccount:
+		summary.Description = fmt.Sprintf("advance nonce account %s", accountLabel(ix, 0, labels))
+	case *system.WithdrawNonceAccount:
+		summary.Description = fmt.Sprintf("withdraw %s from nonce account %s to %s", formatSol(*impl.Lamports), accountLabel(ix, 0, labels), accountLabel(ix, 1, labels))
+	}
+	return summary, nil
+}
+
+// tokenDecoder decodes SPL Token instructions. Token-2022 shares the layout of the base instructions,
+// its extension instructions are summarized by name only.
+func (r *DecoderRegistry) tokenDecoder(program string) InstructionDecoder {
+	return func(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error) {
+		data, err := ix.Data()
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("empty instruction data")
+		}
+		inst, err := token.DecodeInstruction(ix.Accounts(), data)
+		if err != nil {
+			return &InstructionSummary{
+				Program: program,
+				Name:    fmt.Sprintf("Instruction%d", data[0]),
+			}, nil
+		}
+		summary := &InstructionSummary{
+			Program: program,
+			Name:    token.InstructionIDToName(inst.TypeID.Uint8()),
+		}
+
+		// amount formats a token amount, with the symbol of the mint when it is known
+		amount := func(amount uint64, decimals *uint8, mint *solana.PublicKey) string {
+			if mint != nil {
+				if info, ok := r.Token(*mint); ok {
+					return formatAmount(amount, info.Decimals) + " " + info.Symbol
+				}
+			}
+			if decimals != nil {
+				formatted := formatAmount(amount, *decimals)
+				if mint != nil {
+					formatted += " of mint " + labels.Label(*mint)
+				}
+				return formatted
+			}
+			return fmt.Sprintf("%d base units", amount)
+		}
+		mintAt := func(i int) *solana.PublicKey {
+			mint := accountKey(ix, i)
+			return &mint
+		}
+
+		switch impl := inst.Impl.(type) {
+		case *token.Transfer:
+			summary.Description = fmt.Sprintf("transfer %s from %s to %s", amount(*impl.Amount, nil, nil), accountLabel(ix, 0, labels), accountLabel(ix, 1, labels))
+		case *token.TransferChecked:
+			summary.Description = fmt.Sprintf("transfer %s from %s to %s", amount(*impl.Amount, impl.Decimals, mintAt(1)), accountLabel(ix, 0, labels), accountLabel(ix, 2, labels))
+		case *token.MintTo:
+			summary.Description = fmt.Sprintf("mint %s to %s", amount(*impl.Amount, nil, mintAt(0)), accountLabel(ix, 1, labels))
+		case *token.MintToChecked:
+			summary.Description = fmt.Sprintf("mint %s to %s", amount(*impl.Amount, impl.Decimals, mintAt(0)), accountLabel(ix, 1, labels))
+		case *token.Burn:
+			summary.Description = fmt.Sprintf("burn %s from %s", amount(*impl.Amount, nil, mintAt(1)), accountLabel(ix, 0, labels))
+		case *token.BurnChecked:
+			summary.Description = fmt.Sprintf("burn %s from %s", amount(*impl.Amount, impl.Decimals, mintAt(1)), accountLabel(ix, 0, labels))
+		case *token.Approve:
+			summary.Description = fmt.Sprintf("approve %s to spend %s from %s", accountLabel(ix, 1, labels), amount(*impl.Amount, nil, nil), accountLabel(ix, 0, labels))
+		case *token.ApproveChecked:
+			summary.Description = fmt.Sprintf("approve %s to spend %s from %s", accountLabel(ix, 2, labels), amount(*impl.Amount, impl.Decimals, mintAt(1)), accountLabel(ix, 0, labels))
+		case *token.Revoke:
+			summary.Description = fmt.Sprintf("revoke the delegate of %s", accountLabel(ix, 0, labels))
+		case *token.SetAuthority:
+			newAuthority := "none"
+			if impl.NewAuthority != nil {
+				newAuthority = labels.Label(*impl.NewAuthority)
+			}
+			summary.Description = fmt.Sprintf("set %s authority of %s to %s", tokenAuthorityTypeName(impl.AuthorityType), accountLabel(ix, 0, labels), newAuthority)
+		case *token.CloseAccount:
+			summary.Description = fmt.Sprintf("close token account %s, rent to %s", accountLabel(ix, 0, labels), accountLabel(ix, 1, labels))
+		case *token.FreezeAccount:
+			summary.Description = fmt.Sprintf("freeze token account %s", accountLabel(ix, 0, labels))
+		case *token.ThawAccount:
+			summary.Description = fmt.Sprintf("thaw token account %s", accountLabel(ix, 0, labels))
+		case *token.InitializeAccount:
+			summary.Description = fmt.Sprintf("initialize token account %s of mint %s for %s", accountLabel(ix, 0, labels), accountLabel(ix, 1, labels), accountLabel(ix, 2, labels))
+		case *token.InitializeAccount2:
+			summary.Description = fmt.Sprintf("initialize token account %s of mint %s for %s", accountLabel(ix, 0, labels), accountLabel(ix, 1, labels), labels.Label(*impl.Owner))
+		case *token.InitializeAccount3:
+			summary.Description = fmt.Sprintf("initialize token account %s of mint %s for %s", accountLabel(ix, 0, labels), accountLabel(ix, 1, labels), labels.Label(*impl.Owner))
+		case *token.SyncNative:
+			summary.Description = fmt.Sprintf("sync native balance of %s", accountLabel(ix, 0, labels))
+		}
+		return summary, nil
+	}
+}
+
+func tokenAuthorityTypeName(authorityType *token.AuthorityType) string {
+	if authorityType == nil {
+		return "unknown"
+	}
+	switch *authorityType {
+	case token.AuthorityMintTokens:
+		return "mint tokens"
+	case token.AuthorityFreezeAccount:
+		return "freeze account"
+	case token.AuthorityAccountOwner:
+		return "account owner"
+	case token.AuthorityCloseAccount:
+		return "close account"
+	}
+	return fmt.Sprintf("type %d", *authorityType)
+}
+
+// decodeAssociatedTokenAccountInstruction decodes instructions of the Associated Token Account program.
+// Accounts: [payer, associated token account, wallet, mint, system program, token program].
+func (r *DecoderRegistry) decodeAssociatedTokenAccountInstruction(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error) {
+	data, err := ix.Data()
+	if err != nil {
+		return nil, err
+	}
+	summary := &InstructionSummary{Program: "Associated Token Account"}
+	mint := accountLabel(ix, 3, labels)
+	if info, ok := r.Token(accountKey(ix, 3)); ok {
+		mint = info.Symbol
+	}
+	switch {
+	case len(data) == 0 || data[0] == 0:
+		summary.Name = "Create"
+	case data[0] == 1:
+		summary.Name = "CreateIdempotent"
+	case data[0] == 2:
+		summary.Name = "RecoverNested"
+		summary.Description = fmt.Sprintf("recover nested token account %s of %s into %s", accountLabel(ix, 0, labels), accountLabel(ix, 5, labels), accountLabel(ix, 2, labels))
+		return summary, nil
+	default:
+		return nil, fmt.Errorf("unknown associated token account instruction %d", data[0])
+	}
+	summary.Description = fmt.Sprintf("create %s token account %s for %s, paid by %s", mint, accountLabel(ix, 1, labels), accountLabel(ix, 2, labels), accountLabel(ix, 0, labels))
+	return summary, nil
+}
+
+func decodeMemoInstruction(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error) {
+	data, err := ix.Data()
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("memo is not valid UTF-8")
+	}
+	return &InstructionSummary{
+		Program:     "Memo",
+		Name:        "Memo",
+		Description: strconv.Quote(string(data)),
+	}, nil
+}
+
+func decodeComputeBudgetInstruction(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error) {
+	data, err := ix.Data()
+	if err != nil {
+		return nil, err
+	}
+	inst, err := computebudget.DecodeInstruction(ix.Accounts(), data)
+	if err != nil {
+		return nil, err
+	}
+	summary := &InstructionSummary{
+		Program: "Compute Budget",
+		Name:    computebudget.InstructionIDToName(inst.TypeID.Uint8()),
+	}
+	switch impl := inst.Impl.(type) {
+	case *computebudget.SetComputeUnitLimit:
+		summary.Description = fmt.Sprintf("set compute unit limit to %d", impl.Units)
+	case *computebudget.SetComputeUnitPrice:
+		summary.Description = fmt.Sprintf("set compute unit price to %d micro-lamports", impl.MicroLamports)
+	case *computebudget.RequestHeapFrame:
+		summary.Description = fmt.Sprintf("request a heap frame of %d bytes", impl.HeapSize)
+	}
+	return summary, nil
+}
+
+var upgradeableLoaderInstructionNames = []string{
+	"InitializeBuffer",
+	"Write",
+	"DeployWithMaxDataLen",
+	"Upgrade",
+	"SetAuthority",
+	"Close",
+	"ExtendProgram",
+	"SetAuthorityChecked",
+	"Migrate",
+	"ExtendProgramChecked",
+}
+
+// decodeUpgradeableLoaderInstruction decodes instructions of the BPF Upgradeable Loader, whose data starts with
+// a u32 instruction tag
+func decodeUpgradeableLoaderInstruction(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error) {
+	data, err := ix.Data()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("instruction data too short")
+	}
+	tag := binary.LittleEndian.Uint32(data)
+	if int(tag) >= len(upgradeableLoaderInstructionNames) {
+		return nil, fmt.Errorf("unknown upgradeable loader instruction %d", tag)
+	}
+	summary := &InstructionSummary{
+		Program: "BPF Upgradeable Loader",
+		Name:    upgradeableLoaderInstructionNames[tag],
+	}
+	args := data[4:]
+	switch tag {
+	case 0:
+		summary.Description = fmt.Sprintf("initialize buffer %s", accountLabel(ix, 0, labels))
+	case 1:
+		if len(args) < 12 {
+			return nil, fmt.Errorf("instruction data too short")
+		}
+		offset, size := binary.LittleEndian.Uint32(args), binary.LittleEndian.Uint64(args[4:])
+		summary.Description = fmt.Sprintf("write %d bytes to buffer %s at offset %d", size, accountLabel(ix, 0, labels), offset)
+	case 2:
+		summary.Description = fmt.Sprintf("deploy program %s from buffer %s", accountLabel(ix, 2, labels), accountLabel(ix, 3, labels))
+	case 3:
+		summary.Description = fmt.Sprintf("upgrade program %s from buffer %s, refund to %s", accountLabel(ix, 1, labels), accountLabel(ix, 2, labels), accountLabel(ix, 3, labels))
+	case 4, 7:
+		if len(ix.Accounts()) < 3 {
+			summary.Description = fmt.Sprintf("make %s immutable", accountLabel(ix, 0, labels))
+		} else {
+			summary.Description = fmt.Sprintf("set authority of %s to %s", accountLabel(ix, 0, labels), accountLabel(ix, 2, labels))
+		}
+	case 5:
+		summary.Description = fmt.Sprintf("close %s, lamports to %s", accountLabel(ix, 0, labels), accountLabel(ix, 1, labels))
+	case 6, 9:
+		if len(args) < 4 {
+			return nil, fmt.Errorf("instruction data too short")
+		}
+		summary.Description = fmt.Sprintf("extend program %s by %d bytes", accountLabel(ix, 1, labels), binary.LittleEndian.Uint32(args))
+	case 8:
+		summary.Description = fmt.Sprintf("migrate program %s", accountLabel(ix, 1, labels))
+	}
+	return summary, nil
+}
+
+func decodeMultisigInstruction(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error) {
+	data, err := ix.Data()
+	if err != nil {
+		return nil, err
+	}
+	inst, err := squads_multisig_program.DecodeInstruction(ix.Accounts(), data)
+	if err != nil {
+		return nil, err
+	}
+	summary := &InstructionSummary{
+		Program: "Squads",
+		Name:    squads_multisig_program.InstructionIDToName(inst.TypeID),
+	}
+	multisig := accountLabel(ix, 0, labels)
+	switch impl := inst.Impl.(type) {
+	case *squads_multisig_program.MultisigAddMember:
+		summary.Description = fmt.Sprintf("add member %s with permissions %s to %s",
+			labels.Label(impl.Args.NewMember.Key), Permission(impl.Args.NewMember.Permissions.Mask), multisig)
+	case *squads_multisig_program.MultisigRemoveMember:
+		summary.Description = fmt.Sprintf("remove member %s from %s", labels.Label(impl.Args.OldMember), multisig)
+	case *squads_multisig_program.MultisigChangeThreshold:
+		summary.Description = fmt.Sprintf("change threshold of %s to %d", multisig, impl.Args.NewThreshold)
+	case *squads_multisig_program.MultisigSetTimeLock:
+		summary.Description = fmt.Sprintf("set time lock of %s to %d seconds", multisig, impl.Args.TimeLock)
+	}
+	return summary, nil
+}
+
+// DescribeConfigAction summarizes an action of a config transaction
+func (r *DecoderRegistry) DescribeConfigAction(action squads_multisig_program.ConfigAction, labels AddressLabels) *InstructionSummary {
+	summary := &InstructionSummary{
+		ProgramID: squads_multisig_program.ProgramID,
+		Program:   "Squads",
+	}
+	switch a := action.(type) {
+	case *squads_multisig_program.ConfigActionAddMember:
+		summary.Name = "AddMember"
+		summary.Description = fmt.Sprintf("add member %s with permissions %s", labels.Label(a.NewMember.Key), Permission(a.NewMember.Permissions.Mask))
+	case *squads_multisig_program.ConfigActionRemoveMember:
+		summary.Name = "RemoveMember"
+		summary.Description = fmt.Sprintf("remove member %s", labels.Label(a.OldMember))
+	case *squads_multisig_program.ConfigActionChangeThreshold:
+		summary.Name = "ChangeThreshold"
+		summary.Description = fmt.Sprintf("change threshold to %d", a.NewThreshold)
+	case *squads_multisig_program.ConfigActionSetTimeLock:
+		summary.Name = "SetTimeLock"
+		summary.Description = fmt.Sprintf("set time lock to %d seconds", a.NewTimeLock)
+	case *squads_multisig_program.ConfigActionAddSpendingLimit:
+		summary.Name = "AddSpendingLimit"
+		var amount string
+		if a.Mint.IsZero() {
+			amount = formatSol(a.Amount)
+		} else if info, ok := r.Token(a.Mint); ok {
+			amount = formatAmount(a.Amount, info.Decimals) + " " + info.Symbol
+		} else {
+			amount = fmt.Sprintf("%d base units of mint %s", a.Amount, labels.Label(a.Mint))
+		}
+		period := "once"
+		if a.Period != squads_multisig_program.PeriodOneTime {
+			period = "per " + strings.ToLower(a.Period.String())
+		}
+		members := make([]string, 0, len(a.Members))
+		for _, member := range a.Members {
+			members = append(members, labels.Label(member))
+		}
+		summary.Description = fmt.Sprintf("allow %s from vault %d %s, for members %s", amount, a.VaultIndex, period, strings.Join(members, ", "))
+		if len(a.Destinations) > 0 {
+			destinations := make([]string, 0, len(a.Destinations))
+			for _, destination := range a.Destinations {
+				destinations = append(destinations, labels.Label(destination))
+			}
+			summary.Description += fmt.Sprintf(", to %s", strings.Join(destinations, ", "))
+		}
+	case *squads_multisig_program.ConfigActionRemoveSpendingLimit:
+		summary.Name = "RemoveSpendingLimit"
+		summary.Description = fmt.Sprintf("remove spending limit %s", labels.Label(a.SpendingLimit))
+	case *squads_multisig_program.ConfigActionSetRentCollector:
+		summary.Name = "SetRentCollector"
+		if a.NewRentCollector == nil {
+			summary.Description = "unset the rent collector"
+		} else {
+			summary.Description = fmt.Sprintf("set rent collector to %s", labels.Label(*a.NewRentCollector))
+		}
+	default:
+		summary.Name = "Unknown"
+		summary.Description = fmt.Sprintf("%T", action)
+	}
+	return summary
+}
@@ -0,0 +1,233 @@
+package squads
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+func Test_FormatAmount(t *testing.T) {
+	cases := []struct {
+		amount   uint64
+		decimals uint8
+		want     string
+	}{
+		{12_500_000_000, 9, "12.5"},
+		{1_000_000_000, 6, "1,000"},
+		{1, 6, "0.000001"},
+		{0, 9, "0"},
+		{1_234_567, 0, "1,234,567"},
+		{123_456_789_012, 2, "1,234,567,890.12"},
+	}
+	for _, c := range cases {
+		if got := formatAmount(c.amount, c.decimals); got != c.want {
+			t.Errorf("formatAmount(%d, %d) = %q, want %q", c.amount, c.decimals, got, c.want)
+		}
+	}
+}
+
+func Test_DecoderRegistryBuiltins(t *testing.T) {
+	registry := NewDecoderRegistry()
+	vault := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+	labels := AddressLabels{vault: "vault 0"}
+	usdc := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	source := solana.NewWallet().PublicKey()
+	destination := solana.NewWallet().PublicKey()
+
+	cases := []struct {
+		ix   solana.Instruction
+		want string
+	}{
+		{
+			system.NewTransferInstruction(12_500_000_000, vault, recipient).Build(),
+			"System Transfer: transfer 12.5 SOL from vault 0 to " + recipient.String(),
+		},
+		{
+			token.NewTransferCheckedInstruction(1_000_000_000, 6, source, usdc, destination, vault, nil).Build(),
+			"SPL Token TransferChecked: transfer 1,000 USDC from " + source.String() + " to " + destination.String(),
+		},
+		{
+			computebudget.NewSetComputeUnitPriceInstruction(1000).Build(),
+			"Compute Budget SetComputeUnitPrice: set compute unit price to 1000 micro-lamports",
+		},
+		{
+			solana.NewInstruction(solana.MemoProgramID, solana.AccountMetaSlice{solana.NewAccountMeta(vault, false, true)}, []byte("payroll")),
+			`Memo Memo: "payroll"`,
+		},
+	}
+	for _, c := range cases {
+		summary := registry.Decode(c.ix, labels)
+		if got := summary.String(); got != c.want {
+			t.Errorf("got %q, want %q", got, c.want)
+		}
+	}
+
+	// Token-2022 shares the layout of the base instructions
+	ix := token.NewTransferCheckedInstruction(5, 0, source, usdc, destination, vault, nil).Build()
+	ix2022 := solana.NewInstruction(solana.Token2022ProgramID, ix.Accounts(), mustData(t, ix))
+	if got := registry.Decode(ix2022, labels).Description; got != "transfer 0.000005 USDC from "+source.String()+" to "+destination.String() {
+		t.Errorf("unexpected Token-2022 description %q", got)
+	}
+}
+
+func mustData(t *testing.T, ix solana.Instruction) []byte {
+	t.Helper()
+	data, err := ix.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func Test_DecoderRegistryCustomAndUnknown(t *testing.T) {
+	registry := NewDecoderRegistry()
+	programID := solana.NewWallet().PublicKey()
+	account := solana.NewWallet().PublicKey()
+	ix := solana.NewInstruction(programID, solana.AccountMetaSlice{solana.NewAccountMeta(account, true, false)}, []byte{0xde, 0xad})
+
+	summary := registry.Decode(ix, nil)
+	if summary.Name != "Unknown" || summary.Data != "dead" || len(summary.Accounts) != 1 || summary.Accounts[0] != account.String()+" [writable]" {
+		t.Fatalf("unexpected fallback summary %+v", summary)
+	}
+
+	registry.Register(programID, func(ix solana.Instruction, labels AddressLabels) (*InstructionSummary, error) {
+		return &InstructionSummary{Program: "Custom", Name: "Ping", Description: "ping " + labels.Label(ix.Accounts()[0].PublicKey)}, nil
+	})
+	summary = registry.Decode(ix, AddressLabels{account: "treasury"})
+	if got := summary.String(); got != "Custom Ping: ping treasury" || !summary.ProgramID.Equals(programID) {
+		t.Fatalf("unexpected custom summary %q", got)
+	}
+}
+
+func Test_VaultTransactionSummary(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+
+	vaultPda, err := GetVaultPda(multisigPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionPda, err := GetTransactionPda(multisigPda, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := solana.NewWallet().PublicKey()
+	data, err := TransactionMessageToMultisigTransactionMessageBytes(TransactionMessage{
+		PayerKey:     vaultPda,
+		Instructions: []solana.Instruction{system.NewTransferInstruction(12_500_000_000, vaultPda, recipient).Build()},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := DecodeTransactionMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator := solana.NewWallet().PublicKey()
+	err = client.SetBorshAccount(transactionPda, squads_multisig_program.VaultTransaction{
+		Multisig: multisigPda,
+		Creator:  creator,
+		Index:    7,
+		Message:  message,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := s.VaultTransactionSummary(t.Context(), 7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "transfer 12.5 SOL from vault 0 to " + recipient.String()
+	if len(summary.Instructions) != 1 || summary.Instructions[0].Description != want {
+		t.Fatalf("unexpected summary %+v", summary.Instructions)
+	}
+
+	text := new(bytes.Buffer)
+	if err := summary.Render(text, SummaryFormatText); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(text.String()), "Vault transaction #7") || !strings.Contains(text.String(), want) {
+		t.Fatalf("unexpected text rendering:\n%s", text)
+	}
+
+	out := new(bytes.Buffer)
+	if err := summary.Render(out, SummaryFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded TransactionSummary
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Kind != "vault" || !decoded.Creator.Equals(creator) || decoded.Instructions[0].Description != want {
+		t.Fatalf("unexpected json rendering:\n%s", out)
+	}
+}
+
+func Test_ConfigTransactionSummary(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+
+	transactionPda, err := GetTransactionPda(multisigPda, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	member := solana.NewWallet().PublicKey()
+	err = client.SetBorshAccount(transactionPda, squads_multisig_program.ConfigTransaction{
+		Multisig: multisigPda,
+		Index:    2,
+		Actions: squads_multisig_program.ConfigActionsWithVariants([]squads_multisig_program.ConfigAction{
+			&squads_multisig_program.ConfigActionAddMember{NewMember: squads_multisig_program.Member{
+				Key:         member,
+				Permissions: squads_multisig_program.Permissions{Mask: uint8(Initiate | Vote)},
+			}},
+			&squads_multisig_program.ConfigActionChangeThreshold{NewThreshold: 2},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := s.ConfigTransactionSummary(t.Context(), 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Squads AddMember: add member " + member.String() + " with permissions initiate, vote",
+		"Squads ChangeThreshold: change threshold to 2",
+	}
+	if len(summary.Actions) != len(want) {
+		t.Fatalf("got %d actions, want %d", len(summary.Actions), len(want))
+	}
+	for i, action := range summary.Actions {
+		if action.String() != want[i] {
+			t.Errorf("action %d: got %q, want %q", i, action, want[i])
+		}
+	}
+}
+
+func Test_DecoderRegistryFollowsProgramID(t *testing.T) {
+	defaultProgramID := squads_multisig_program.ProgramID
+	t.Cleanup(func() { squads_multisig_program.SetProgramID(defaultProgramID) })
+	squads_multisig_program.SetProgramID(solana.NewWallet().PublicKey())
+
+	s := New(squadstest.NewClient(), solana.NewWallet().PublicKey())
+	ix, err := s.ProposalApproveIx(t.Context(), solana.NewWallet().PublicKey(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary := DefaultDecoderRegistry.Decode(ix, nil); summary.Program != "Squads" || summary.Name != "ProposalApprove" {
+		t.Fatalf("expected the multisig decoder for the new program id, got %+v", summary)
+	}
+}
@@ -0,0 +1,100 @@
+// Regenerates the "expected" section of every fixture in this directory with the TypeScript SDK,
+// so the Go compiler is checked against @sqds/multisig rather than against itself.
+//
+//   npm install --no-save @sqds/multisig @solana/web3.js
+//   node generate.mjs
+import { readdirSync, readFileSync, writeFileSync } from "node:fs";
+import { dirname, join } from "node:path";
+import { fileURLToPath } from "node:url";
+import * as multisig from "@sqds/multisig";
+import {
+  AddressLookupTableAccount,
+  PublicKey,
+  TransactionInstruction,
+  TransactionMessage,
+} from "@solana/web3.js";
+
+const dir = dirname(fileURLToPath(import.meta.url));
+
+// decode parses the bytes of transactionMessageBeet so the fixture can list keys by name
+function decode(bytes) {
+  let offset = 0;
+  const u8 = () => bytes[offset++];
+  const key = () => new PublicKey(bytes.subarray(offset, (offset += 32)));
+  const u8s = () => Array.from({ length: u8() }, u8);
+  const message = {
+    numSigners: u8(),
+    numWritableSigners: u8(),
+    numWritableNonSigners: u8(),
+  };
+  message.accountKeys = Array.from({ length: u8() }, key);
+  message.instructions = Array.from({ length: u8() }, () => {
+    const programIdIndex = u8();
+    const accountIndexes = u8s();
+    const length = bytes[offset] | (bytes[offset + 1] << 8);
+    offset += 2;
+    const data = Buffer.from(bytes.subarray(offset, (offset += length))).toString("hex");
+    return { programIdIndex, accountIndexes, data };
+  });
+  message.addressTableLookups = Array.from({ length: u8() }, () => ({
+    accountKey: key(),
+    writableIndexes: u8s(),
+    readonlyIndexes: u8s(),
+  }));
+  return message;
+}
+
+for (const file of readdirSync(dir).filter((name) => name.endsWith(".json")).sort()) {
+  const path = join(dir, file);
+  const fixture = JSON.parse(readFileSync(path, "utf8"));
+  const pubkey = (name) => new PublicKey(fixture.keys[name]);
+  const names = new Map(Object.entries(fixture.keys).map(([name, address]) => [address, name]));
+  const name = (key) => names.get(key.toBase58());
+
+  const message = new TransactionMessage({
+    payerKey: pubkey(fixture.payer),
+    recentBlockhash: PublicKey.default.toBase58(),
+    instructions: fixture.instructions.map(
+      (ix) =>
+        new TransactionInstruction({
+          programId: pubkey(ix.programId),
+          keys: ix.accounts.map((account) => ({
+            pubkey: pubkey(account.key),
+            isSigner: account.isSigner,
+            isWritable: account.isWritable,
+          })),
+          data: Buffer.from(ix.data, "hex"),
+        }),
+    ),
+  });
+  const addressLookupTableAccounts = fixture.addressLookupTables.map(
+    (table) =>
+      new AddressLookupTableAccount({
+        key: pubkey(table.key),
+        state: {
+          deactivationSlot: BigInt("18446744073709551615"),
+          lastExtendedSlot: 0,
+          lastExtendedSlotStartIndex: 0,
+          addresses: table.addresses.map(pubkey),
+        },
+      }),
+  );
+
+  const bytes = multisig.utils.transactionMessageToMultisigTransactionMessageBytes({
+    message,
+    addressLookupTableAccounts,
+    vaultPda: pubkey(fixture.payer),
+  });
+  const decoded = decode(bytes);
+  fixture.expected = {
+    ...decoded,
+    accountKeys: decoded.accountKeys.map(name),
+    addressTableLookups: decoded.addressTableLookups.map((lookup) => ({
+      ...lookup,
+      accountKey: name(lookup.accountKey),
+    })),
+    bytes: Buffer.from(bytes).toString("hex"),
+  };
+  writeFileSync(path, JSON.stringify(fixture, null, 2) + "\n");
+  console.log(`${file}: ${fixture.expected.bytes.length / 2} bytes`);
+}
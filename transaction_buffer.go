@@ -0,0 +1,339 @@
+package squads
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// TransactionBufferChunkSize is the largest number of message bytes carried by a single
+// TransactionBufferCreate or TransactionBufferExtend instruction. It leaves enough
+// room for the signature, account keys and instruction args to stay under the
+// 1232 byte packet limit; the instructions added by TxOptions shrink the chunks further.
+const TransactionBufferChunkSize = 900
+
+// VaultTransactionBufferTxs holds the transactions that create a vault transaction through a transaction buffer
+type VaultTransactionBufferTxs struct {
+	TransactionIndex uint64
+	TransactionPda   solana.PublicKey
+	BufferPda        solana.PublicKey
+	FinalBufferHash  [32]uint8
+	FinalBufferSize  uint16
+
+	// Create creates the buffer account with the first chunk of the message
+	Create *solana.Transaction
+	// Extend appends the remaining chunks, must be sent in order after Create
+	Extend []*solana.Transaction
+	// CreateFromBuffer creates the vault transaction and closes the buffer
+	CreateFromBuffer *solana.Transaction
+	// Close reclaims the buffer rent when the flow is abandoned before CreateFromBuffer lands
+	Close *solana.Transaction
+}
+
+// Transactions returns the transactions in the order they must be sent:
+// create, extend..., create-from-buffer. Close is not included since
+// CreateFromBuffer already closes the buffer account.
+func (b *VaultTransactionBufferTxs) Transactions() []*solana.Transaction {
+	txs := []*solana.Transaction{b.Create}
+	txs = append(txs, b.Extend...)
+	return append(txs, b.CreateFromBuffer)
+}
+
+// transactionBufferChunkSize returns the number of message bytes the buffer instruction ix, built with an
+// empty chunk, can carry in the transactions of builder, at most TransactionBufferChunkSize
+func transactionBufferChunkSize(builder *txBuilder, ix solana.Instruction, payer solana.PublicKey) (int, error) {
+	size, err := builder.maxSize([]solana.Instruction{ix}, payer)
+	if err != nil {
+		return 0, err
+	}
+	// a chunk also grows the compact length of the instruction data from one byte to two
+	room := MaxTransactionSize - size - 1
+	if room <= 0 {
+		return 0, fmt.Errorf("transaction options leave no room for a transaction buffer chunk, %d bytes without it", size)
+	}
+	return min(room, TransactionBufferChunkSize), nil
+}
+
+// splitTransactionBuffer splits the message bytes into chunks of at most chunkSize bytes
+func splitTransactionBuffer(buffer []byte, chunkSize int) [][]byte {
+	var chunks [][]byte
+	for len(buffer) > chunkSize {
+		chunks = append(chunks, buffer[:chunkSize])
+		buffer = buffer[chunkSize:]
+	}
+	return append(chunks, buffer)
+}
+
+// TransactionBufferAccount retrieves the transaction buffer account information
+func (s *Multisig) TransactionBufferAccount(ctx context.Context, bufferPda solana.PublicKey) (*squads_multisig_program.TransactionBuffer, error) {
+	out, err := s.client.GetAccountInfo(ctx, bufferPda)
+	if err != nil {
+		return nil, err
+	}
+	data := out.Value.Data.GetBinary()
+
+	account := &squads_multisig_program.TransactionBuffer{}
+	decoder := ag_binary.NewBorshDecoder(data)
+	if err := account.UnmarshalWithDecoder(decoder); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// TransactionBufferCreateIx creates an instruction to create a transaction buffer holding the first chunk of a message
+func (s *Multisig) TransactionBufferCreateIx(ctx context.Context, creatorAndPayer solana.PublicKey, bufferIndex, vaultIndex uint8, finalBufferHash [32]uint8, finalBufferSize uint16, buffer []byte) (solana.Instruction, error) {
+	bufferPda, err := GetTransactionBufferPda(s.multisigPda, creatorAndPayer, bufferIndex)
+	if err != nil {
+		return nil, err
+	}
+	args := squads_multisig_program.TransactionBufferCreateArgs{
+		BufferIndex:     bufferIndex,
+		VaultIndex:      vaultIndex,
+		FinalBufferHash: finalBufferHash,
+		FinalBufferSize: finalBufferSize,
+		Buffer:          buffer,
+	}
+
+	ix := squads_multisig_program.NewTransactionBufferCreateInstruction(
+		args,
+		s.multisigPda,
+		bufferPda,
+		creatorAndPayer, // creator
+		creatorAndPayer,
+		solana.SystemProgramID,
+	).Build()
+
+	return ix, nil
+}
+
+// TransactionBufferCreateTx creates a transaction to create a transaction buffer
+func (s *Multisig) TransactionBufferCreateTx(ctx context.Context, creatorAndPayer solana.PublicKey, bufferIndex, vaultIndex uint8, finalBufferHash [32]uint8, finalBufferSize uint16, buffer []byte, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.TransactionBufferCreateIx(ctx, creatorAndPayer, bufferIndex, vaultIndex, finalBufferHash, finalBufferSize, buffer)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, creatorAndPayer, opts)
+}
+
+// TransactionBufferExtendIx creates an instruction to append a chunk to a transaction buffer
+func (s *Multisig) TransactionBufferExtendIx(ctx context.Context, creator solana.PublicKey, bufferIndex uint8, buffer []byte) (solana.Instruction, error) {
+	bufferPda, err := GetTransactionBufferPda(s.multisigPda, creator, bufferIndex)
+	if err != nil {
+		return nil, err
+	}
+	args := squads_multisig_program.TransactionBufferExtendArgs{
+		Buffer: buffer,
+	}
+
+	ix := squads_multisig_program.NewTransactionBufferExtendInstruction(
+		args,
+		s.multisigPda,
+		bufferPda,
+		creator,
+	).Build()
+
+	return ix, nil
+}
+
+// TransactionBufferExtendTx creates a transaction to append a chunk to a transaction buffer
+func (s *Multisig) TransactionBufferExtendTx(ctx context.Context, creator solana.PublicKey, bufferIndex uint8, buffer []byte, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.TransactionBufferExtendIx(ctx, creator, bufferIndex, buffer)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, creator, opts)
+}
+
+// TransactionBufferCloseIx creates an instruction to close a transaction buffer and reclaim its rent
+func (s *Multisig) TransactionBufferCloseIx(ctx context.Context, creator solana.PublicKey, bufferIndex uint8) (solana.Instruction, error) {
+	bufferPda, err := GetTransactionBufferPda(s.multisigPda, creator, bufferIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := squads_multisig_program.NewTransactionBufferCloseInstruction(
+		s.multisigPda,
+		bufferPda,
+		creator,
+	).Build()
+
+	return ix, nil
+}
+
+// TransactionBufferCloseTx creates a transaction to close a transaction buffer
+func (s *Multisig) TransactionBufferCloseTx(ctx context.Context, creator solana.PublicKey, bufferIndex uint8, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.TransactionBufferCloseIx(ctx, creator, bufferIndex)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, creator, opts)
+}
+
+// VaultTransactionCreateFromBufferIx creates an instruction to create a vault transaction from a completed transaction buffer.
+// The buffer must have been created by creatorAndPayer, it is closed by the program on success.
+// ephemeralSigners is the number of ephemeral signers used by the buffered message.
+func (s *Multisig) VaultTransactionCreateFromBufferIx(ctx context.Context, creatorAndPayer solana.PublicKey, bufferIndex, vaultIndex uint8, transactionIndex uint64, ephemeralSigners uint8) (solana.Instruction, error) {
+	if transactionIndex == 0 {
+		multisigInfo, err := s.MultisigAccount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		transactionIndex = multisigInfo.TransactionIndex + 1
+	}
+	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	bufferPda, err := GetTransactionBufferPda(s.multisigPda, creatorAndPayer, bufferIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	args := squads_multisig_program.VaultTransactionCreateArgs{
+		VaultIndex:       vaultIndex,
+		EphemeralSigners: ephemeralSigners,
+		// the message is read from the buffer, the program expects an empty placeholder
+		TransactionMessage: []byte{0, 0, 0, 0, 0, 0},
+	}
+
+	ix := squads_multisig_program.NewVaultTransactionCreateFromBufferInstruction(
+		args,
+		s.multisigPda,
+		transactionPda,
+		creatorAndPayer, // creator
+		creatorAndPayer,
+		solana.SystemProgramID,
+		bufferPda,
+		creatorAndPayer,
+	).Build()
+
+	return ix, nil
+}
+
+// VaultTransactionCreateFromBufferTx creates a transaction to create a vault transaction from a completed transaction buffer
+func (s *Multisig) VaultTransactionCreateFromBufferTx(ctx context.Context, creatorAndPayer solana.PublicKey, bufferIndex, vaultIndex uint8, transactionIndex uint64, ephemeralSigners uint8, opts ...TxOption) (*solana.Transaction, error) {
+	ix, err := s.VaultTransactionCreateFromBufferIx(ctx, creatorAndPayer, bufferIndex, vaultIndex, transactionIndex, ephemeralSigners)
+	if err != nil {
+		return nil, err
+	}
+	return buildTransaction(ctx, s.client, []solana.Instruction{ix}, creatorAndPayer, opts)
+}
+
+// VaultTransactionCreateViaBuffer builds the transactions that create a vault transaction whose
+// message does not fit in a single transaction. The compiled message is uploaded to a transaction
+// buffer in chunks of at most TransactionBufferChunkSize bytes, sized so the transactions built with opts
+// fit in a packet, then turned into a vault transaction.
+func (s *Multisig) VaultTransactionCreateViaBuffer(ctx context.Context, creatorAndPayer solana.PublicKey, bufferIndex, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction, opts ...TxOption) (*VaultTransactionBufferTxs, error) {
+	return s.VaultTransactionCreateViaBufferWithOpts(ctx, creatorAndPayer, bufferIndex, vaultIndex, transactionIndex, instructions, nil, opts...)
+}
+
+// VaultTransactionCreateViaBufferWithOpts is VaultTransactionCreateViaBuffer with the message compiled with messageOpts
+func (s *Multisig) VaultTransactionCreateViaBufferWithOpts(ctx context.Context, creatorAndPayer solana.PublicKey, bufferIndex, vaultIndex uint8, transactionIndex uint64, instructions []solana.Instruction, messageOpts *VaultTransactionMessageOpts, opts ...TxOption) (*VaultTransactionBufferTxs, error) {
+	if transactionIndex == 0 {
+		multisigInfo, err := s.MultisigAccount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		transactionIndex = multisigInfo.TransactionIndex + 1
+	}
+	transactionPda, err := GetTransactionPda(s.multisigPda, transactionIndex)
+	if err != nil {
+		return nil, err
+	}
+	bufferPda, err := GetTransactionBufferPda(s.multisigPda, creatorAndPayer, bufferIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	txMessageBytes, ephemeralSigners, err := s.vaultTransactionMessageBytes(ctx, transactionPda, vaultIndex, instructions, messageOpts)
+	if err != nil {
+		return nil, err
+	}
+	if len(txMessageBytes) > math.MaxUint16 {
+		return nil, errors.New("transaction message too large for a transaction buffer")
+	}
+	finalBufferHash := sha256.Sum256(txMessageBytes)
+	finalBufferSize := uint16(len(txMessageBytes))
+
+	builder, err := newTxBuilder(ctx, s.client, opts)
+	if err != nil {
+		return nil, err
+	}
+	newTx := func(ix solana.Instruction) (*solana.Transaction, error) {
+		return builder.build(ctx, []solana.Instruction{ix}, creatorAndPayer)
+	}
+
+	out := &VaultTransactionBufferTxs{
+		TransactionIndex: transactionIndex,
+		TransactionPda:   transactionPda,
+		BufferPda:        bufferPda,
+		FinalBufferHash:  finalBufferHash,
+		FinalBufferSize:  finalBufferSize,
+	}
+
+	emptyCreateIx, err := s.TransactionBufferCreateIx(ctx, creatorAndPayer, bufferIndex, vaultIndex, finalBufferHash, finalBufferSize, nil)
+	if err != nil {
+		return nil, err
+	}
+	createChunkSize, err := transactionBufferChunkSize(builder, emptyCreateIx, creatorAndPayer)
+	if err != nil {
+		return nil, err
+	}
+	emptyExtendIx, err := s.TransactionBufferExtendIx(ctx, creatorAndPayer, bufferIndex, nil)
+	if err != nil {
+		return nil, err
+	}
+	extendChunkSize, err := transactionBufferChunkSize(builder, emptyExtendIx, creatorAndPayer)
+	if err != nil {
+		return nil, err
+	}
+
+	first := txMessageBytes[:min(len(txMessageBytes), createChunkSize)]
+	createIx, err := s.TransactionBufferCreateIx(ctx, creatorAndPayer, bufferIndex, vaultIndex, finalBufferHash, finalBufferSize, first)
+	if err != nil {
+		return nil, err
+	}
+	if out.Create, err = newTx(createIx); err != nil {
+		return nil, err
+	}
+
+	var chunks [][]byte
+	if rest := txMessageBytes[len(first):]; len(rest) > 0 {
+		chunks = splitTransactionBuffer(rest, extendChunkSize)
+	}
+	for _, chunk := range chunks {
+		extendIx, err := s.TransactionBufferExtendIx(ctx, creatorAndPayer, bufferIndex, chunk)
+		if err != nil {
+			return nil, err
+		}
+		extendTx, err := newTx(extendIx)
+		if err != nil {
+			return nil, err
+		}
+		out.Extend = append(out.Extend, extendTx)
+	}
+
+	createFromBufferIx, err := s.VaultTransactionCreateFromBufferIx(ctx, creatorAndPayer, bufferIndex, vaultIndex, transactionIndex, ephemeralSigners)
+	if err != nil {
+		return nil, err
+	}
+	if out.CreateFromBuffer, err = newTx(createFromBufferIx); err != nil {
+		return nil, err
+	}
+
+	closeIx, err := s.TransactionBufferCloseIx(ctx, creatorAndPayer, bufferIndex)
+	if err != nil {
+		return nil, err
+	}
+	if out.Close, err = newTx(closeIx); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,156 @@
+package squads
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func Test_SplitTransactionBuffer(t *testing.T) {
+	buffer := bytes.Repeat([]byte{1}, 2*TransactionBufferChunkSize+1)
+	chunks := splitTransactionBuffer(buffer, TransactionBufferChunkSize)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if len(chunks[2]) != 1 {
+		t.Fatalf("expected last chunk of 1 byte, got %d", len(chunks[2]))
+	}
+	if !bytes.Equal(bytes.Join(chunks, nil), buffer) {
+		t.Fatal("chunks do not reassemble to the original buffer")
+	}
+}
+
+func Test_TransactionBufferCreateFitsPacket(t *testing.T) {
+	client := squadstest.NewClient()
+	creator := solana.NewWallet().PublicKey()
+	s := New(client, solana.NewWallet().PublicKey())
+	budget := []TxOption{WithComputeUnitPrice(1000), WithComputeUnitLimit(200_000)}
+
+	cases := map[string][]TxOption{
+		"no options":     nil,
+		"compute budget": budget,
+		"durable nonce":  testNonces(t, client, 1),
+		"both":           append(testNonces(t, client, 1), budget...),
+	}
+	for name, opts := range cases {
+		steps := []struct {
+			kind string
+			ix   func(chunk []byte) (solana.Instruction, error)
+			tx   func(chunk []byte) (*solana.Transaction, error)
+		}{
+			{
+				"create",
+				func(chunk []byte) (solana.Instruction, error) {
+					return s.TransactionBufferCreateIx(t.Context(), creator, 0, 0, [32]uint8{}, 4000, chunk)
+				},
+				func(chunk []byte) (*solana.Transaction, error) {
+					return s.TransactionBufferCreateTx(t.Context(), creator, 0, 0, [32]uint8{}, 4000, chunk, opts...)
+				},
+			},
+			{
+				"extend",
+				func(chunk []byte) (solana.Instruction, error) {
+					return s.TransactionBufferExtendIx(t.Context(), creator, 0, chunk)
+				},
+				func(chunk []byte) (*solana.Transaction, error) {
+					return s.TransactionBufferExtendTx(t.Context(), creator, 0, chunk, opts...)
+				},
+			},
+		}
+		for _, step := range steps {
+			builder, err := newTxBuilder(t.Context(), client, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			emptyIx, err := step.ix(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chunk, err := transactionBufferChunkSize(builder, emptyIx, creator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name == "no options" && chunk != TransactionBufferChunkSize {
+				t.Errorf("%s %s: chunk of %d bytes, want %d", name, step.kind, chunk, TransactionBufferChunkSize)
+			}
+			tx, err := step.tx(make([]byte, chunk))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := serializedSize(t, tx); size > MaxTransactionSize {
+				t.Errorf("%s %s: transaction is %d bytes, exceeds packet limit", name, step.kind, size)
+			}
+			if chunk == TransactionBufferChunkSize {
+				continue
+			}
+			// a chunk shrunk by the options is as large as the packet allows
+			tx, err = step.tx(make([]byte, chunk+1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := serializedSize(t, tx); size <= MaxTransactionSize {
+				t.Errorf("%s %s: chunk of %d bytes leaves room, transaction is %d bytes", name, step.kind, chunk, size)
+			}
+		}
+	}
+}
+
+// serializedSize returns the size of tx once signed
+func serializedSize(t *testing.T, tx *solana.Transaction) int {
+	t.Helper()
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(raw)
+}
+
+// testNonces stores count nonce accounts whose authority is not the payer, so it signs as well
+func testNonces(t *testing.T, client *squadstest.Client, count int) []TxOption {
+	t.Helper()
+	authority := solana.NewWallet().PublicKey()
+	opts := make([]TxOption, count)
+	for i := range opts {
+		account := solana.NewWallet().PublicKey()
+		if err := client.SetNonceAccount(account, authority, solana.Hash{byte(i + 1)}); err != nil {
+			t.Fatal(err)
+		}
+		opts[i] = WithDurableNonce(account, authority)
+	}
+	return opts
+}
+
+func Test_VaultTransactionCreateViaBufferFitsPacket(t *testing.T) {
+	client := squadstest.NewClient()
+	s := New(client, solana.NewWallet().PublicKey())
+	creator := solana.NewWallet().PublicKey()
+	vaultPda, err := GetVaultPda(s.multisigPda, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instructions := []solana.Instruction{
+		solana.NewInstruction(solana.MemoProgramID, solana.AccountMetaSlice{solana.NewAccountMeta(vaultPda, false, true)}, bytes.Repeat([]byte{'a'}, 3000)),
+	}
+	budget := []TxOption{WithComputeUnitPrice(1000), WithComputeUnitLimit(200_000)}
+
+	cases := map[string][]TxOption{
+		"no options":     nil,
+		"compute budget": budget,
+		"durable nonce":  testNonces(t, client, 8),
+		"both":           append(testNonces(t, client, 8), budget...),
+	}
+	for name, opts := range cases {
+		out, err := s.VaultTransactionCreateViaBuffer(t.Context(), creator, 0, 0, 1, instructions, opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i, tx := range append(out.Transactions(), out.Close) {
+			if size := serializedSize(t, tx); size > MaxTransactionSize {
+				t.Errorf("%s: transaction %d is %d bytes, exceeds packet limit", name, i, size)
+			}
+		}
+	}
+}
@@ -15,9 +15,23 @@ type TransactionMessage struct {
 	RecentBlockhash solana.Hash
 }
 
-// TransactionMessageToMultisigTransactionMessageBytes converts a transaction message to bytes
+// TransactionMessageToMultisigTransactionMessageBytes converts a transaction message to bytes.
+// The account keys are ordered like the TypeScript SDK does, so every member compiles the same bytes.
 func TransactionMessageToMultisigTransactionMessageBytes(message TransactionMessage,
 	addressLookupTableAccounts []addresslookuptable.KeyedAddressLookupTable) ([]byte, error) {
+	txMsg := compileMultisigTransactionMessage(message, addressLookupTableAccounts)
+
+	// encode custom
+	buf := new(bytes.Buffer)
+	if err := squads_multisig_program.NewEncoder(buf).Encode(&txMsg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// compileMultisigTransactionMessage compiles a transaction message into the format stored by the multisig program
+func compileMultisigTransactionMessage(message TransactionMessage,
+	addressLookupTableAccounts []addresslookuptable.KeyedAddressLookupTable) squads_multisig_program.TransactionMessage {
 	// Compile the message to V0 format
 	compiledMessage := CompileToWrappedMessageV0(message.PayerKey,
 		message.RecentBlockhash,
@@ -47,11 +61,22 @@ func TransactionMessageToMultisigTransactionMessageBytes(message TransactionMess
 			ReadonlyIndexes: squads_multisig_program.SmallVec[uint8, uint8]{Data: v.ReadonlyIndexes},
 		})
 	}
+	return txMsg
+}
 
-	// encode custom
-	buf := new(bytes.Buffer)
-	if err := squads_multisig_program.NewEncoder(buf).Encode(&txMsg); err != nil {
-		return nil, err
+// VaultTransactionMessageAccountMetas returns the account metas of the static account keys of a stored
+// vault transaction message, in the order the program expects them as remaining accounts.
+// Signers of the inner message are PDAs signed for by the program, so none of the metas are signers.
+func VaultTransactionMessageAccountMetas(message squads_multisig_program.VaultTransactionMessage) []*solana.AccountMeta {
+	metas := make([]*solana.AccountMeta, 0, len(message.AccountKeys))
+	for i, accountKey := range message.AccountKeys {
+		var isWritable bool
+		if i < int(message.NumSigners) {
+			isWritable = i < int(message.NumWritableSigners) // Writable signer
+		} else {
+			isWritable = i-int(message.NumSigners) < int(message.NumWritableNonSigners) // Writable non-signer
+		}
+		metas = append(metas, solana.NewAccountMeta(accountKey, isWritable, false))
 	}
-	return buf.Bytes(), nil
+	return metas
 }
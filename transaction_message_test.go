@@ -0,0 +1,73 @@
+package squads
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+)
+
+func Test_VaultTransactionMessageAccountMetas(t *testing.T) {
+	keys := make([]solana.PublicKey, 6)
+	for i := range keys {
+		keys[i] = solana.NewWallet().PublicKey()
+	}
+	// [writable signer, readonly signer, writable, writable, readonly, readonly]
+	message := squads_multisig_program.VaultTransactionMessage{
+		NumSigners:            2,
+		NumWritableSigners:    1,
+		NumWritableNonSigners: 2,
+		AccountKeys:           keys,
+	}
+	want := []bool{true, false, true, true, false, false}
+
+	metas := VaultTransactionMessageAccountMetas(message)
+	if len(metas) != len(keys) {
+		t.Fatalf("expected %d metas, got %d", len(keys), len(metas))
+	}
+	for i, meta := range metas {
+		if !meta.PublicKey.Equals(keys[i]) {
+			t.Fatalf("meta %d: unexpected key %s", i, meta.PublicKey)
+		}
+		if meta.IsWritable != want[i] {
+			t.Fatalf("meta %d: expected writable=%v", i, want[i])
+		}
+		if meta.IsSigner {
+			t.Fatalf("meta %d: must not be a signer", i)
+		}
+	}
+}
+
+func Test_TransactionMessageWithLookupTable(t *testing.T) {
+	vault := solana.NewWallet().PublicKey()
+	writable := solana.NewWallet().PublicKey()
+	readonly := solana.NewWallet().PublicKey()
+	programID := solana.NewWallet().PublicKey()
+	ix := solana.NewInstruction(programID, solana.AccountMetaSlice{
+		solana.NewAccountMeta(vault, true, true),
+		solana.NewAccountMeta(writable, true, false),
+		solana.NewAccountMeta(readonly, false, false),
+	}, []byte{1})
+
+	table := addresslookuptable.KeyedAddressLookupTable{
+		Key: solana.NewWallet().PublicKey(),
+		State: addresslookuptable.AddressLookupTableState{
+			Addresses: solana.PublicKeySlice{programID, readonly, writable},
+		},
+	}
+	message := CompileToWrappedMessageV0(vault, solana.Hash{}, []solana.Instruction{ix}, []addresslookuptable.KeyedAddressLookupTable{table})
+
+	if len(message.AddressTableLookups) != 1 {
+		t.Fatalf("expected 1 address table lookup, got %d", len(message.AddressTableLookups))
+	}
+	lookup := message.AddressTableLookups[0]
+	// like the TypeScript SDK, the invoked program is loaded from the table too
+	if !bytes.Equal(lookup.WritableIndexes, []uint8{2}) || !bytes.Equal(lookup.ReadonlyIndexes, []uint8{0, 1}) {
+		t.Fatalf("unexpected lookup indexes: writable=%v readonly=%v", lookup.WritableIndexes, lookup.ReadonlyIndexes)
+	}
+	if len(message.AccountKeys) != 1 || !message.AccountKeys[0].Equals(vault) {
+		t.Fatalf("unexpected static account keys: %v", message.AccountKeys)
+	}
+}
@@ -0,0 +1,295 @@
+package squads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// MaxComputeUnitLimit is the largest compute unit limit a transaction can request
+	MaxComputeUnitLimit = 1_400_000
+	// DefaultPriorityFeePercentile is the percentile of recent prioritization fees used by auto mode
+	DefaultPriorityFeePercentile = 75
+	// DefaultComputeUnitMargin is the fraction added to the simulated compute units by auto mode
+	DefaultComputeUnitMargin = 0.1
+	// MaxTransactionSize is the packet limit of a serialized transaction, signatures included
+	MaxTransactionSize = 1232
+)
+
+// ErrAutoComputeBudgetUnsupported is returned when auto compute budget is requested with a client
+// that cannot simulate transactions or report recent prioritization fees
+var ErrAutoComputeBudgetUnsupported = errors.New("client does not support simulateTransaction and getRecentPrioritizationFees")
+
+// TxOption configures the transactions returned by the *Tx builders
+type TxOption func(*txOptions)
+
+type txOptions struct {
+	computeUnitPrice *uint64
+	computeUnitLimit *uint32
+	auto             *AutoComputeBudget
+	nonces           []DurableNonce
+}
+
+// AutoComputeBudget sizes the compute budget of a transaction from the cluster.
+// The limit is the compute units consumed by a simulation plus Margin,
+// the price is the Percentile of the recent prioritization fees paid for the writable accounts of the transaction,
+// clamped to [MinPrice, MaxPrice] (MaxPrice 0 means no maximum).
+// A simulation failure is returned as an error: transactions depending on an earlier one
+// of the same sequence cannot be simulated before it lands, use a fixed limit for them.
+type AutoComputeBudget struct {
+	Percentile float64
+	Margin     float64
+	MinPrice   uint64
+	MaxPrice   uint64
+}
+
+// PriorityFeeClient is the RPC API used by auto compute budget, *rpc.Client implements it
+type PriorityFeeClient interface {
+	SimulateTransactionWithOpts(ctx context.Context, transaction *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResponse, error)
+	GetRecentPrioritizationFees(ctx context.Context, accounts solana.PublicKeySlice) ([]rpc.PriorizationFeeResult, error)
+}
+
+var _ PriorityFeeClient = (*rpc.Client)(nil)
+
+// WithComputeUnitPrice sets the compute unit price, in micro-lamports, with a SetComputeUnitPrice instruction.
+// It overrides the price chosen by WithAutoComputeBudget.
+func WithComputeUnitPrice(microLamports uint64) TxOption {
+	return func(o *txOptions) {
+		o.computeUnitPrice = &microLamports
+	}
+}
+
+// WithComputeUnitLimit sets the compute unit limit with a SetComputeUnitLimit instruction.
+// It overrides the limit chosen by WithAutoComputeBudget.
+func WithComputeUnitLimit(units uint32) TxOption {
+	return func(o *txOptions) {
+		o.computeUnitLimit = &units
+	}
+}
+
+// WithAutoComputeBudget sizes the compute unit limit and price from the cluster, see AutoComputeBudget.
+// A nil auto uses DefaultPriorityFeePercentile and DefaultComputeUnitMargin.
+// The client of the builder must implement PriorityFeeClient.
+func WithAutoComputeBudget(auto *AutoComputeBudget) TxOption {
+	return func(o *txOptions) {
+		o.auto = &AutoComputeBudget{Percentile: DefaultPriorityFeePercentile, Margin: DefaultComputeUnitMargin}
+		if auto != nil {
+			o.auto = auto
+		}
+	}
+}
+
+// txBuilder compiles the instructions of a builder into transactions according to the TxOptions.
+// The blockhash is fetched once, so all the transactions of a sequence share it,
+// with durable nonces each transaction takes the next nonce.
+type txBuilder struct {
+	client      RPCClient
+	opts        txOptions
+	blockhash   solana.Hash
+	nonceValues []solana.Hash
+	built       int
+}
+
+func newTxBuilder(ctx context.Context, client RPCClient, opts []TxOption) (*txBuilder, error) {
+	b := &txBuilder{client: client}
+	for _, opt := range opts {
+		opt(&b.opts)
+	}
+	if b.opts.auto != nil {
+		if _, ok := client.(PriorityFeeClient); !ok {
+			return nil, ErrAutoComputeBudgetUnsupported
+		}
+	}
+	if len(b.opts.nonces) > 0 {
+		var err error
+		if b.nonceValues, err = fetchNonces(ctx, client, b.opts.nonces); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	recent, err := client.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, err
+	}
+	b.blockhash = recent.Value.Blockhash
+	return b, nil
+}
+
+// buildTransaction compiles a single transaction, see txBuilder
+func buildTransaction(ctx context.Context, client RPCClient, ixs []solana.Instruction, payer solana.PublicKey, opts []TxOption, txOpts ...solana.TransactionOption) (*solana.Transaction, error) {
+	b, err := newTxBuilder(ctx, client, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.build(ctx, ixs, payer, txOpts...)
+}
+
+func (b *txBuilder) build(ctx context.Context, ixs []solana.Instruction, payer solana.PublicKey, txOpts ...solana.TransactionOption) (*solana.Transaction, error) {
+	txOpts = append([]solana.TransactionOption{solana.TransactionPayer(payer)}, txOpts...)
+	blockhash, advance := b.blockhash, []solana.Instruction(nil)
+	if len(b.opts.nonces) > 0 {
+		if b.built >= len(b.opts.nonces) {
+			return nil, fmt.Errorf("transaction %d of the sequence has no durable nonce, pass one WithDurableNonce per transaction", b.built+1)
+		}
+		nonce := b.opts.nonces[b.built]
+		blockhash = b.nonceValues[b.built]
+		advance = []solana.Instruction{AdvanceNonceAccountIx(nonce.Account, nonce.Authority)}
+	}
+	b.built++
+	price, limit := b.opts.computeUnitPrice, b.opts.computeUnitLimit
+	if auto := b.opts.auto; auto != nil && (price == nil || limit == nil) {
+		client := b.client.(PriorityFeeClient)
+		if limit == nil {
+			units, err := b.simulateComputeUnits(ctx, client, advance, ixs, blockhash, txOpts)
+			if err != nil {
+				return nil, err
+			}
+			limit = &units
+		}
+		if price == nil {
+			microLamports, err := recentPriorityFee(ctx, client, ixs, auto)
+			if err != nil {
+				return nil, err
+			}
+			price = &microLamports
+		}
+	}
+	// AdvanceNonceAccount must be the first instruction
+	return solana.NewTransaction(append(advance, withComputeBudget(ixs, price, limit)...), blockhash, txOpts...)
+}
+
+// maxSize returns the largest serialized size, signatures included, of the transactions the builder
+// can build from ixs whichever durable nonce they take. It neither simulates nor takes a nonce:
+// compute budget instructions have the same size whatever their values.
+func (b *txBuilder) maxSize(ixs []solana.Instruction, payer solana.PublicKey) (int, error) {
+	var price *uint64
+	var limit *uint32
+	if b.opts.computeUnitPrice != nil || b.opts.auto != nil {
+		price = new(uint64)
+	}
+	if b.opts.computeUnitLimit != nil || b.opts.auto != nil {
+		limit = new(uint32)
+	}
+	advances := [][]solana.Instruction{nil}
+	if len(b.opts.nonces) > 0 {
+		advances = advances[:0]
+		for _, nonce := range b.opts.nonces {
+			advances = append(advances, []solana.Instruction{AdvanceNonceAccountIx(nonce.Account, nonce.Authority)})
+		}
+	}
+	size := 0
+	for _, advance := range advances {
+		tx, err := solana.NewTransaction(append(advance, withComputeBudget(ixs, price, limit)...), solana.Hash{}, solana.TransactionPayer(payer))
+		if err != nil {
+			return 0, err
+		}
+		tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return 0, err
+		}
+		size = max(size, len(raw))
+	}
+	return size, nil
+}
+
+// withComputeBudget prepends the compute budget instructions to ixs
+func withComputeBudget(ixs []solana.Instruction, price *uint64, limit *uint32) []solana.Instruction {
+	var budget []solana.Instruction
+	if limit != nil {
+		budget = append(budget, computebudget.NewSetComputeUnitLimitInstruction(*limit).Build())
+	}
+	if price != nil {
+		budget = append(budget, computebudget.NewSetComputeUnitPriceInstruction(*price).Build())
+	}
+	if len(budget) == 0 {
+		return ixs
+	}
+	return append(budget, ixs...)
+}
+
+// simulateComputeUnits simulates the transaction with the maximum limit and a placeholder price,
+// so the compute budget instructions are accounted for, and adds the margin to the units consumed
+func (b *txBuilder) simulateComputeUnits(ctx context.Context, client PriorityFeeClient, advance, ixs []solana.Instruction, blockhash solana.Hash, txOpts []solana.TransactionOption) (uint32, error) {
+	maxLimit, placeholder := uint32(MaxComputeUnitLimit), uint64(1)
+	tx, err := solana.NewTransaction(append(advance, withComputeBudget(ixs, &placeholder, &maxLimit)...), blockhash, txOpts...)
+	if err != nil {
+		return 0, err
+	}
+	out, err := client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		Commitment:             rpc.CommitmentConfirmed,
+		ReplaceRecentBlockhash: true,
+	})
+	if err != nil {
+		if txErr, ok := DecodeTransactionError(err); ok {
+			return 0, txErr
+		}
+		return 0, fmt.Errorf("compute unit simulation: %w", err)
+	}
+	if out.Value == nil {
+		return 0, errors.New("compute unit simulation: empty result")
+	}
+	if out.Value.Err != nil {
+		if txErr, ok := DecodeSimulationError(out.Value); ok {
+			return 0, txErr
+		}
+		return 0, fmt.Errorf("compute unit simulation failed: %v\n%s", out.Value.Err, strings.Join(out.Value.Logs, "\n"))
+	}
+	if out.Value.UnitsConsumed == nil {
+		return 0, errors.New("compute unit simulation: units consumed not reported")
+	}
+	units := math.Ceil(float64(*out.Value.UnitsConsumed) * (1 + b.opts.auto.Margin))
+	return uint32(min(units, MaxComputeUnitLimit)), nil
+}
+
+// recentPriorityFee returns the percentile of the fees recently paid to write the accounts of ixs
+func recentPriorityFee(ctx context.Context, client PriorityFeeClient, ixs []solana.Instruction, auto *AutoComputeBudget) (uint64, error) {
+	fees, err := client.GetRecentPrioritizationFees(ctx, writableAccounts(ixs))
+	if err != nil {
+		return 0, fmt.Errorf("recent prioritization fees: %w", err)
+	}
+	values := make([]uint64, len(fees))
+	for i, fee := range fees {
+		values[i] = fee.PrioritizationFee
+	}
+	price := max(percentile(values, auto.Percentile), auto.MinPrice)
+	if auto.MaxPrice != 0 {
+		price = min(price, auto.MaxPrice)
+	}
+	return price, nil
+}
+
+// writableAccounts returns the distinct writable accounts of ixs, at most 128 as accepted by the RPC
+func writableAccounts(ixs []solana.Instruction) solana.PublicKeySlice {
+	var out solana.PublicKeySlice
+	for _, ix := range ixs {
+		for _, meta := range ix.Accounts() {
+			if meta.IsWritable && !out.Contains(meta.PublicKey) {
+				out = append(out, meta.PublicKey)
+			}
+		}
+	}
+	if len(out) > 128 {
+		out = out[:128]
+	}
+	return out
+}
+
+// percentile returns the nearest-rank percentile of values, 0 when empty
+func percentile(values []uint64, p float64) uint64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,134 @@
+package squads
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// computeBudgetOf decodes the compute budget instructions of tx
+func computeBudgetOf(t *testing.T, tx *solana.Transaction) (limit uint32, price uint64, count int) {
+	t.Helper()
+	for _, compiled := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(compiled.ProgramIDIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !programID.Equals(solana.ComputeBudget) {
+			continue
+		}
+		accounts, err := compiled.ResolveInstructionAccounts(&tx.Message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ix, err := computebudget.DecodeInstruction(accounts, compiled.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch inst := ix.Impl.(type) {
+		case *computebudget.SetComputeUnitLimit:
+			limit = inst.Units
+		case *computebudget.SetComputeUnitPrice:
+			price = inst.MicroLamports
+		}
+		count++
+	}
+	return limit, price, count
+}
+
+func Test_TxOptionsFixed(t *testing.T) {
+	voter := solana.NewWallet().PublicKey()
+	s := New(squadstest.NewClient(), solana.NewWallet().PublicKey())
+
+	tx, err := s.ProposalApproveTx(t.Context(), voter, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, count := computeBudgetOf(t, tx); count != 0 || len(tx.Message.Instructions) != 1 {
+		t.Fatal("compute budget instructions added without options")
+	}
+
+	tx, err = s.ProposalApproveTx(t.Context(), voter, 1, WithComputeUnitLimit(50_000), WithComputeUnitPrice(1_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit, price, count := computeBudgetOf(t, tx)
+	if count != 2 || limit != 50_000 || price != 1_000 || len(tx.Message.Instructions) != 3 {
+		t.Fatalf("unexpected compute budget: limit %d, price %d, %d instructions", limit, price, count)
+	}
+	if programID, _ := tx.Message.Program(tx.Message.Instructions[2].ProgramIDIndex); !programID.Equals(squads_multisig_program.ProgramID) {
+		t.Fatal("compute budget instructions must come first")
+	}
+}
+
+func Test_TxOptionsAuto(t *testing.T) {
+	client := squadstest.NewClient()
+	voter := solana.NewWallet().PublicKey()
+	multisigPda := solana.NewWallet().PublicKey()
+	s := New(client, multisigPda)
+	proposalPda, err := GetProposalPda(multisigPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.OnSimulate(func(tx *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResult, error) {
+		if opts.SigVerify || !opts.ReplaceRecentBlockhash {
+			t.Error("simulation must replace the blockhash without verifying signatures")
+		}
+		if limit, _, _ := computeBudgetOf(t, tx); limit != MaxComputeUnitLimit {
+			t.Errorf("simulated with limit %d", limit)
+		}
+		units := uint64(10_000)
+		return &rpc.SimulateTransactionResult{UnitsConsumed: &units}, nil
+	})
+	var requested solana.PublicKeySlice
+	client.OnPrioritizationFees(func(accounts solana.PublicKeySlice) ([]rpc.PriorizationFeeResult, error) {
+		requested = accounts
+		var fees []rpc.PriorizationFeeResult
+		for i := range uint64(10) {
+			fees = append(fees, rpc.PriorizationFeeResult{Slot: i, PrioritizationFee: (i + 1) * 100})
+		}
+		return fees, nil
+	})
+
+	tx, err := s.ProposalApproveTx(t.Context(), voter, 1, WithAutoComputeBudget(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit, price, _ := computeBudgetOf(t, tx)
+	if limit != 11_000 || price != 800 {
+		t.Fatalf("unexpected auto compute budget: limit %d, price %d", limit, price)
+	}
+	if len(requested) != 2 || !requested.Contains(voter) || !requested.Contains(proposalPda) {
+		t.Fatalf("fees requested for %v", requested)
+	}
+
+	// fixed values override auto mode, MaxPrice caps the price
+	tx, err = s.ProposalApproveTx(t.Context(), voter, 1, WithAutoComputeBudget(&AutoComputeBudget{Percentile: 100, MaxPrice: 500}), WithComputeUnitLimit(30_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit, price, _ := computeBudgetOf(t, tx); limit != 30_000 || price != 500 {
+		t.Fatalf("unexpected compute budget: limit %d, price %d", limit, price)
+	}
+
+	client.OnSimulate(func(tx *solana.Transaction, opts *rpc.SimulateTransactionOpts) (*rpc.SimulateTransactionResult, error) {
+		return &rpc.SimulateTransactionResult{
+			Err:  map[string]interface{}{"InstructionError": []interface{}{2, map[string]interface{}{"Custom": 6021}}},
+			Logs: []string{"Program " + squads_multisig_program.ProgramID.String() + " failed: custom program error: 0x1785"},
+		}, nil
+	})
+	if _, err := s.ProposalApproveTx(t.Context(), voter, 1, WithAutoComputeBudget(nil)); !errors.Is(err, ErrTimeLockNotReleased) {
+		t.Fatalf("expected ErrTimeLockNotReleased, got %v", err)
+	}
+
+	limited := New(struct{ RPCClient }{client}, multisigPda)
+	if _, err := limited.ProposalApproveTx(t.Context(), voter, 1, WithAutoComputeBudget(nil)); !errors.Is(err, ErrAutoComputeBudgetUnsupported) {
+		t.Fatalf("expected ErrAutoComputeBudgetUnsupported, got %v", err)
+	}
+}
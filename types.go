@@ -1,6 +1,11 @@
 package squads
 
-import "github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+)
 
 type Permission uint8
 
@@ -26,6 +31,40 @@ const (
 	ProposalStatusCancelled
 )
 
+func (s ProposalStatus) String() string {
+	switch s {
+	case ProposalStatusDraft:
+		return "Draft"
+	case ProposalStatusActive:
+		return "Active"
+	case ProposalStatusRejected:
+		return "Rejected"
+	case ProposalStatusApproved:
+		return "Approved"
+	case ProposalStatusExecuting:
+		return "Executing"
+	case ProposalStatusExecuted:
+		return "Executed"
+	case ProposalStatusCancelled:
+		return "Cancelled"
+	}
+	return fmt.Sprintf("ProposalStatus(%d)", uint8(s))
+}
+
+func (s ProposalStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *ProposalStatus) UnmarshalText(text []byte) error {
+	for status := ProposalStatusDraft; status <= ProposalStatusCancelled; status++ {
+		if status.String() == string(text) {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown proposal status %q", text)
+}
+
 func GetProposalStatus(status squads_multisig_program.ProposalStatus) ProposalStatus {
 	switch status.(type) {
 	case *squads_multisig_program.ProposalStatusDraft:
@@ -45,3 +84,21 @@ func GetProposalStatus(status squads_multisig_program.ProposalStatus) ProposalSt
 	}
 	return ProposalStatusDraft
 }
+
+// String returns the permissions as a comma separated list, e.g. "initiate, vote"
+func (p Permission) String() string {
+	var names []string
+	if p.Has(Initiate) {
+		names = append(names, "initiate")
+	}
+	if p.Has(Vote) {
+		names = append(names, "vote")
+	}
+	if p.Has(Execute) {
+		names = append(names, "execute")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,549 @@
+package squads
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+// VoteKind is the kind of vote a member cast on a proposal
+type VoteKind uint8
+
+const (
+	VoteApprove VoteKind = iota
+	VoteReject
+	VoteCancel
+)
+
+func (v VoteKind) String() string {
+	switch v {
+	case VoteApprove:
+		return "Approve"
+	case VoteReject:
+		return "Reject"
+	case VoteCancel:
+		return "Cancel"
+	}
+	return fmt.Sprintf("VoteKind(%d)", uint8(v))
+}
+
+// WatchEvent is an event emitted by a Watcher, one of *TransactionCreatedEvent, *ProposalStatusChangedEvent,
+// *VoteCastEvent, *ConfigChangedEvent, *AccountClosedEvent, *ConnectedEvent or *DisconnectedEvent
+type WatchEvent interface {
+	watchEvent()
+}
+
+// TransactionCreatedEvent is emitted when a vault transaction, config transaction or batch is created
+type TransactionCreatedEvent struct {
+	Slot             uint64
+	Address          solana.PublicKey
+	TransactionIndex uint64
+	// Kind is "vault", "config" or "batch"
+	Kind string
+}
+
+// ProposalStatusChangedEvent is emitted when a proposal is created or its status changes
+type ProposalStatusChangedEvent struct {
+	Slot             uint64
+	Address          solana.PublicKey
+	TransactionIndex uint64
+	// Created is set for new proposals, Previous is then meaningless
+	Created  bool
+	Previous ProposalStatus
+	Status   ProposalStatus
+	Proposal *squads_multisig_program.Proposal
+}
+
+// VoteCastEvent is emitted for every new approval, rejection or cancellation on a proposal
+type VoteCastEvent struct {
+	Slot             uint64
+	Address          solana.PublicKey
+	TransactionIndex uint64
+	Member           solana.PublicKey
+	Vote             VoteKind
+}
+
+// ConfigChangedEvent is emitted when the threshold, the members or the time lock of the multisig change
+type ConfigChangedEvent struct {
+	Slot             uint64
+	Previous         *squads_multisig_program.Multisig
+	Multisig         *squads_multisig_program.Multisig
+	ThresholdChanged bool
+	MembersChanged   bool
+	TimeLockChanged  bool
+}
+
+// AccountClosedEvent is emitted when a watched proposal, transaction or batch account is closed
+type AccountClosedEvent struct {
+	Slot             uint64
+	Address          solana.PublicKey
+	TransactionIndex uint64
+	// Kind is "proposal", "vault", "config" or "batch"
+	Kind string
+}
+
+// ConnectedEvent is emitted once the watcher subscribed and caught up with the accounts, after every (re)connection
+type ConnectedEvent struct {
+	// Slot is the slot the accounts were refetched at
+	Slot uint64
+}
+
+// DisconnectedEvent is emitted when the websocket connection fails, before the watcher reconnects
+type DisconnectedEvent struct {
+	Err error
+}
+
+func (*TransactionCreatedEvent) watchEvent()    {}
+func (*ProposalStatusChangedEvent) watchEvent() {}
+func (*VoteCastEvent) watchEvent()              {}
+func (*ConfigChangedEvent) watchEvent()         {}
+func (*AccountClosedEvent) watchEvent()         {}
+func (*ConnectedEvent) watchEvent()             {}
+func (*DisconnectedEvent) watchEvent()          {}
+
+// WatcherOpts configures a Watcher
+type WatcherOpts struct {
+	// Commitment of the subscriptions, rpc.CommitmentConfirmed when empty
+	Commitment rpc.CommitmentType
+	// ReconnectDelay is the first delay before reconnecting, doubled after every failed attempt; one second when zero
+	ReconnectDelay time.Duration
+	// MaxReconnectDelay caps the reconnect delay, 30 seconds when zero
+	MaxReconnectDelay time.Duration
+	// ResyncInterval refetches the watched accounts periodically, catching closed accounts the program
+	// subscription does not report. 30 seconds when zero, negative only refetches after (re)connecting.
+	ResyncInterval time.Duration
+	// EventBuffer is the capacity of the events channel, 64 when zero
+	EventBuffer int
+}
+
+// Watcher follows a multisig, its proposals and transactions over websocket subscriptions and emits typed events.
+// After every (re)connection it refetches the accounts over RPC so no change is missed while disconnected.
+type Watcher struct {
+	multisig   *Multisig
+	wsEndpoint string
+	opts       WatcherOpts
+	events     chan WatchEvent
+
+	// state, only touched by Run
+	synced     bool
+	config     *squads_multisig_program.Multisig
+	configSlot uint64
+	accounts   map[solana.PublicKey]*watchedAccount
+}
+
+type watchedAccount struct {
+	kind     string
+	index    uint64
+	slot     uint64
+	proposal *squads_multisig_program.Proposal
+}
+
+type accountUpdate struct {
+	slot    uint64
+	address solana.PublicKey
+	account *rpc.Account
+}
+
+// NewWatcher creates a watcher for the multisig, rpc calls go through client and subscriptions to wsEndpoint
+func NewWatcher(client RPCClient, wsEndpoint string, multisigPda solana.PublicKey, opts *WatcherOpts) *Watcher {
+	w := &Watcher{
+		multisig:   New(client, multisigPda),
+		wsEndpoint: wsEndpoint,
+		accounts:   make(map[solana.PublicKey]*watchedAccount),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Commitment == "" {
+		w.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if w.opts.ReconnectDelay <= 0 {
+		w.opts.ReconnectDelay = time.Second
+	}
+	if w.opts.MaxReconnectDelay <= 0 {
+		w.opts.MaxReconnectDelay = 30 * time.Second
+	}
+	if w.opts.ResyncInterval == 0 {
+		w.opts.ResyncInterval = 30 * time.Second
+	}
+	if w.opts.EventBuffer <= 0 {
+		w.opts.EventBuffer = 64
+	}
+	w.events = make(chan WatchEvent, w.opts.EventBuffer)
+	return w
+}
+
+// Events returns the channel events are delivered on. It is closed when Run returns.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Run connects, subscribes and emits events until ctx is done, reconnecting and resubscribing
+// whenever the connection fails. The accounts present when Run starts do not produce events.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+	delay := w.opts.ReconnectDelay
+	for {
+		connected, err := w.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			delay = w.opts.ReconnectDelay
+		}
+		if err := w.emit(ctx, &DisconnectedEvent{Err: err}); err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(delay*2, w.opts.MaxReconnectDelay)
+	}
+}
+
+// session runs a single websocket connection, it reports whether the subscriptions were established
+func (w *Watcher) session(ctx context.Context) (bool, error) {
+	client, err := ws.Connect(ctx, w.wsEndpoint)
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	accountSub, err := client.AccountSubscribe(w.multisig.multisigPda, w.opts.Commitment)
+	if err != nil {
+		return false, err
+	}
+	programSub, err := client.ProgramSubscribeWithOpts(squads_multisig_program.ProgramID, w.opts.Commitment, solana.EncodingBase64, []rpc.RPCFilter{
+		{Memcmp: &rpc.RPCFilterMemcmp{Offset: multisigFieldOffset, Bytes: w.multisig.multisigPda.Bytes()}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	updates := make(chan accountUpdate)
+	errs := make(chan error, 2)
+	forward := func(update accountUpdate) bool {
+		select {
+		case updates <- update:
+			return true
+		case <-sessionCtx.Done():
+			return false
+		}
+	}
+	go func() {
+		for {
+			res, err := accountSub.Recv(sessionCtx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			account := res.Value.Account
+			if !forward(accountUpdate{slot: res.Context.Slot, address: w.multisig.multisigPda, account: &account}) {
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			res, err := programSub.Recv(sessionCtx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !forward(accountUpdate{slot: res.Context.Slot, address: res.Value.Pubkey, account: res.Value.Account}) {
+				return
+			}
+		}
+	}()
+
+	// the subscriptions are buffered while the accounts are refetched, so nothing is missed in between
+	slot, err := w.sync(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := w.emit(ctx, &ConnectedEvent{Slot: slot}); err != nil {
+		return true, err
+	}
+
+	var resync <-chan time.Time
+	if w.opts.ResyncInterval > 0 {
+		ticker := time.NewTicker(w.opts.ResyncInterval)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case err := <-errs:
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return true, err
+		case update := <-updates:
+			if err := w.apply(ctx, update); err != nil {
+				return true, err
+			}
+		case <-resync:
+			if _, err := w.sync(ctx); err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+func (w *Watcher) emit(ctx context.Context, events ...WatchEvent) error {
+	for _, event := range events {
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// sync refetches the multisig and its accounts, emitting the changes made since they were last seen
+func (w *Watcher) sync(ctx context.Context) (uint64, error) {
+	out, err := w.multisig.client.GetAccountInfo(ctx, w.multisig.multisigPda)
+	if err != nil {
+		return 0, err
+	}
+	slot := out.Context.Slot
+	config := &squads_multisig_program.Multisig{}
+	if err := config.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(out.Value.Data.GetBinary())); err != nil {
+		return 0, err
+	}
+
+	vaultTransactions, err := w.multisig.VaultTransactions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	configTransactions, err := w.multisig.ConfigTransactions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	batches, err := w.multisig.Batches(ctx)
+	if err != nil {
+		return 0, err
+	}
+	proposals, err := w.multisig.Proposals(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var events []WatchEvent
+	events = append(events, w.updateConfig(slot, config)...)
+	seen := make(map[solana.PublicKey]bool)
+	for _, transaction := range vaultTransactions {
+		seen[transaction.Address] = true
+		events = append(events, w.updateTransaction(slot, transaction.Address, "vault", transaction.Account.Index)...)
+	}
+	for _, transaction := range configTransactions {
+		seen[transaction.Address] = true
+		events = append(events, w.updateTransaction(slot, transaction.Address, "config", transaction.Account.Index)...)
+	}
+	for _, batch := range batches {
+		seen[batch.Address] = true
+		events = append(events, w.updateTransaction(slot, batch.Address, "batch", batch.Account.Index)...)
+	}
+	for _, proposal := range proposals {
+		seen[proposal.Address] = true
+		events = append(events, w.updateProposal(slot, proposal.Address, proposal.Account)...)
+	}
+
+	var closed []solana.PublicKey
+	for address, watched := range w.accounts {
+		if !seen[address] && watched.slot <= slot {
+			closed = append(closed, address)
+		}
+	}
+	slices.SortFunc(closed, func(a, b solana.PublicKey) int {
+		return cmpWatched(w.accounts[a], w.accounts[b])
+	})
+	for _, address := range closed {
+		events = append(events, w.close(slot, address)...)
+	}
+
+	w.synced = true
+	return slot, w.emit(ctx, events...)
+}
+
+func cmpWatched(a, b *watchedAccount) int {
+	return cmp.Or(cmp.Compare(a.index, b.index), strings.Compare(a.kind, b.kind))
+}
+
+// apply handles a subscription notification
+func (w *Watcher) apply(ctx context.Context, update accountUpdate) error {
+	var data []byte
+	if update.account != nil && update.account.Data != nil {
+		data = update.account.Data.GetBinary()
+	}
+	closed := update.account == nil || update.account.Lamports == 0 || len(data) == 0
+
+	if update.address.Equals(w.multisig.multisigPda) {
+		if closed {
+			return nil
+		}
+		config := &squads_multisig_program.Multisig{}
+		if err := config.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(data)); err != nil {
+			return fmt.Errorf("decode multisig %s: %w", update.address, err)
+		}
+		return w.emit(ctx, w.updateConfig(update.slot, config)...)
+	}
+
+	if closed {
+		return w.emit(ctx, w.close(update.slot, update.address)...)
+	}
+
+	var events []WatchEvent
+	switch {
+	case bytes.HasPrefix(data, squads_multisig_program.ProposalDiscriminator[:]):
+		proposal := &squads_multisig_program.Proposal{}
+		if err := proposal.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(data)); err != nil {
+			return fmt.Errorf("decode proposal %s: %w", update.address, err)
+		}
+		events = w.updateProposal(update.slot, update.address, proposal)
+	case bytes.HasPrefix(data, squads_multisig_program.VaultTransactionDiscriminator[:]):
+		transaction := &squads_multisig_program.VaultTransaction{}
+		if err := transaction.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(data)); err != nil {
+			return fmt.Errorf("decode vault transaction %s: %w", update.address, err)
+		}
+		events = w.updateTransaction(update.slot, update.address, "vault", transaction.Index)
+	case bytes.HasPrefix(data, squads_multisig_program.ConfigTransactionDiscriminator[:]):
+		transaction, err := squads_multisig_program.DecodeConfigTransaction(data)
+		if err != nil {
+			return fmt.Errorf("decode config transaction %s: %w", update.address, err)
+		}
+		events = w.updateTransaction(update.slot, update.address, "config", transaction.Index)
+	case bytes.HasPrefix(data, squads_multisig_program.BatchDiscriminator[:]):
+		batch := &squads_multisig_program.Batch{}
+		if err := batch.UnmarshalWithDecoder(ag_binary.NewBorshDecoder(data)); err != nil {
+			return fmt.Errorf("decode batch %s: %w", update.address, err)
+		}
+		events = w.updateTransaction(update.slot, update.address, "batch", batch.Index)
+	}
+	return w.emit(ctx, events...)
+}
+
+func (w *Watcher) updateConfig(slot uint64, config *squads_multisig_program.Multisig) []WatchEvent {
+	previous := w.config
+	if previous != nil && slot < w.configSlot {
+		return nil
+	}
+	w.config, w.configSlot = config, slot
+	if previous == nil {
+		return nil
+	}
+
+	event := &ConfigChangedEvent{
+		Slot:             slot,
+		Previous:         previous,
+		Multisig:         config,
+		ThresholdChanged: previous.Threshold != config.Threshold,
+		MembersChanged: !slices.EqualFunc(previous.Members, config.Members, func(a, b squads_multisig_program.Member) bool {
+			return a.Key.Equals(b.Key) && a.Permissions.Mask == b.Permissions.Mask
+		}),
+		TimeLockChanged: previous.TimeLock != config.TimeLock,
+	}
+	if !event.ThresholdChanged && !event.MembersChanged && !event.TimeLockChanged {
+		return nil
+	}
+	return []WatchEvent{event}
+}
+
+func (w *Watcher) updateTransaction(slot uint64, address solana.PublicKey, kind string, index uint64) []WatchEvent {
+	if watched, ok := w.accounts[address]; ok {
+		watched.slot = max(watched.slot, slot)
+		return nil
+	}
+	w.accounts[address] = &watchedAccount{kind: kind, index: index, slot: slot}
+	if !w.synced {
+		return nil
+	}
+	return []WatchEvent{&TransactionCreatedEvent{Slot: slot, Address: address, TransactionIndex: index, Kind: kind}}
+}
+
+func (w *Watcher) updateProposal(slot uint64, address solana.PublicKey, proposal *squads_multisig_program.Proposal) []WatchEvent {
+	watched, ok := w.accounts[address]
+	if ok && slot < watched.slot {
+		return nil
+	}
+	w.accounts[address] = &watchedAccount{kind: "proposal", index: proposal.TransactionIndex, slot: slot, proposal: proposal}
+	if !w.synced {
+		return nil
+	}
+
+	var previous *squads_multisig_program.Proposal
+	if ok {
+		previous = watched.proposal
+	} else {
+		previous = &squads_multisig_program.Proposal{}
+	}
+	var events []WatchEvent
+	for _, vote := range []struct {
+		kind              VoteKind
+		previous, current []solana.PublicKey
+	}{
+		{VoteApprove, previous.Approved, proposal.Approved},
+		{VoteReject, previous.Rejected, proposal.Rejected},
+		{VoteCancel, previous.Cancelled, proposal.Cancelled},
+	} {
+		for _, member := range vote.current {
+			if !slices.Contains(vote.previous, member) {
+				events = append(events, &VoteCastEvent{
+					Slot:             slot,
+					Address:          address,
+					TransactionIndex: proposal.TransactionIndex,
+					Member:           member,
+					Vote:             vote.kind,
+				})
+			}
+		}
+	}
+
+	status := GetProposalStatus(proposal.Status)
+	if !ok || GetProposalStatus(previous.Status) != status {
+		event := &ProposalStatusChangedEvent{
+			Slot:             slot,
+			Address:          address,
+			TransactionIndex: proposal.TransactionIndex,
+			Created:          !ok,
+			Status:           status,
+			Proposal:         proposal,
+		}
+		if ok {
+			event.Previous = GetProposalStatus(previous.Status)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func (w *Watcher) close(slot uint64, address solana.PublicKey) []WatchEvent {
+	watched, ok := w.accounts[address]
+	if !ok || slot < watched.slot {
+		return nil
+	}
+	delete(w.accounts, address)
+	return []WatchEvent{&AccountClosedEvent{Slot: slot, Address: address, TransactionIndex: watched.index, Kind: watched.kind}}
+}
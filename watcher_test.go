@@ -0,0 +1,208 @@
+package squads
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Lee0x273/go-squads/generated/squads_multisig_program"
+	"github.com/Lee0x273/go-squads/squadstest"
+	"github.com/gagliardetto/solana-go"
+)
+
+func nextEvent[T WatchEvent](t *testing.T, events <-chan WatchEvent) T {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("events channel closed")
+		}
+		typed, ok := event.(T)
+		if !ok {
+			t.Fatalf("expected a %T, got %T %+v", typed, event, event)
+		}
+		return typed
+	case <-time.After(5 * time.Second):
+		var zero T
+		t.Fatalf("timed out waiting for a %T", zero)
+	}
+	panic("unreachable")
+}
+
+func Test_Watcher(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	a, b := testMember(Vote), testMember(Vote)
+	multisig := testMultisig(1, a, b)
+
+	setPda := func(pda solana.PublicKey, account interface{}) {
+		t.Helper()
+		if err := client.SetBorshAccount(pda, account); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transactionPda := func(index uint64) solana.PublicKey {
+		pda, err := GetTransactionPda(multisigPda, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pda
+	}
+	proposalPda := func(index uint64) solana.PublicKey {
+		pda, err := GetProposalPda(multisigPda, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pda
+	}
+
+	setPda(multisigPda, *multisig)
+	setPda(transactionPda(1), squads_multisig_program.VaultTransaction{Multisig: multisigPda, Index: 1})
+	setPda(proposalPda(1), squads_multisig_program.Proposal{
+		Multisig:         multisigPda,
+		TransactionIndex: 1,
+		Status:           &squads_multisig_program.ProposalStatusActive{},
+	})
+
+	server := squadstest.NewWebsocketServer(client)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	w := NewWatcher(client, server.URL(), multisigPda, &WatcherOpts{ReconnectDelay: 10 * time.Millisecond})
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	events := w.Events()
+
+	// the accounts present at start do not produce events
+	nextEvent[*ConnectedEvent](t, events)
+	if err := server.WaitForSubscriptions(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	setPda(proposalPda(1), squads_multisig_program.Proposal{
+		Multisig:         multisigPda,
+		TransactionIndex: 1,
+		Status:           &squads_multisig_program.ProposalStatusApproved{Timestamp: 1700000000},
+		Approved:         []solana.PublicKey{a.Key},
+	})
+	if err := server.Publish(proposalPda(1)); err != nil {
+		t.Fatal(err)
+	}
+	vote := nextEvent[*VoteCastEvent](t, events)
+	if vote.Member != a.Key || vote.Vote != VoteApprove || vote.TransactionIndex != 1 {
+		t.Fatalf("unexpected vote %+v", vote)
+	}
+	status := nextEvent[*ProposalStatusChangedEvent](t, events)
+	if status.Created || status.Previous != ProposalStatusActive || status.Status != ProposalStatusApproved {
+		t.Fatalf("unexpected status change %+v", status)
+	}
+
+	setPda(transactionPda(2), squads_multisig_program.VaultTransaction{Multisig: multisigPda, Index: 2})
+	if err := server.Publish(transactionPda(2)); err != nil {
+		t.Fatal(err)
+	}
+	created := nextEvent[*TransactionCreatedEvent](t, events)
+	if created.TransactionIndex != 2 || created.Kind != "vault" || created.Address != transactionPda(2) {
+		t.Fatalf("unexpected transaction %+v", created)
+	}
+
+	multisig.Threshold = 2
+	setPda(multisigPda, *multisig)
+	if err := server.Publish(multisigPda); err != nil {
+		t.Fatal(err)
+	}
+	config := nextEvent[*ConfigChangedEvent](t, events)
+	if !config.ThresholdChanged || config.MembersChanged || config.TimeLockChanged || config.Previous.Threshold != 1 {
+		t.Fatalf("unexpected config change %+v", config)
+	}
+
+	// changes made while disconnected are caught up after reconnecting
+	client.DeleteAccount(transactionPda(2))
+	setPda(proposalPda(2), squads_multisig_program.Proposal{
+		Multisig:         multisigPda,
+		TransactionIndex: 2,
+		Status:           &squads_multisig_program.ProposalStatusActive{},
+	})
+	server.DropConnections()
+
+	nextEvent[*DisconnectedEvent](t, events)
+	status = nextEvent[*ProposalStatusChangedEvent](t, events)
+	if !status.Created || status.TransactionIndex != 2 || status.Status != ProposalStatusActive {
+		t.Fatalf("unexpected status change %+v", status)
+	}
+	closed := nextEvent[*AccountClosedEvent](t, events)
+	if closed.Address != transactionPda(2) || closed.Kind != "vault" || closed.TransactionIndex != 2 {
+		t.Fatalf("unexpected closed account %+v", closed)
+	}
+	nextEvent[*ConnectedEvent](t, events)
+
+	// and the subscriptions are restored
+	if err := server.WaitForSubscriptions(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	setPda(proposalPda(2), squads_multisig_program.Proposal{
+		Multisig:         multisigPda,
+		TransactionIndex: 2,
+		Status:           &squads_multisig_program.ProposalStatusActive{},
+		Rejected:         []solana.PublicKey{b.Key},
+	})
+	if err := server.Publish(proposalPda(2)); err != nil {
+		t.Fatal(err)
+	}
+	vote = nextEvent[*VoteCastEvent](t, events)
+	if vote.Member != b.Key || vote.Vote != VoteReject || vote.TransactionIndex != 2 {
+		t.Fatalf("unexpected vote %+v", vote)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("unexpected run error %v", err)
+	}
+	for range events {
+	}
+}
+
+func Test_WatcherResyncReportsClosedAccounts(t *testing.T) {
+	client := squadstest.NewClient()
+	multisigPda := solana.NewWallet().PublicKey()
+	if err := client.SetBorshAccount(multisigPda, *testMultisig(1, testMember(Vote))); err != nil {
+		t.Fatal(err)
+	}
+	transactionPda, err := GetTransactionPda(multisigPda, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetBorshAccount(transactionPda, squads_multisig_program.VaultTransaction{Multisig: multisigPda, Index: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := squadstest.NewWebsocketServer(client)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	w := NewWatcher(client, server.URL(), multisigPda, &WatcherOpts{ResyncInterval: 20 * time.Millisecond})
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	events := w.Events()
+	nextEvent[*ConnectedEvent](t, events)
+
+	// the program subscription does not report closed accounts, the periodic resync does
+	client.DeleteAccount(transactionPda)
+	closed := nextEvent[*AccountClosedEvent](t, events)
+	if closed.Address != transactionPda || closed.Kind != "vault" || closed.TransactionIndex != 1 {
+		t.Fatalf("unexpected closed account %+v", closed)
+	}
+
+	cancel()
+	<-done
+	for range events {
+	}
+}
+
+func Test_WatcherDefaultResyncInterval(t *testing.T) {
+	w := NewWatcher(squadstest.NewClient(), "", solana.NewWallet().PublicKey(), nil)
+	if w.opts.ResyncInterval <= 0 {
+		t.Fatalf("closed accounts are only reported by a periodic resync, got interval %s", w.opts.ResyncInterval)
+	}
+}